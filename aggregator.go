@@ -0,0 +1,118 @@
+package imagesearch
+
+import (
+    "context"
+    "crypto/sha256"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// An Aggregator is an Engine that searches several engines at once and merges their results, which gives far better coverage for rare queries than any single engine.
+// Images are interleaved by their rank in each engine, so the most relevant image of every engine comes first, and images found by more than one engine are only kept once.
+type Aggregator struct {
+    engines []Engine
+}
+
+// Returns a constructor of an Aggregator of the given engines, to be passed into WithEngine. For example:
+//	client := imagesearch.NewClient(imagesearch.WithEngine(imagesearch.Aggregate(imagesearch.Google, imagesearch.Bing, imagesearch.DuckDuckGo)))
+func Aggregate(engines ...func(*Client) Engine) func(*Client) Engine {
+    return func(c *Client) Engine {
+        aggregator := &Aggregator{}
+        for _, newEngine := range engines {
+            aggregator.engines = append(aggregator.engines, newEngine(c))
+        }
+        return aggregator
+    }
+}
+
+// Creates an Aggregator of engines that were already created.
+func NewAggregator(engines ...Engine) *Aggregator {
+    return &Aggregator{engines: engines}
+}
+
+// Returns the names of the aggregated engines joined by +, like "google+bing".
+func (a *Aggregator) Name() string {
+    names := make([]string, len(a.engines))
+    for i, engine := range a.engines {
+        names[i] = engine.Name()
+    }
+    return strings.Join(names, "+")
+}
+
+// Searches every engine concurrently and merges their images. Engines that fail are left out, and an error is only returned if every engine fails.
+func (a *Aggregator) Search(ctx context.Context, query Query) ([]Image, error) {
+    found := make([][]Image, len(a.engines))
+    errs := make([]error, len(a.engines))
+
+    var wg sync.WaitGroup
+    for i, engine := range a.engines {
+        wg.Add(1)
+        go func(i int, engine Engine) {
+            defer wg.Done()
+            found[i], errs[i] = engine.Search(ctx, query)
+        }(i, engine)
+    }
+    wg.Wait()
+
+    type ranked struct {
+        image  Image
+        rank   int
+        engine int
+    }
+    var merged []ranked
+    var firstErr error
+    failed := 0
+    for i, images := range found {
+        if errs[i] != nil {
+            failed++
+            if firstErr == nil {
+                firstErr = fmt.Errorf("%s: %w", a.engines[i].Name(), errs[i])
+            }
+            continue
+        }
+        for j, image := range images {
+            rank := image.Rank
+            if rank == 0 {
+                rank = j + 1
+            }
+            if image.Engine == "" {
+                image.Engine = a.engines[i].Name()
+            }
+            merged = append(merged, ranked{image: image, rank: rank, engine: i})
+        }
+    }
+    if len(a.engines) > 0 && failed == len(a.engines) {
+        return nil, firstErr
+    }
+
+    sort.SliceStable(merged, func(i, j int) bool {
+        if merged[i].rank != merged[j].rank {
+            return merged[i].rank < merged[j].rank
+        }
+        return merged[i].engine < merged[j].engine
+    })
+
+    seen := map[string]bool{}
+    images := make([]Image, 0, len(merged))
+    for _, m := range merged {
+        keys := []string{"url " + dedupeKey(m.image.Url)}
+        // Identical thumbnails are the same image, even if it is hosted at different urls
+        if len(m.image.ThumbnailData) > 0 {
+            keys = append(keys, fmt.Sprintf("content %x", sha256.Sum256(m.image.ThumbnailData)))
+        }
+        duplicate := false
+        for _, key := range keys {
+            duplicate = duplicate || seen[key]
+            seen[key] = true
+        }
+        if duplicate {
+            continue
+        }
+
+        m.image.Rank = len(images) + 1
+        images = append(images, m.image)
+    }
+    return images, nil
+}
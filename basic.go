@@ -0,0 +1,125 @@
+package imagesearch
+
+import (
+    "html"
+    "net/url"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// An old mobile browser, which Google serves the basic results page to.
+const basicUserAgent = "Mozilla/5.0 (Linux; U; Android 4.0.3; en-us; GT-I9100 Build/IML74K) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Mobile Safari/534.30"
+
+var basicStrategies = []strategy{
+    {"imgres", imgresStrategy},
+    {"basic", basicStrategy},
+}
+
+var (
+    hrefPattern = regexp.MustCompile(`href="([^"]+)"`)
+    srcPattern  = regexp.MustCompile(`<img[^>]+src="(https?://[^"]+)"`)
+)
+
+// Reads the /imgres links of the basic page, which contain both the full size image url and the url of its source.
+//	<a href="/imgres?imgurl=https://example.com/image.png&amp;imgrefurl=https://example.com/article&amp;...">
+func imgresStrategy(page *resultsPage, diagnostics *Diagnostics) []Image {
+    var images []Image
+    var links int
+    for _, match := range hrefPattern.FindAllStringSubmatch(page.html, -1) {
+        href := html.UnescapeString(match[1])
+        if !strings.HasPrefix(href, "/imgres?") {
+            continue
+        }
+        links++
+
+        values, err := url.ParseQuery(strings.TrimPrefix(href, "/imgres?"))
+        if err != nil {
+            diagnostics.warn(links-1, "invalid /imgres query: %v", err)
+            continue
+        }
+        if values.Get("imgurl") == "" {
+            diagnostics.warn(links-1, "/imgres link has no imgurl")
+            continue
+        }
+        image := Image{Url: values.Get("imgurl"), Source: values.Get("imgrefurl")}
+        image.Width, _ = strconv.Atoi(values.Get("w"))
+        image.Height, _ = strconv.Atoi(values.Get("h"))
+        if source, err := url.Parse(image.Source); err == nil {
+            image.Base = strings.TrimPrefix(source.Hostname(), "www.")
+        }
+        images = append(images, image)
+    }
+
+    if links == 0 {
+        diagnostics.fail(StageIndex, errUnpack, "no /imgres links")
+        return nil
+    }
+    diagnostics.pass(StageIndex, "%d /imgres links", links)
+    if len(images) == 0 {
+        diagnostics.fail(StageFields, errUnpack, "no imgurl in %d /imgres links", links)
+        return nil
+    }
+    diagnostics.pass(StageFields, "%d images", len(images))
+
+    return images
+}
+
+// Pairs every /url?q= link of the basic page with the first image inside it. These pages only link the thumbnail Google hosts,
+// so the results are marked as BestEffort, but the source urls are accurate.
+//	<a href="/url?q=https://example.com/article&amp;sa=U&amp;..."><img src="https://encrypted-tbn0.gstatic.com/images?q=tbn:..."></a>
+func basicStrategy(page *resultsPage, diagnostics *Diagnostics) []Image {
+    var images []Image
+    var links int
+    rest := page.html
+    for {
+        start := strings.Index(rest, `href="/url?`)
+        if start == -1 {
+            break
+        }
+        rest = rest[start+len(`href="/url?`):]
+        end := strings.Index(rest, `"`)
+        if end == -1 {
+            break
+        }
+        links++
+
+        values, err := url.ParseQuery(html.UnescapeString(rest[:end]))
+        if err != nil {
+            diagnostics.warn(links-1, "invalid /url query: %v", err)
+            continue
+        }
+        source := values.Get("q")
+        if source == "" {
+            continue
+        }
+
+        closing := strings.Index(rest, "</a>")
+        if closing == -1 {
+            break
+        }
+        img := srcPattern.FindStringSubmatch(rest[:closing])
+        if img == nil {
+            continue
+        }
+
+        image := Image{Url: html.UnescapeString(img[1]), Source: source, BestEffort: true}
+        if parsed, err := url.Parse(source); err == nil {
+            image.Base = strings.TrimPrefix(parsed.Hostname(), "www.")
+        }
+        images = append(images, image)
+    }
+
+    if links == 0 {
+        diagnostics.fail(StageIndex, errUnpack, "no /url links")
+        return nil
+    }
+    diagnostics.pass(StageIndex, "%d /url links", links)
+    if len(images) == 0 {
+        diagnostics.fail(StageFields, errUnpack, "no images in %d /url links", links)
+        return nil
+    }
+    diagnostics.pass(StageFields, "%d best effort images", len(images))
+
+    return images
+}
@@ -0,0 +1,99 @@
+package imagesearch
+
+import (
+    "context"
+    "sync"
+)
+
+// A search to run as part of a batch, see BatchSearch.
+type BatchQuery struct {
+    // Identifies the search in the results of the batch. Defaults to Query, so set it when searching for the same query more than once, like with different engines.
+    Key string
+
+    Query     string
+    Limit     int
+    Arguments []string
+
+    // Constructor of the engine to search with, like Bing. nil searches with the Client's engine.
+    Engine func(*Client) Engine
+}
+
+// The outcome of a single search of a batch.
+type BatchResult struct {
+    Results Results
+    Err     error
+}
+
+// Limits shared by every search of a batch.
+type BatchLimits struct {
+    // Most searches running at once. Below 1 runs one search at a time.
+    Concurrency int
+
+    // Most searches started per second with each engine, on average. Searches with different engines are limited separately, so each engine only sees this rate. 0 means no limit.
+    Rate float64
+
+    // Most searches started at once with each engine before Rate applies. Below 1 means 1.
+    Burst int
+}
+
+// Runs many searches concurrently under shared limits, and returns the results and error of each search by its key. Searches that weren't started before the context was done fail with the context's error.
+func BatchSearch(ctx context.Context, queries []BatchQuery, limits BatchLimits) map[string]BatchResult {
+    return defaultClient.BatchSearch(ctx, queries, limits)
+}
+
+// Runs many searches concurrently under shared limits, and returns the results and error of each search by its key. Searches that weren't started before the context was done fail with the context's error.
+// Every search goes through the Client, sharing its configuration and cache.
+func (c *Client) BatchSearch(ctx context.Context, queries []BatchQuery, limits BatchLimits) map[string]BatchResult {
+    concurrency := limits.Concurrency
+    if concurrency < 1 {
+        concurrency = 1
+    }
+
+    var mu sync.Mutex
+    results := make(map[string]BatchResult, len(queries))
+    limiters := map[string]*limiter{}
+    limiterFor := func(engine string) *limiter {
+        mu.Lock()
+        defer mu.Unlock()
+        if limiters[engine] == nil && limits.Rate > 0 {
+            limiters[engine] = newLimiter(limits.Rate, limits.Burst)
+        }
+        return limiters[engine]
+    }
+
+    semaphore := make(chan struct{}, concurrency)
+    var wg sync.WaitGroup
+    for _, query := range queries {
+        key := query.Key
+        if key == "" {
+            key = query.Query
+        }
+
+        client := c
+        if query.Engine != nil {
+            client = c.With(WithEngine(query.Engine))
+        }
+
+        wg.Add(1)
+        semaphore <- struct{}{}
+        go func(key string, query BatchQuery, client *Client) {
+            defer wg.Done()
+            defer func() { <-semaphore }()
+
+            var result BatchResult
+            if result.Err = limiterFor(client.engine.Name()).wait(ctx); result.Err == nil {
+                result.Results, result.Err = client.Search(ctx, query.Query, query.Limit, query.Arguments...)
+            }
+
+            mu.Lock()
+            results[key] = result
+            mu.Unlock()
+        }(key, query, client)
+    }
+    wg.Wait()
+
+    if c.webhook != "" {
+        c.notifyBatch(results)
+    }
+    return results
+}
@@ -0,0 +1,138 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "html"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// Returns the Bing Images engine, which makes its requests through the given Client.
+// Bing supports most of the arguments Google does, and arguments it has no equivalent for, like Format, are ignored.
+func Bing(c *Client) Engine {
+    return &bing{client: c}
+}
+
+// Searches Bing Images by reading the metadata of the result links on its results page.
+type bing struct {
+    client *Client
+}
+
+func (b *bing) Name() string {
+    return "bing"
+}
+
+func (b *bing) Search(ctx context.Context, query Query) ([]Image, error) {
+    page, final, err := b.client.getPage(ctx, buildBingUrl(query), userAgent)
+    if err != nil {
+        return nil, err
+    }
+
+    images, ok := parseBingPage(page)
+    if !ok {
+        return nil, newEngineUnpackError("bing", query.Text, final, page, "iusc result links")
+    }
+    return images, nil
+}
+
+// The filterui filters of Bing equivalent to the arguments, which are passed in the qft parameter.
+var bingFilters = map[string]string{
+    Color.Red:    "color2-FGcls_RED",
+    Color.Orange: "color2-FGcls_ORANGE",
+    Color.Yellow: "color2-FGcls_YELLOW",
+    Color.Green:  "color2-FGcls_GREEN",
+    Color.Teal:   "color2-FGcls_TEAL",
+    Color.Blue:   "color2-FGcls_BLUE",
+    Color.Purple: "color2-FGcls_PURPLE",
+    Color.Pink:   "color2-FGcls_PINK",
+    Color.White:  "color2-FGcls_WHITE",
+    Color.Gray:   "color2-FGcls_GRAY",
+    Color.Black:  "color2-FGcls_BLACK",
+    Color.Brown:  "color2-FGcls_BROWN",
+
+    ColorType.Color:       "color2-color",
+    ColorType.Grayscale:   "color2-bw",
+    ColorType.Transparent: "photo-transparent",
+
+    License.CreativeCommons: "license-L2_L3_L4_L5_L6_L7",
+
+    Type.Face:     "face-face",
+    Type.Photo:    "photo-photo",
+    Type.Clipart:  "photo-clipart",
+    Type.Lineart:  "photo-linedrawing",
+    Type.Animated: "photo-animatedgif",
+
+    Time.PastDay:   "age-lt1440",
+    Time.PastWeek:  "age-lt10080",
+    Time.PastMonth: "age-lt43200",
+    Time.PastYear:  "age-lt525600",
+
+    AspectRatio.Tall:   "aspect-tall",
+    AspectRatio.Square: "aspect-square",
+    AspectRatio.Wide:   "aspect-wide",
+
+    Size.Large:  "imagesize-large",
+    Size.Medium: "imagesize-medium",
+    Size.Icon:   "imagesize-small",
+}
+
+func buildBingUrl(query Query) string {
+    u := "https://www.bing.com/images/search?q=" + url.QueryEscape(query.Text) + "&first=1"
+
+    var filters string
+    for _, argument := range query.Arguments {
+        if filter, ok := bingFilters[argument]; ok {
+            filters += "+filterui:" + filter
+        }
+    }
+    if filters != "" {
+        u += "&qft=" + url.QueryEscape(filters)
+    }
+
+    return u
+}
+
+var (
+    // Every result on the page is a link with the iusc class, whose m attribute holds the metadata of the result as html escaped json
+    bingLinkPattern = regexp.MustCompile(`<a\s[^>]*class="[^"]*\biusc\b[^"]*"[^>]*>`)
+    bingMetaPattern = regexp.MustCompile(`\sm="([^"]*)"`)
+)
+
+// The metadata Bing keeps in the m attribute of a result.
+type bingMeta struct {
+    // Media url, the full size image
+    Murl string `json:"murl"`
+    // Page url, the page the image was found on
+    Purl string `json:"purl"`
+    // Title and description of the page
+    T    string `json:"t"`
+    Desc string `json:"desc"`
+    // Id of the image
+    Mid string `json:"mid"`
+}
+
+// Extracts the images from a Bing results page. Returns false if the page has no result links and doesn't say there are no results either.
+func parseBingPage(page string) ([]Image, bool) {
+    links := bingLinkPattern.FindAllString(page, -1)
+    if len(links) == 0 {
+        return []Image{}, hasNoResults(page) || strings.Contains(page, "There are no results for")
+    }
+
+    images := []Image{}
+    for _, link := range links {
+        m := bingMetaPattern.FindStringSubmatch(link)
+        if m == nil {
+            continue
+        }
+
+        raw := []byte(html.UnescapeString(m[1]))
+        var meta bingMeta
+        if err := json.Unmarshal(raw, &meta); err != nil || meta.Murl == "" {
+            continue
+        }
+        images = append(images, Image{Url: meta.Murl, Source: meta.Purl, Title: meta.T, Description: meta.Desc, Id: meta.Mid, Raw: raw})
+    }
+    return images, len(images) > 0
+}
@@ -0,0 +1,105 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// Returns a constructor of the Brave Search engine, to be passed into WithEngine.
+// With an apiKey for the Brave Search API the engine uses the API, which is the way to search if you can't scrape search engines for legal or contractual reasons.
+// With an empty apiKey it scrapes the results page instead, and the images are marked as BestEffort.
+// Brave doesn't support any of the arguments, so they are ignored.
+func Brave(apiKey string) func(*Client) Engine {
+    return func(c *Client) Engine {
+        return &brave{client: c, apiKey: apiKey}
+    }
+}
+
+// Searches Brave Search's image results, through the API if there is a key.
+type brave struct {
+    client *Client
+    apiKey string
+}
+
+func (b *brave) Name() string {
+    return "brave"
+}
+
+func (b *brave) Search(ctx context.Context, query Query) ([]Image, error) {
+    if b.apiKey == "" {
+        return b.scrape(ctx, query)
+    }
+
+    var response struct {
+        Results []json.RawMessage `json:"results"`
+    }
+    header := http.Header{"Accept": {"application/json"}, "X-Subscription-Token": {b.apiKey}}
+    err := b.client.getJSON(ctx, "https://api.search.brave.com/res/v1/images/search?count=100&q="+url.QueryEscape(query.Text), header, &response)
+    if err != nil {
+        return nil, err
+    }
+
+    images := make([]Image, 0, len(response.Results))
+    for _, raw := range response.Results {
+        var result struct {
+            Title string `json:"title"`
+            // Page the image was found on
+            Url        string `json:"url"`
+            Properties struct {
+                // Full size image
+                Url string `json:"url"`
+            } `json:"properties"`
+        }
+        if err := json.Unmarshal(raw, &result); err != nil || result.Properties.Url == "" {
+            continue
+        }
+        images = append(images, Image{Url: result.Properties.Url, Source: result.Url, Title: result.Title, Raw: raw})
+    }
+    return images, nil
+}
+
+var (
+    // The results page serializes every result as a javascript object, with the full size image in properties
+    braveImagePattern = regexp.MustCompile(`properties:\{url:"((?:[^"\\]|\\.)+)"`)
+    braveFieldPattern = regexp.MustCompile(`(title|url):"((?:[^"\\]|\\.)*)"`)
+)
+
+// Searches by scraping the image results page, reading the title and page of each image from the fields right before its properties.
+func (b *brave) scrape(ctx context.Context, query Query) ([]Image, error) {
+    page, final, err := b.client.getPage(ctx, "https://search.brave.com/images?q="+url.QueryEscape(query.Text), userAgent)
+    if err != nil {
+        return nil, err
+    }
+
+    matches := braveImagePattern.FindAllStringSubmatchIndex(page, -1)
+    if len(matches) == 0 {
+        if hasNoResults(page) {
+            return []Image{}, nil
+        }
+        return nil, newEngineUnpackError("brave", query.Text, final, page, "image result properties")
+    }
+
+    images := make([]Image, 0, len(matches))
+    previous := 0
+    for _, match := range matches {
+        image := Image{Url: unquote(page[match[2]:match[3]]), BestEffort: true}
+        for _, field := range braveFieldPattern.FindAllStringSubmatch(page[previous:match[0]], -1) {
+            switch field[1] {
+            case "title":
+                image.Title = unquote(field[2])
+            case "url":
+                image.Source = unquote(field[2])
+            }
+        }
+        previous = match[1]
+        if !strings.HasPrefix(image.Url, "http") {
+            continue
+        }
+        images = append(images, image)
+    }
+    return images, nil
+}
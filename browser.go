@@ -0,0 +1,104 @@
+package imagesearch
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "strings"
+)
+
+// A Browser loads pages in a real, usually headless, browser, so that the page's JavaScript runs and results are lazily loaded as they are in a desktop browser.
+// Implementations live in their own modules to keep this package free of dependencies, see github.com/commonkestrel/imagesearch/chromedp and github.com/commonkestrel/imagesearch/playwright.
+type Browser interface {
+    // Loads the page at url and scrolls to its bottom up to scrolls times, waiting for more results to load each time.
+    // Returns the rendered html of the page along with the bodies of the responses the page fetched while scrolling.
+    Load(ctx context.Context, url string, scrolls int) (page string, responses []string, err error)
+}
+
+// About how many results Google loads per scroll, used to work out how far to scroll for a limit
+const resultsPerScroll = 100
+
+// Returns a constructor of an engine that searches Google Images in the given Browser, to be passed into WithEngine.
+// Scrolling down the results page makes Google load more results, so the engine can find far more than the 100 images of a single request, up to maxScrolls pages of results.
+// Searches with a limit only scroll as far as needed to reach it. The images share the Image type and Download pipeline of every other engine.
+func Headless(browser Browser, maxScrolls int) func(*Client) Engine {
+    return func(c *Client) Engine {
+        return &headless{browser: browser, maxScrolls: maxScrolls}
+    }
+}
+
+// Searches Google Images in a Browser.
+type headless struct {
+    browser    Browser
+    maxScrolls int
+}
+
+func (h *headless) Name() string {
+    return "headless"
+}
+
+func (h *headless) Search(ctx context.Context, query Query) ([]Image, error) {
+    scrolls := h.maxScrolls
+    if query.Limit > 0 && (query.Limit+resultsPerScroll-1)/resultsPerScroll < scrolls {
+        scrolls = (query.Limit + resultsPerScroll - 1) / resultsPerScroll
+    }
+
+    url := buildUrl(query.Text, query.Arguments)
+    page, responses, err := h.browser.Load(ctx, url, scrolls)
+    if err != nil {
+        return nil, err
+    }
+
+    images, diagnostics := extractWith(page, strategies)
+    if diagnostics.Failed != StageNone {
+        return nil, newUnpackError(query.Text, url, page, diagnostics)
+    }
+    for _, response := range responses {
+        for _, image := range imagesFromBatch(response) {
+            image.Rank = len(images) + 1
+            images = append(images, image)
+        }
+    }
+    return images, nil
+}
+
+// Extracts the images from a batchexecute response, which is how the results page fetches more results while scrolling.
+// After a )]}' prefix, the response is a sequence of json arrays on their own lines, holding rows like ["wrb.fr", rpc id, payload as a json string, ...].
+// Each payload has the same result envelopes as the results page. Responses that aren't batchexecute responses have no images.
+func imagesFromBatch(response string) []Image {
+    var images []Image
+    scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(response, ")]}'")))
+    scanner.Buffer(nil, len(response)+1)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if !strings.HasPrefix(line, "[") {
+            continue
+        }
+
+        var rows [][]interface{}
+        if err := json.Unmarshal([]byte(line), &rows); err != nil {
+            continue
+        }
+        for _, row := range rows {
+            if len(row) < 3 || row[0] != "wrb.fr" {
+                continue
+            }
+            data, ok := row[2].(string)
+            if !ok || !strings.Contains(data, envelopeKey) {
+                continue
+            }
+            payload, err := readPayload(data)
+            if err != nil {
+                continue
+            }
+
+            envelopes := make([]interface{}, len(payload.envelopes))
+            for i, envelope := range payload.envelopes {
+                envelopes[i] = envelope.value
+            }
+            var diagnostics Diagnostics
+            images = append(images, imagesFromEnvelopes(envelopes, &diagnostics)...)
+        }
+    }
+    return images
+}
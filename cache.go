@@ -0,0 +1,80 @@
+package imagesearch
+
+import (
+    "container/list"
+    "sync"
+    "time"
+)
+
+// A size and TTL bounded least recently used cache of search results, keyed by the search url.
+type cache struct {
+    mu      sync.Mutex
+    size    int
+    ttl     time.Duration
+    order   *list.List
+    entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+    key     string
+    results Results
+    expires time.Time
+}
+
+func newCache(size int, ttl time.Duration) *cache {
+    return &cache{
+        size:    size,
+        ttl:     ttl,
+        order:   list.New(),
+        entries: map[string]*list.Element{},
+    }
+}
+
+// Returns a copy of the cached results for the key, if they exist and have not expired.
+func (c *cache) get(key string) (Results, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    element, ok := c.entries[key]
+    if !ok {
+        return Results{}, false
+    }
+
+    entry := element.Value.(*cacheEntry)
+    if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+        c.order.Remove(element)
+        delete(c.entries, key)
+        return Results{}, false
+    }
+
+    c.order.MoveToFront(element)
+    return entry.results.copy(), true
+}
+
+// Stores a copy of the results under the key, evicting the least recently used entries if the cache is full.
+func (c *cache) put(key string, results Results) {
+    c.putFor(key, results, c.ttl)
+}
+
+// Stores a copy of the results under the key like put, but expiring after ttl instead of the ttl of the cache. A ttl of 0 means the results never expire.
+func (c *cache) putFor(key string, results Results, ttl time.Duration) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    entry := &cacheEntry{key: key, results: results.copy()}
+    if ttl > 0 {
+        entry.expires = time.Now().Add(ttl)
+    }
+    if element, ok := c.entries[key]; ok {
+        element.Value = entry
+        c.order.MoveToFront(element)
+        return
+    }
+
+    c.entries[key] = c.order.PushFront(entry)
+    for c.order.Len() > c.size {
+        oldest := c.order.Back()
+        c.order.Remove(oldest)
+        delete(c.entries, oldest.Value.(*cacheEntry).key)
+    }
+}
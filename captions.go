@@ -0,0 +1,89 @@
+package imagesearch
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/json"
+    "os"
+    "path"
+)
+
+// The name of the captions file kept in download directories by a Client created with WithCaptions.
+const CaptionsName = "captions.jsonl"
+
+// The text paired with a downloaded image in a captions file.
+type Caption struct {
+    // Name of the file relative to the directory of the captions file, with forward slashes
+    File string `json:"file"`
+
+    // The query the image was found with, and the title and description of the page it was found on, if known
+    Query       string `json:"query"`
+    Title       string `json:"title,omitempty"`
+    Description string `json:"description,omitempty"`
+}
+
+// Writes a captions file alongside downloads, pairing every image with the query it was found with and the title of its page, for training caption or CLIP style models.
+// The file is kept under CaptionsName in the directory Download and DownloadUrls write to, or in the root of a dataset, as a JSON object per line.
+// Images downloaded into the directory again replace their captions. DownloadUrls doesn't know the pages of the urls, so its captions only have the name as the query.
+func WithCaptions() Option {
+    return func(c *Client) {
+        c.captions = true
+    }
+}
+
+// Merges captions into the captions file of dir, replacing the captions of any files that are already in it.
+func writeCaptions(dir string, captions []Caption) error {
+    file := path.Join(dir, CaptionsName)
+    existing, err := readCaptions(file)
+    if err != nil {
+        return err
+    }
+
+    index := map[string]int{}
+    for i, caption := range existing {
+        index[caption.File] = i
+    }
+    for _, caption := range captions {
+        if i, ok := index[caption.File]; ok {
+            existing[i] = caption
+        } else {
+            index[caption.File] = len(existing)
+            existing = append(existing, caption)
+        }
+    }
+
+    var buffer bytes.Buffer
+    encoder := json.NewEncoder(&buffer)
+    for _, caption := range existing {
+        if err := encoder.Encode(caption); err != nil {
+            return err
+        }
+    }
+    return os.WriteFile(file, buffer.Bytes(), 0666)
+}
+
+// Reads a captions file, which is empty if it doesn't exist.
+func readCaptions(file string) ([]Caption, error) {
+    f, err := os.Open(file)
+    if os.IsNotExist(err) {
+        return nil, nil
+    } else if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    var captions []Caption
+    scanner := bufio.NewScanner(f)
+    scanner.Buffer(nil, 1<<20)
+    for scanner.Scan() {
+        if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+            continue
+        }
+        var caption Caption
+        if err := json.Unmarshal(scanner.Bytes(), &caption); err != nil {
+            return nil, err
+        }
+        captions = append(captions, caption)
+    }
+    return captions, scanner.Err()
+}
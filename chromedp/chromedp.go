@@ -0,0 +1,119 @@
+// Package chromedp implements an imagesearch.Browser with chromedp, which drives a local Chrome or Chromium through the DevTools protocol.
+// It is a module of its own, so that only users of the headless engine depend on chromedp. For example:
+//
+//	browser, cancel, err := chromedp.NewBrowser(context.Background())
+//	if err != nil {
+//	    return err
+//	}
+//	defer cancel()
+//	client := imagesearch.NewClient(imagesearch.WithEngine(imagesearch.Headless(browser, 10)))
+//	images, err := client.Images(context.Background(), "example", 500)
+package chromedp
+
+import (
+    "context"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/chromedp/cdproto/network"
+    "github.com/chromedp/chromedp"
+    "github.com/commonkestrel/imagesearch"
+)
+
+// A Browser loads pages in a headless Chrome started by NewBrowser. Each Load opens a new tab, so a Browser is safe for concurrent use.
+type Browser struct {
+    ctx context.Context
+
+    // How long to wait for more results to load after each scroll
+    ScrollDelay time.Duration
+}
+
+var _ imagesearch.Browser = (*Browser)(nil)
+
+// Starts a headless Chrome with chromedp's default options, and returns a Browser using it along with a function that closes it.
+// Use chromedp.NewExecAllocator and NewBrowserFrom to start Chrome with other options.
+func NewBrowser(ctx context.Context) (*Browser, context.CancelFunc, error) {
+    return NewBrowserFrom(ctx)
+}
+
+// Starts Chrome in the chromedp allocator of ctx, if it has one, passing opts on to chromedp.NewContext, and returns a Browser using it along with a function that closes it.
+// Chrome is started here rather than by the first Load, so every Load opens a tab in the same Chrome instead of starting a Chrome of its own.
+// Returns the error starting Chrome, like when it isn't installed.
+func NewBrowserFrom(ctx context.Context, opts ...chromedp.ContextOption) (*Browser, context.CancelFunc, error) {
+    browserCtx, cancel := chromedp.NewContext(ctx, opts...)
+    if err := chromedp.Run(browserCtx); err != nil {
+        cancel()
+        return nil, nil, err
+    }
+    return &Browser{ctx: browserCtx, ScrollDelay: time.Second}, cancel, nil
+}
+
+// Loads the page at url in a new tab and scrolls to its bottom up to scrolls times, waiting ScrollDelay for more results after each scroll.
+// Scrolling stops early once the page stops growing. Returns the rendered html along with the bodies of the batchexecute responses the page fetched.
+func (b *Browser) Load(ctx context.Context, url string, scrolls int) (string, []string, error) {
+    tab, cancel := chromedp.NewContext(b.ctx)
+    defer cancel()
+    // Stop the tab when the caller's context is done
+    stop := context.AfterFunc(ctx, cancel)
+    defer stop()
+
+    var mu sync.Mutex
+    var requests []network.RequestID
+    chromedp.ListenTarget(tab, func(event interface{}) {
+        if response, ok := event.(*network.EventResponseReceived); ok && isBatch(response.Response.URL) {
+            mu.Lock()
+            requests = append(requests, response.RequestID)
+            mu.Unlock()
+        }
+    })
+
+    var page string
+    err := chromedp.Run(tab,
+        network.Enable(),
+        chromedp.Navigate(url),
+        chromedp.WaitReady("body"),
+        chromedp.ActionFunc(func(ctx context.Context) error {
+            var height, previous float64
+            for i := 0; i < scrolls; i++ {
+                err := chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight); document.body.scrollHeight`, &height).Do(ctx)
+                if err != nil {
+                    return err
+                }
+                if i > 0 && height == previous {
+                    break
+                }
+                previous = height
+                if err := chromedp.Sleep(b.ScrollDelay).Do(ctx); err != nil {
+                    return err
+                }
+            }
+            return nil
+        }),
+        chromedp.OuterHTML("html", &page),
+    )
+    if err != nil {
+        return "", nil, err
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    var responses []string
+    err = chromedp.Run(tab, chromedp.ActionFunc(func(ctx context.Context) error {
+        for _, id := range requests {
+            body, err := network.GetResponseBody(id).Do(ctx)
+            if err != nil {
+                // The body of a response is dropped once the browser needs the memory
+                continue
+            }
+            responses = append(responses, string(body))
+        }
+        return nil
+    }))
+    return page, responses, err
+}
+
+// Checks if the url is of a batchexecute request, which is how the results page loads more results.
+func isBatch(url string) bool {
+    return strings.Contains(url, "/batchexecute")
+}
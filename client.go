@@ -0,0 +1,769 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "path"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// How many times the limit Search asks engines that page through their results for, at most, to make up for the images the Client filters out
+const maxWidening = 8
+
+// No idea why this works, but Google renders the page differently with this header. Credit to joeclinton1 on Github for this
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.104 Safari/537.36"
+
+var (
+    errInvalidFormat = errors.New("invalid image format")
+    errNotModified   = errors.New("not modified")
+    errBlobUrl       = errors.New("blob: urls only exist inside the browser that created them, and can't be downloaded")
+    errTooSmall      = errors.New("image is smaller than the minimum size")
+)
+
+// Returned by a Client created WithOffline when a search or download can't be answered from its caches.
+type ErrCacheMiss struct {
+    // The url that would have been requested
+    Url string
+}
+
+func (e *ErrCacheMiss) Error() string {
+    return "offline cache miss: " + e.Url
+}
+
+// Checks if an error is an *ErrCacheMiss, returned by offline clients when a request can't be answered from their caches.
+func IsCacheMiss(err error) bool {
+    var miss *ErrCacheMiss
+    return errors.As(err, &miss)
+}
+
+// Returned when an API responds with a status other than 2xx.
+type StatusError struct {
+    // Host and path of the request. The query string is left out, since it may hold an API key.
+    Url string
+
+    StatusCode int
+    Status     string
+}
+
+func (e *StatusError) Error() string {
+    return "request to " + e.Url + " failed: " + e.Status
+}
+
+// A Client searches for and downloads images with its own configuration, set through the Options passed to NewClient.
+// The package level functions, such as Images and Download, use a Client with the default configuration.
+type Client struct {
+    httpClient     *http.Client
+    manifest       bool
+    cache          *cache
+    offline        bool
+    basic          bool
+    failureHook    func(Fingerprint)
+    strict         bool
+    duplicates     bool
+    noInline       bool
+    engine         Engine
+    newEngine      func(*Client) Engine
+    progress       func(Progress)
+    webhook        string
+    captions       bool
+    stages         []PipelineStage
+    minSize        int64
+    picks          *cache
+    diverse        bool
+    maxPerDomain   int
+    resultFilters  []func(Image) bool
+    commercialSafe bool
+    userAgent      string
+    retries        int
+    limiter        *limiter
+}
+
+// Configures a Client. Options are passed into NewClient, for example:
+//	client := imagesearch.NewClient(imagesearch.WithManifest())
+type Option func(*Client)
+
+// Sets the http.Client used for every request made by the Client. Defaults to http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+    return func(c *Client) {
+        c.httpClient = httpClient
+    }
+}
+
+// Keeps a Manifest of the downloaded images in every directory Download writes to.
+// When downloading into a directory with a manifest again, images that are already in it are only transferred if they changed since, which is checked with the ETag and Last-Modified headers the server sent.
+func WithManifest() Option {
+    return func(c *Client) {
+        c.manifest = true
+    }
+}
+
+// Keeps the results of up to size searches in memory, so repeating a search within the ttl does not make any requests.
+// The least recently used search is evicted once the cache is full. A ttl of 0 means results never expire.
+// The cache is shared by every search made with the Client, and is safe for concurrent use.
+func WithCache(size int, ttl time.Duration) Option {
+    return func(c *Client) {
+        if size > 0 {
+            c.cache = newCache(size, ttl)
+        }
+    }
+}
+
+// Answers only from the Client's caches and never makes a network request.
+// Searches are answered from the cache set up with WithCache, and images downloaded by Download from the directory's Manifest if the Client was created WithManifest.
+// Anything else fails with an *ErrCacheMiss, which can be checked for with IsCacheMiss.
+func WithOffline() Option {
+    return func(c *Client) {
+        c.offline = true
+    }
+}
+
+// Always searches the basic results page Google serves to old and mobile browsers, instead of only falling back to it when the full page can't be unpacked.
+// The basic page has a simpler structure that has historically been more stable, but it may only link thumbnails for some results, which are marked as BestEffort.
+func WithBasicResults() Option {
+    return func(c *Client) {
+        c.basic = true
+    }
+}
+
+// Calls hook with an anonymized Fingerprint of the page structure whenever a results page can't be unpacked.
+// Fingerprints are safe to log or report, and make it possible to spot Google changing its layout as soon as it happens.
+// The hook is called from the goroutine making the search, so it should return quickly.
+func WithParseFailureHook(hook func(Fingerprint)) Option {
+    return func(c *Client) {
+        c.failureHook = hook
+    }
+}
+
+// Fails searches with an *ErrMissingFields if any result is missing its Url, Source, or Base, instead of returning whatever could be extracted.
+// Without this, which is the default, results whose Source or Base couldn't be read are returned with those fields left empty.
+func WithStrictParsing() Option {
+    return func(c *Client) {
+        c.strict = true
+    }
+}
+
+// Keeps duplicate results. By default, results whose url is a duplicate of a more relevant result's url are removed, see Dedupe, so a limit of 50 means 50 distinct images.
+func WithDuplicates() Option {
+    return func(c *Client) {
+        c.duplicates = true
+    }
+}
+
+// Removes results whose url is an inline data: or blob: url rather than a link to the image on its origin.
+// Without this, data: urls are kept, since their content is part of the url itself and they can still be downloaded. blob: urls can never be downloaded.
+// The content of a data: url is always available as the image's ThumbnailData as well.
+func WithoutInlineUrls() Option {
+    return func(c *Client) {
+        c.noInline = true
+    }
+}
+
+// Skips images smaller than size bytes, like tracking pixels, placeholder GIFs, and broken thumbnails, which count as failed downloads.
+// Images whose Content-Length is below the size are skipped before their content is transferred, and the rest once they are read.
+func WithMinSize(size int64) Option {
+    return func(c *Client) {
+        c.minSize = size
+    }
+}
+
+// Orders the results so consecutive images come from different domains, which improves the variety of the first results when only a few are used.
+// The most relevant image of every domain comes first, then the second most relevant of every domain, and so on. The limit applies after reordering.
+func WithDomainDiversity() Option {
+    return func(c *Client) {
+        c.diverse = true
+    }
+}
+
+// Keeps only the results keep returns true for, like those whose url matches a pattern or that are large enough, in every search of the Client, including those of Urls and Download.
+// The filter runs before the limit applies, so a limit of 10 means 10 images that pass it, as long as the engine finds that many. Filters added more than once all have to keep an image.
+// Unlike WithFilter, which decides on the content of fetched images, this only sees the search results, so it doesn't cost a download.
+func WithResultFilter(keep func(img Image) bool) Option {
+    return func(c *Client) {
+        c.resultFilters = append(append([]func(Image) bool{}, c.resultFilters...), keep)
+    }
+}
+
+// Keeps at most n results from the pages of any one domain, so a single stock photo site or Pinterest board can't dominate the results.
+// The most relevant images of every domain are kept, and the limit applies after the cap, so a limit of 50 still means 50 images if enough domains have them. Below 1 means no cap.
+func WithMaxPerDomain(n int) Option {
+    return func(c *Client) {
+        c.maxPerDomain = n
+    }
+}
+
+// Creates a new Client configured with the given options.
+func NewClient(options ...Option) *Client {
+    c := &Client{
+        httpClient: http.DefaultClient,
+        picks:      newCache(pickCacheSize, 0),
+    }
+    for _, option := range options {
+        option(c)
+    }
+    c.engine = c.engineConstructor()(c)
+    return c
+}
+
+// Returns a copy of the Client with the options applied on top of its configuration. The copy shares the Client's cache.
+// Useful for changing a setting for some calls only, like reporting the progress of a single download.
+func (c *Client) With(options ...Option) *Client {
+    clone := *c
+    for _, option := range options {
+        option(&clone)
+    }
+    clone.engine = clone.engineConstructor()(&clone)
+    return &clone
+}
+
+// Returns the constructor of the engine set WithEngine, or of the default engine, which is Google unless the Client was created WithCommercialSafe.
+func (c *Client) engineConstructor() func(*Client) Engine {
+    if c.newEngine != nil {
+        return c.newEngine
+    }
+    if c.commercialSafe {
+        return FallbackTo(Openverse, Wikimedia, Google)
+    }
+    return Google
+}
+
+var defaultClient = NewClient()
+
+// Searches for the query along with the given arguments, and returns a slice of Image objects.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+// A query without any results returns an empty slice rather than an error.
+func (c *Client) Images(ctx context.Context, query string, limit int, arguments ...string) (images []Image, err error) {
+    results, err := c.Search(ctx, query, limit, arguments...)
+    if err != nil {
+        return []Image{}, err
+    }
+
+    return results.Images, nil
+}
+
+// Searches for the query along with the given arguments, and returns the images along with information about how they were extracted.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+func (c *Client) Search(ctx context.Context, query string, limit int, arguments ...string) (Results, error) {
+    if c.commercialSafe {
+        arguments = commercialArguments(arguments)
+    }
+    results, err := c.search(ctx, query, limit, arguments)
+    if err != nil {
+        return Results{}, err
+    }
+
+    images := c.filter(results.Images, limit)
+    if _, ok := c.engine.(*google); !ok {
+        // Engines that page through their results stop once they have the limit, so search further when filtering left fewer images than asked for
+        for searched := limit; limit > 0 && len(images) < limit && len(results.Images) >= searched && searched < limit*maxWidening; {
+            searched *= 2
+            wider, err := c.search(ctx, query, searched, arguments)
+            if err != nil {
+                return Results{}, err
+            }
+            if len(wider.Images) <= len(results.Images) {
+                break
+            }
+            results = wider
+            images = c.filter(results.Images, limit)
+        }
+    }
+    results.Images = images
+    return results, nil
+}
+
+// Removes the duplicate, inline, unlicensed, filtered, and excess per domain images the Client is configured to remove, orders them by domain if configured to, and cuts the images down to the limit unless it is 0.
+func (c *Client) filter(images []Image, limit int) []Image {
+    if !c.duplicates {
+        images = Dedupe(images)
+    }
+    if c.noInline {
+        images = withoutInline(images)
+    }
+    if c.commercialSafe {
+        images = withCommercialLicense(images)
+    }
+    for _, keep := range c.resultFilters {
+        images = ImageSet(images).Filter(keep)
+    }
+    if c.maxPerDomain > 0 {
+        images = capPerDomain(images, c.maxPerDomain)
+    }
+    if c.diverse {
+        images = diversify(images)
+    }
+    if len(images) > limit && limit > 0 {
+        images = images[:limit]
+    }
+    return images
+}
+
+// Returns every image the Client's engine finds for the query along with the given arguments, from the cache if possible.
+// The limit is passed on to engines that page through their results, and 0 means every page.
+func (c *Client) search(ctx context.Context, query string, limit int, arguments []string) (Results, error) {
+    if results, ok := c.cached(query, limit, arguments); ok {
+        return results, nil
+    }
+
+    var results Results
+    var err error
+    if g, ok := c.engine.(*google); ok {
+        results, err = g.client.searchGoogle(ctx, query, arguments)
+    } else {
+        results, err = c.searchEngine(ctx, Query{Text: query, Arguments: arguments, Limit: limit})
+    }
+    if err != nil {
+        return Results{}, err
+    }
+
+    if c.cache != nil {
+        c.cache.put(c.cacheKey(query, limit, arguments), results)
+    }
+    return results, nil
+}
+
+// Returns the results of a search from the cache, if the Client has a cache and they are in it.
+func (c *Client) cached(query string, limit int, arguments []string) (Results, bool) {
+    if c.cache == nil {
+        return Results{}, false
+    }
+    if results, ok := c.cache.get(c.cacheKey(query, limit, arguments)); ok {
+        return results, true
+    }
+    // Results without a limit, like those stored by Prime, answer searches with any limit
+    return c.cache.get(c.cacheKey(query, 0, arguments))
+}
+
+// Searches with an engine other than Google, normalizing the images it returns the same way as Google's.
+func (c *Client) searchEngine(ctx context.Context, query Query) (Results, error) {
+    images, err := c.engine.Search(ctx, query)
+    if err != nil {
+        return Results{}, err
+    }
+
+    diagnostics := Diagnostics{strategy: c.engine.Name()}
+    images = c.normalizeEngineImages(images, 0, &diagnostics)
+    return Results{Images: images, Warnings: diagnostics.Warnings}, nil
+}
+
+// Ranks the images of the engine that come after skipped others and names the engine they were found with, unless the engine did, and normalizes them.
+func (c *Client) normalizeEngineImages(images []Image, skipped int, diagnostics *Diagnostics) []Image {
+    for i := range images {
+        if images[i].Rank == 0 {
+            images[i].Rank = skipped + i + 1
+        }
+        if images[i].Engine == "" {
+            images[i].Engine = c.engine.Name()
+        }
+    }
+    return normalizeImages(images, diagnostics)
+}
+
+// Returns the key the results of a search are cached under, which is the url of the results page for Google.
+// Other engines may return a different number of images depending on the limit, so it is part of their key.
+func (c *Client) cacheKey(query string, limit int, arguments []string) string {
+    if _, ok := c.engine.(*google); ok {
+        return buildUrl(query, arguments)
+    }
+    return c.engine.Name() + " " + strconv.Itoa(limit) + " " + buildUrl(query, arguments)
+}
+
+// Stores images in the Client's cache as the results of searching for the query along with the given arguments, replacing any results that are already cached.
+// This is mainly useful for answering searches of an offline Client with previously collected results. Does nothing if the Client was not created WithCache.
+func (c *Client) Prime(query string, images []Image, arguments ...string) {
+    if c.cache != nil {
+        c.cache.put(c.cacheKey(query, 0, arguments), Results{Images: images})
+    }
+}
+
+// Searches for the query along with the given arguments, and returns a slice of the image urls.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all urls found.
+func (c *Client) Urls(ctx context.Context, query string, limit int, arguments ...string) (urls []string, err error) {
+    images, err := c.Images(ctx, query, limit, arguments...)
+    if err != nil {
+        return []string{}, err
+    }
+
+    for _, image := range images {
+        urls = append(urls, image.Url)
+    }
+
+    return urls, nil
+}
+
+// Searches for the given query along with the given arguments and downloads the images into the given directory.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will download all images found.
+// Returns a slice of the absolute paths of all downloaded images, along with the number of missing images.
+//
+// The number of missing images is the difference between the limit and the actual number of images downloaded.
+// This is only non-zero when the limit is higher than the number of downloadable images found.
+func (c *Client) Download(ctx context.Context, query string, limit int, dir string, arguments ...string) (paths []string, missing int, err error) {
+    failures := map[string]string{}
+    if c.webhook != "" {
+        defer func() {
+            c.notifyDownload(query, limit, dir, paths, missing, failures, err)
+        }()
+    }
+
+    dir, err = filepath.Abs(strings.ReplaceAll(dir, "\\", "/"))
+    if err != nil {
+        return []string{}, 0, err
+    }
+
+    images, err := c.Images(ctx, query, c.overfetch(limit), arguments...)
+    if err != nil {
+        return []string{}, 0, err
+    }
+    sources := make([]ImageData, len(images))
+    for i, image := range images {
+        sources[i] = ImageData{Url: image.Url, Query: query, Arguments: arguments, Image: image}
+    }
+
+    return c.downloadUrls(ctx, sources, limit, dir, query, failures)
+}
+
+// Times the limit of a download that is searched for, so there are spare images to take the place of those that fail to download
+const downloadOverfetch = 2
+
+// Returns how many images to search for to download limit of them, 0 for all images found.
+// Engines that page through their results stop once they have the number searched for, so bounding it keeps those that charge per page, like GoogleCSE, from spending requests on results that are never downloaded.
+// Google returns all of its results in one page either way, so all of them are kept as spares, and the search shares its cache entry with searches of any limit.
+func (c *Client) overfetch(limit int) int {
+    if _, ok := c.engine.(*google); ok || limit <= 0 {
+        return 0
+    }
+    return limit * downloadOverfetch
+}
+
+// Downloads the images at urls into the given directory, in order, until limit of them are downloaded, skipping any that fail.
+// The files are named like Download names them, with name followed by a number. This is how Download saves the results of a search,
+// so it can be used to download results that were already found, like a list saved to resume an interrupted download.
+// Returns the same values as Download, and notifies the webhook like Download with name as the query.
+func (c *Client) DownloadUrls(ctx context.Context, urls []string, limit int, dir, name string) (paths []string, missing int, err error) {
+    failures := map[string]string{}
+    if c.webhook != "" {
+        defer func() {
+            c.notifyDownload(name, limit, dir, paths, missing, failures, err)
+        }()
+    }
+
+    dir, err = filepath.Abs(strings.ReplaceAll(dir, "\\", "/"))
+    if err != nil {
+        return []string{}, 0, err
+    }
+    sources := make([]ImageData, len(urls))
+    for i, url := range urls {
+        sources[i] = ImageData{Url: url}
+    }
+    return c.downloadUrls(ctx, sources, limit, dir, name, failures)
+}
+
+// Downloads search results that were already found for the query and arguments into the given directory, in order, until limit of them are downloaded, skipping any that fail.
+// The results are downloaded like Download downloads them, so the filters see what they were found as, and the captions and manifest record their source and license,
+// which DownloadUrls can't do with urls alone. Returns the same values as Download, and notifies the webhook like Download.
+func (c *Client) DownloadImages(ctx context.Context, images []Image, limit int, dir, query string, arguments ...string) (paths []string, missing int, err error) {
+    failures := map[string]string{}
+    if c.webhook != "" {
+        defer func() {
+            c.notifyDownload(query, limit, dir, paths, missing, failures, err)
+        }()
+    }
+
+    dir, err = filepath.Abs(strings.ReplaceAll(dir, "\\", "/"))
+    if err != nil {
+        return []string{}, 0, err
+    }
+    sources := make([]ImageData, len(images))
+    for i, image := range images {
+        sources[i] = ImageData{Url: image.Url, Query: query, Arguments: arguments, Image: image}
+    }
+    return c.downloadUrls(ctx, sources, limit, dir, query, failures)
+}
+
+// Downloads the images of sources into the absolute directory dir, recording every url that failed in failures.
+// The search results of the sources, if any, are passed on to the filters and written to the captions.
+func (c *Client) downloadUrls(ctx context.Context, sources []ImageData, limit int, dir, name string, failures map[string]string) (paths []string, missing int, err error) {
+    var manifest *Manifest
+    if c.manifest {
+        manifest, err = LoadManifest(dir)
+        if err != nil {
+            return []string{}, 0, err
+        }
+    }
+
+    progress := c.tracker(name, limit)
+    var captions []Caption
+    var suffix int
+    for _, source := range sources {
+        url := source.Url
+        if limit > 0 && len(paths) >= limit {
+            break
+        }
+        if err = ctx.Err(); err != nil {
+            break
+        }
+
+        var file string
+        if previous := manifest.existing(url); previous != nil {
+            file, err = c.download(ctx, source, dir, strings.TrimSuffix(previous.File, filepath.Ext(previous.File)), previous, manifest, progress)
+        } else {
+            for exists(dir, name+strconv.Itoa(suffix)) {
+                suffix++
+            }
+            file, err = c.download(ctx, source, dir, name+strconv.Itoa(suffix), nil, manifest, progress)
+        }
+        progress.done(url, file, err)
+        if err != nil {
+            failures[url] = err.Error()
+            continue
+        }
+
+        paths = append(paths, file)
+        captions = append(captions, Caption{File: path.Base(file), Query: name, Title: source.Image.Title, Description: source.Image.Description})
+    }
+
+    if c.captions && len(captions) > 0 {
+        if err = writeCaptions(dir, captions); err != nil {
+            return paths, 0, err
+        }
+    }
+    if manifest != nil {
+        if err = manifest.Save(); err != nil {
+            return paths, 0, err
+        }
+    }
+    if err = ctx.Err(); err != nil {
+        return paths, 0, err
+    }
+
+    if limit > len(paths) {
+        missing = limit - len(paths)
+    }
+
+    return paths, missing, nil
+}
+
+// Given the url of the image, the directory to download to, and the name of the file *without extension*, this will find the type of image and download it to the given directory.
+// Warning: This will overwrite any image file with the same name, if the extension matches, so make sure to keep the name unique.
+func (c *Client) DownloadImage(ctx context.Context, url, dir, name string) (imgpath string, err error) {
+    progress := c.tracker("", 1)
+    imgpath, err = c.download(ctx, ImageData{Url: url}, dir, name, nil, nil, progress)
+    progress.done(url, imgpath, err)
+    return imgpath, err
+}
+
+// Downloads the image of source into dir, named name with the extension of its format, unless a stage of the Client rejects it.
+// If previous is not nil, the request is conditional on the image having changed since previous was recorded, and the existing file is kept if it hasn't.
+// The download is recorded in the manifest if it is not nil.
+func (c *Client) download(ctx context.Context, source ImageData, dir, name string, previous *ManifestEntry, manifest *Manifest, progress *tracker) (imgpath string, err error) {
+    dir, err = filepath.Abs(dir)
+    if err != nil {
+        return "", err
+    }
+    _, err = os.Stat(dir)
+    if os.IsNotExist(err) {
+        err = os.MkdirAll(dir, os.ModePerm)
+        if err != nil {
+            return "", err
+        }
+    }
+
+    if c.offline && previous != nil {
+        return path.Join(dir, previous.File), nil
+    }
+    url := source.Url
+    bytes, header, err := c.fetch(ctx, url, previous, progress)
+    if err == errNotModified {
+        return path.Join(dir, previous.File), nil
+    } else if err != nil {
+        return "", err
+    }
+
+    source.Data = bytes
+    if err = c.process(ctx, &source); err != nil {
+        return "", err
+    }
+
+    file := name + "." + source.Format
+    abs := path.Join(dir, file)
+
+    err = os.WriteFile(abs, source.Data, 0666)
+    if err != nil {
+        return "", err
+    }
+    source.Path = abs
+    if err = c.runStages(ctx, &source, PhaseStore, PhaseStore); err != nil {
+        os.Remove(abs)
+        return "", err
+    }
+
+    if previous != nil && previous.File != file {
+        // The image changed format, so the old file would be left behind
+        os.Remove(path.Join(dir, previous.File))
+    }
+    manifest.record(url, file, header, source.Data, source.Image)
+
+    return abs, nil
+}
+
+// Fetches the image at url into memory without saving it, and returns its bytes along with its format, like "jpeg", which is also the extension Download would save it with.
+// Fails if the content isn't an image, or a stage of the Client rejects it. Transforms apply to the returned image, but stages of PhaseStore don't run. Use it to process images without a pass over the disk, like packing them into TFRecords.
+func (c *Client) Fetch(ctx context.Context, url string) (data []byte, format string, err error) {
+    progress := c.tracker("", 1)
+    img, err := c.fetchImage(ctx, ImageData{Url: url}, progress)
+    progress.done(url, "", err)
+    return img.Data, img.Format, err
+}
+
+// Fetches the image of source with the progress reported to progress, and runs it through the stages of the Client before storage.
+func (c *Client) fetchImage(ctx context.Context, source ImageData, progress *tracker) (ImageData, error) {
+    data, _, err := c.fetch(ctx, source.Url, nil, progress)
+    if err != nil {
+        return ImageData{}, err
+    }
+    source.Data = data
+    if err := c.process(ctx, &source); err != nil {
+        return ImageData{}, err
+    }
+    return source, nil
+}
+
+// Returns the format of an image from its content type, like "jpeg", or errInvalidFormat if the data isn't an image.
+func imageFormat(data []byte) (string, error) {
+    mimetype := http.DetectContentType(data)
+    if !strings.Contains(mimetype, "image") {
+        return "", errInvalidFormat
+    }
+    return strings.ReplaceAll(mimetype, "image/", ""), nil
+}
+
+// Fetches the content at url. If previous is not nil, the request is conditional on the content having changed since previous was recorded, and errNotModified is returned if it hasn't.
+// Inline data: urls are decoded instead of requested.
+func (c *Client) fetch(ctx context.Context, url string, previous *ManifestEntry, progress *tracker) ([]byte, http.Header, error) {
+    if strings.HasPrefix(url, "data:") {
+        data, ok := decodeDataUrl(url)
+        if !ok {
+            return nil, nil, errMalformedUrl
+        }
+        if err := c.checkSize(int64(len(data))); err != nil {
+            return nil, nil, err
+        }
+        return data, http.Header{}, nil
+    }
+    if strings.HasPrefix(url, "blob:") {
+        return nil, nil, errBlobUrl
+    }
+
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return nil, nil, err
+    }
+    req.Header.Set("User-Agent", userAgent)
+    if previous != nil {
+        if previous.ETag != "" {
+            req.Header.Set("If-None-Match", previous.ETag)
+        }
+        if previous.LastModified != "" {
+            req.Header.Set("If-Modified-Since", previous.LastModified)
+        }
+    }
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer resp.Body.Close()
+
+    if previous != nil && resp.StatusCode == http.StatusNotModified {
+        return nil, nil, errNotModified
+    }
+    if resp.ContentLength >= 0 {
+        if err := c.checkSize(resp.ContentLength); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    bytes, err := io.ReadAll(progress.reader(url, resp.Body, resp.ContentLength))
+    if err != nil {
+        return nil, nil, err
+    }
+    if err := c.checkSize(int64(len(bytes))); err != nil {
+        return nil, nil, err
+    }
+    return bytes, resp.Header, nil
+}
+
+// Returns an error if an image of size bytes is smaller than the minimum size set WithMinSize.
+func (c *Client) checkSize(size int64) error {
+    if size < c.minSize {
+        return fmt.Errorf("%w of %d bytes: %d bytes", errTooSmall, c.minSize, size)
+    }
+    return nil
+}
+
+// Checks if a file with the given name, of any extension, exists in dir.
+func exists(dir, name string) bool {
+    pat := path.Join(dir, name) + ".*"
+    matches, _ := filepath.Glob(pat)
+    return len(matches) > 0
+}
+
+// Sends the request, unless the Client is offline.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+    if c.offline {
+        return nil, &ErrCacheMiss{Url: req.URL.String()}
+    }
+    return c.send(req)
+}
+
+// Fetches the page at url, and returns it along with the final url after any redirects.
+func (c *Client) getPage(ctx context.Context, url, agent string) (page string, final string, err error) {
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return "", "", err
+    }
+    req.Header.Set("User-Agent", agent)
+    resp, err := c.do(req)
+    if err != nil {
+        return "", "", err
+    }
+    defer resp.Body.Close()
+
+    // Reading into a builder avoids copying the whole page again to convert it into a string
+    var html strings.Builder
+    _, err = io.Copy(&html, resp.Body)
+    if err != nil {
+        return "", "", err
+    }
+    return html.String(), resp.Request.URL.String(), nil
+}
+
+// Fetches the json at url with the given headers, and decodes it into v. Fails if the server doesn't respond with a 2xx status.
+func (c *Client) getJSON(ctx context.Context, url string, header http.Header, v interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return err
+    }
+    for key, values := range header {
+        req.Header[key] = values
+    }
+    if req.Header.Get("User-Agent") == "" {
+        req.Header.Set("User-Agent", userAgent)
+    }
+    resp, err := c.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return &StatusError{Url: req.URL.Host + req.URL.Path, StatusCode: resp.StatusCode, Status: resp.Status}
+    }
+    return json.NewDecoder(resp.Body).Decode(v)
+}
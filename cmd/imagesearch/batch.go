@@ -0,0 +1,209 @@
+package main
+
+import (
+    "context"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "text/tabwriter"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// A query of a batch file, along with its limit.
+type batchQuery struct {
+    Query string `json:"query"`
+    Limit int    `json:"limit"`
+}
+
+// The outcome of downloading a query of a batch, as written to the report.
+type batchReport struct {
+    batchQuery
+    Dir        string            `json:"dir"`
+    Downloaded int               `json:"downloaded"`
+    Missing    int               `json:"missing,omitempty"`
+    Failures   map[string]string `json:"failures,omitempty"`
+    Error      string            `json:"error,omitempty"`
+
+    paths []string
+}
+
+// Reads the queries of a batch file. Files ending in .csv have a query and optionally a limit per row, with an optional header row,
+// and any other file has a query per line. Blank lines and lines starting with # are skipped, and queries without a limit get limit.
+func readBatch(path string, limit int) ([]batchQuery, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    var rows [][]string
+    if strings.EqualFold(filepath.Ext(path), ".csv") {
+        reader := csv.NewReader(file)
+        reader.FieldsPerRecord = -1
+        reader.Comment = '#'
+        if rows, err = reader.ReadAll(); err != nil {
+            return nil, err
+        }
+        if len(rows) > 0 && len(rows[0]) > 1 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "query") {
+            rows = rows[1:]
+        }
+    } else {
+        data, err := io.ReadAll(file)
+        if err != nil {
+            return nil, err
+        }
+        for _, line := range strings.Split(string(data), "\n") {
+            rows = append(rows, []string{line})
+        }
+    }
+
+    var queries []batchQuery
+    for i, row := range rows {
+        query := batchQuery{Query: strings.TrimSpace(row[0]), Limit: limit}
+        if query.Query == "" || strings.HasPrefix(query.Query, "#") {
+            continue
+        }
+        if len(row) > 1 && strings.TrimSpace(row[1]) != "" {
+            if query.Limit, err = strconv.Atoi(strings.TrimSpace(row[1])); err != nil || query.Limit < 0 {
+                return nil, usagef("%s: invalid limit %q for %q", path, row[1], query.Query)
+            }
+        }
+        if len(row) > 2 {
+            return nil, usagef("%s: row %d has %d columns, expected a query and a limit", path, i+1, len(row))
+        }
+        queries = append(queries, query)
+    }
+    if len(queries) == 0 {
+        return nil, usagef("%s has no queries", path)
+    }
+    return queries, nil
+}
+
+// Downloads every query into its own directory inside dir, with at most concurrency queries downloading at once,
+// and returns the report of every query in the order of queries. Progress is printed to stderr as each query finishes unless quiet.
+// Each directory keeps the state of its query, and if resume is set the queries that have a state are resumed instead of searched again.
+func downloadBatch(ctx context.Context, client *imagesearch.Client, queries []batchQuery, dir string, concurrency int, arguments []string, resume, quiet bool) []batchReport {
+    if concurrency < 1 {
+        concurrency = 1
+    }
+
+    reports := make([]batchReport, len(queries))
+    var finished int
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    sem := make(chan struct{}, concurrency)
+    for i, query := range queries {
+        wg.Add(1)
+        go func(i int, query batchQuery) {
+            defer wg.Done()
+            sem <- struct{}{}
+            defer func() { <-sem }()
+
+            report := batchReport{batchQuery: query, Dir: filepath.Join(dir, directoryName(query.Query))}
+            state := filepath.Join(report.Dir, jobStateName)
+            _, err := os.Stat(state)
+            job, err := startJob(ctx, client, state, query.Query, query.Limit, arguments, resume && err == nil)
+            if err == nil {
+                report.paths, report.Missing, err = job.run(ctx, client, report.Dir, nil)
+                report.Downloaded = len(job.Completed)
+                report.Failures = job.Failures
+            }
+            if err != nil {
+                report.Error = err.Error()
+            }
+            reports[i] = report
+
+            mu.Lock()
+            defer mu.Unlock()
+            finished++
+            if !quiet {
+                fmt.Fprintf(stderr, "[%d/%d] %s: %s\n", finished, len(queries), query.Query, report.outcome())
+            }
+        }(i, query)
+    }
+    wg.Wait()
+    return reports
+}
+
+func (r *batchReport) outcome() string {
+    if r.Error != "" {
+        return r.Error
+    }
+    outcome := fmt.Sprintf("%d downloaded", r.Downloaded)
+    if len(r.Failures) > 0 {
+        outcome += fmt.Sprintf(", %d failed", len(r.Failures))
+    }
+    if r.Missing > 0 {
+        outcome += fmt.Sprintf(", %d missing", r.Missing)
+    }
+    return outcome
+}
+
+// Names the directory of a query, replacing the characters that can't appear in file names.
+func directoryName(query string) string {
+    name := strings.Map(func(r rune) rune {
+        switch r {
+        case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+            return '_'
+        }
+        return r
+    }, strings.TrimSpace(query))
+    if name == "" || name == "." || name == ".." {
+        name = "_"
+    }
+    return name
+}
+
+// Prints a table of how every query went, and the totals.
+func writeBatchSummary(w io.Writer, reports []batchReport) {
+    table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(table, "QUERY\tDOWNLOADED\tFAILED\tMISSING\tERROR")
+    var downloaded, failed, missing, errored int
+    for _, report := range reports {
+        fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%s\n", report.Query, report.Downloaded, len(report.Failures), report.Missing, report.Error)
+        downloaded += report.Downloaded
+        failed += len(report.Failures)
+        missing += report.Missing
+        if report.Error != "" {
+            errored++
+        }
+    }
+    fmt.Fprintf(table, "TOTAL\t%d\t%d\t%d\t%d errors\n", downloaded, failed, missing, errored)
+    table.Flush()
+}
+
+// Writes the reports as JSON to path.
+func writeBatchReport(path string, reports []batchReport) error {
+    data, err := json.MarshalIndent(reports, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Returns an error if any query of the batch failed entirely, so the exit status reflects it, reported as a partial failure if other queries succeeded.
+func batchError(reports []batchReport) error {
+    var failed int
+    for _, report := range reports {
+        if report.Error != "" {
+            failed++
+        }
+    }
+    switch {
+    case failed == 0:
+        return nil
+    case failed == len(reports):
+        return fmt.Errorf("all %d queries failed", failed)
+    case failed == 1:
+        return partialf("1 of %d queries failed", len(reports))
+    default:
+        return partialf("%d of %d queries failed", failed, len(reports))
+    }
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "flag"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// Name of the config file read from the home directory when -config isn't given
+const configName = ".imagesearch.yaml"
+
+// Finds the config file: the -config flag in args if there is one, otherwise ~/.imagesearch.yaml if it exists.
+// Returns an empty path if there is no config file to read.
+func configPath(args []string) string {
+    for i, arg := range args {
+        if arg == "--" {
+            break
+        }
+        name := strings.TrimLeft(arg, "-")
+        if !strings.HasPrefix(arg, "-") || !strings.HasPrefix(name, "config") {
+            continue
+        }
+        if name == "config" && i+1 < len(args) {
+            return args[i+1]
+        }
+        if strings.HasPrefix(name, "config=") {
+            return strings.TrimPrefix(name, "config=")
+        }
+    }
+
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return ""
+    }
+    path := filepath.Join(home, configName)
+    if _, err := os.Stat(path); err != nil {
+        return ""
+    }
+    return path
+}
+
+// Sets the flags of set to the values in the config file at path, so the flags given on the command line override them.
+// Keys are flag names, like dir or engine, and keys that aren't flags of the command are skipped, so one file can hold the defaults of every command.
+func applyConfig(set *flag.FlagSet, path string) error {
+    values, err := readConfig(path)
+    if err != nil {
+        return err
+    }
+    for _, value := range values {
+        if set.Lookup(value.key) == nil {
+            continue
+        }
+        if err := set.Set(value.key, value.value); err != nil {
+            return usagef("%s:%d: invalid %s: %v", path, value.line, value.key, err)
+        }
+    }
+    return nil
+}
+
+type configValue struct {
+    key, value string
+    line       int
+}
+
+// Reads a config file of flat key: value lines, the subset of YAML the config needs. Blank lines and # comments are ignored, and values may be quoted.
+func readConfig(path string) ([]configValue, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    var values []configValue
+    scanner := bufio.NewScanner(file)
+    for line := 1; scanner.Scan(); line++ {
+        text := scanner.Text()
+        trimmed := strings.TrimSpace(text)
+        if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+            continue
+        }
+        if text[0] == ' ' || text[0] == '\t' {
+            return nil, usagef("%s:%d: nested values are not supported", path, line)
+        }
+
+        key, value, ok := strings.Cut(trimmed, ":")
+        if !ok || strings.TrimSpace(key) == "" {
+            return nil, usagef("%s:%d: expected key: value", path, line)
+        }
+        value, err := configString(strings.TrimSpace(value))
+        if err != nil {
+            return nil, usagef("%s:%d: %v", path, line, err)
+        }
+        values = append(values, configValue{key: strings.TrimSpace(key), value: value, line: line})
+    }
+    return values, scanner.Err()
+}
+
+// Unquotes a value, or strips the comment after an unquoted one.
+func configString(value string) (string, error) {
+    switch {
+    case strings.HasPrefix(value, `"`):
+        end := strings.LastIndex(value, `"`)
+        if end == 0 {
+            return "", errors.New("unterminated string")
+        }
+        return strconv.Unquote(value[:end+1])
+    case strings.HasPrefix(value, "'"):
+        end := strings.LastIndex(value, "'")
+        if end == 0 {
+            return "", errors.New("unterminated string")
+        }
+        return strings.ReplaceAll(value[1:end], "''", "'"), nil
+    }
+    if i := strings.Index(value, " #"); i != -1 {
+        value = value[:i]
+    }
+    return strings.TrimSpace(value), nil
+}
+
@@ -0,0 +1,210 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "os/signal"
+    "strconv"
+    "strings"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+func runDataset(args []string) error {
+    set := flag.NewFlagSet("dataset", flag.ContinueOnError)
+    var flags searchFlags
+    flags.register(set)
+    dir := set.String("dir", ".", "root directory of the dataset, with a folder of images for every class")
+    classesFile := set.String("classes", "", "file of classes to download along with those given as arguments, one label=query or query per line, optionally followed by |variant|variant for -top-up")
+    concurrency := set.Int("concurrency", 4, "classes to download at once")
+    split := set.String("split", "", "ratios of train, val, and test images of every class, like 0.8,0.1,0.1, which splits the dataset into train/, val/, and test/ folders")
+    tfrecord := set.Bool("tfrecord", false, "pack the images into sharded TFRecord files in -dir instead of saving a folder of files for every class")
+    shards := set.Int("shards", 1, "TFRecord files to spread the images of -tfrecord over")
+    captions := set.Bool("captions", false, "write "+imagesearch.CaptionsName+" in -dir, pairing every image with its query and the title of its page")
+    crossDuplicates := set.String("cross-duplicates", "report", "what to do with images downloaded under more than one class: report them, drop all but the largest copy, or ignore them")
+    topUp := set.Bool("top-up", false, "search variants of the query of classes that fall short of -limit until they have enough images, the variants of the class first")
+    seed := set.Int64("seed", 0, "seed of the shuffle that splits the images with -split, the same seed splits the same images the same way")
+    positional, err := parse(set, args)
+    if err != nil {
+        return err
+    }
+
+    var lines []string
+    if *classesFile != "" {
+        data, err := os.ReadFile(*classesFile)
+        if err != nil {
+            return err
+        }
+        lines = strings.Split(string(data), "\n")
+    }
+    classes, err := parseClasses(append(lines, positional...), flags.limit)
+    if err != nil {
+        return err
+    }
+    if len(classes) == 0 {
+        set.Usage()
+        return usagef("dataset needs at least one class, like cat=cats or -classes")
+    }
+
+    splits, err := parseSplits(*split)
+    if err != nil {
+        return err
+    }
+    if *tfrecord && *split != "" {
+        return usagef("-split doesn't work with -tfrecord")
+    }
+    if *crossDuplicates != "report" && *crossDuplicates != "drop" && *crossDuplicates != "ignore" {
+        return usagef("invalid -cross-duplicates %q, expected one of: report, drop, ignore", *crossDuplicates)
+    }
+    arguments, err := flags.arguments()
+    if err != nil {
+        return err
+    }
+    for i := range classes {
+        classes[i].Arguments = arguments
+    }
+    var options []imagesearch.Option
+    if *captions {
+        options = append(options, imagesearch.WithCaptions())
+    }
+    client, err := flags.client(options...)
+    if err != nil {
+        return err
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    if *tfrecord {
+        paths, err := client.WriteTFRecords(ctx, *dir, classes, imagesearch.TFRecordOptions{Concurrency: *concurrency, Shards: *shards})
+        for _, path := range paths {
+            fmt.Println(path)
+        }
+        return err
+    }
+    dataset, err := client.DownloadDataset(ctx, *dir, classes, imagesearch.DatasetOptions{Concurrency: *concurrency, Splits: splits, Seed: *seed, DropCrossClassDuplicates: *crossDuplicates == "drop", TopUp: *topUp})
+    if dataset == nil {
+        return err
+    }
+    if err == nil && *crossDuplicates == "report" {
+        if err := reportCrossDuplicates(dataset); err != nil {
+            return err
+        }
+    }
+    for _, entry := range dataset.Entries {
+        if entry.File != "" {
+            fmt.Println(dataset.Path(entry))
+        }
+    }
+    if err != nil {
+        return err
+    }
+    return datasetError(dataset)
+}
+
+// Parses classes written as label=query, or as a query labeled with imagesearch.ClassLabel, followed by any variants of the query separated by |.
+// Blank lines and lines starting with # are skipped.
+func parseClasses(lines []string, limit int) ([]imagesearch.DatasetClass, error) {
+    var classes []imagesearch.DatasetClass
+    for _, line := range lines {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        queries := strings.Split(line, "|")
+        class := imagesearch.DatasetClass{Query: strings.TrimSpace(queries[0]), Limit: limit}
+        if label, query, ok := strings.Cut(class.Query, "="); ok {
+            class.Label, class.Query = strings.TrimSpace(label), strings.TrimSpace(query)
+        }
+        for _, variant := range queries[1:] {
+            if variant = strings.TrimSpace(variant); variant != "" {
+                class.Variants = append(class.Variants, variant)
+            }
+        }
+        if class.Query == "" {
+            return nil, usagef("class %q has no query", line)
+        }
+        classes = append(classes, class)
+    }
+    return classes, nil
+}
+
+// Parses the ratios of -split, which are the train ratio followed by the optional val and test ratios.
+func parseSplits(value string) (imagesearch.Splits, error) {
+    var splits imagesearch.Splits
+    if value == "" {
+        return splits, nil
+    }
+    parts := strings.Split(value, ",")
+    if len(parts) > 3 {
+        return splits, usagef("invalid -split %q, expected up to 3 ratios for train, val, and test", value)
+    }
+    ratios := []*float64{&splits.Train, &splits.Val, &splits.Test}
+    for i, part := range parts {
+        ratio, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+        if err != nil || ratio < 0 {
+            return splits, usagef("invalid -split ratio %q", part)
+        }
+        *ratios[i] = ratio
+    }
+    return splits, nil
+}
+
+// Prints the images of the dataset that were downloaded under more than one class to stderr.
+func reportCrossDuplicates(dataset *imagesearch.Dataset) error {
+    groups, err := dataset.CrossClassDuplicates(imagesearch.DefaultHashDistance)
+    if err != nil {
+        return err
+    }
+    for _, group := range groups {
+        files := make([]string, len(group))
+        for i, entry := range group {
+            files[i] = entry.File
+        }
+        fmt.Fprintf(stderr, "duplicate across classes: %s\n", strings.Join(files, ", "))
+    }
+    if len(groups) > 0 {
+        fmt.Fprintf(stderr, "%d images were downloaded under more than one class, download with -cross-duplicates drop to keep only the largest copy\n", len(groups))
+    }
+    return nil
+}
+
+// Prints how many images every class has to stderr, and returns an error if some classes fell short of their limit.
+func datasetError(dataset *imagesearch.Dataset) error {
+    downloaded := map[string]int{}
+    failed := map[string]int{}
+    dropped := map[string]int{}
+    for _, entry := range dataset.Entries {
+        switch {
+        case entry.File != "":
+            downloaded[entry.Label]++
+        case entry.Duplicate != "":
+            dropped[entry.Label]++
+        default:
+            failed[entry.Label]++
+        }
+    }
+
+    shortfall := dataset.Shortfall()
+    var total int
+    for _, class := range dataset.Classes {
+        fmt.Fprintf(stderr, "%s: %d images, %d failed", class.Label, downloaded[class.Label], failed[class.Label])
+        if dropped[class.Label] > 0 {
+            fmt.Fprintf(stderr, ", %d dropped as duplicates of other classes", dropped[class.Label])
+        }
+        if shortfall[class.Label] > 0 {
+            fmt.Fprintf(stderr, ", %d short of %d", shortfall[class.Label], class.Limit)
+        }
+        fmt.Fprintln(stderr)
+        total += downloaded[class.Label]
+    }
+    switch {
+    case total == 0:
+        return &exitError{code: exitNoResults, kind: "no_results", err: fmt.Errorf("no images found for any class")}
+    case len(shortfall) > 0:
+        return partialf("%d of %d classes have fewer images than asked for", len(shortfall), len(dataset.Classes))
+    default:
+        return nil
+    }
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+func runDedupe(args []string) error {
+    set := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+    distance := set.Int("distance", imagesearch.DefaultHashDistance, "most bits the perceptual hashes of near duplicates may differ in")
+    exact := set.Bool("exact", false, "only remove identical files, not near duplicates")
+    move := set.String("move", "", "directory to move the duplicates into instead of deleting them")
+    dryRun := set.Bool("dry-run", false, "print what would be removed without changing anything")
+    positional, err := parse(set, args)
+    if err != nil {
+        return err
+    }
+    if len(positional) != 1 {
+        set.Usage()
+        return usagef("dedupe needs a directory")
+    }
+    dir := positional[0]
+
+    paths, err := imageFiles(dir)
+    if err != nil {
+        return err
+    }
+    if *exact {
+        *distance = -1
+    }
+    groups, err := imagesearch.FindDuplicates(paths, *distance)
+    if err != nil {
+        return err
+    }
+
+    var removed []string
+    for _, group := range groups {
+        fmt.Printf("keep %s\n", group[0])
+        for _, path := range group[1:] {
+            if *move != "" {
+                fmt.Printf("  move %s\n", path)
+            } else {
+                fmt.Printf("  remove %s\n", path)
+            }
+            if *dryRun {
+                continue
+            }
+            if err := removeDuplicate(path, *move); err != nil {
+                return err
+            }
+            removed = append(removed, path)
+        }
+    }
+
+    if !*dryRun && len(removed) > 0 {
+        if err := forget(dir, removed); err != nil {
+            return err
+        }
+    }
+    verb := "removed"
+    if *move != "" {
+        verb = "moved"
+    }
+    if *dryRun {
+        verb = "would have " + verb
+    }
+    fmt.Fprintf(stderr, "%s %d duplicates of %d images in %d files\n", verb, duplicates(groups), len(groups), len(paths))
+    return nil
+}
+
+// Returns the files in dir, skipping hidden files like the manifest and the job state, and the captions file.
+func imageFiles(dir string) ([]string, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, err
+    }
+    var paths []string
+    for _, entry := range entries {
+        if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || entry.Name() == imagesearch.CaptionsName {
+            continue
+        }
+        paths = append(paths, filepath.Join(dir, entry.Name()))
+    }
+    return paths, nil
+}
+
+// Deletes the file at path, or moves it into the directory move if it isn't empty.
+func removeDuplicate(path, move string) error {
+    if move == "" {
+        return os.Remove(path)
+    }
+    if err := os.MkdirAll(move, 0755); err != nil {
+        return err
+    }
+    target := filepath.Join(move, filepath.Base(path))
+    if _, err := os.Stat(target); err == nil {
+        return fmt.Errorf("can't move %s, %s already exists", path, target)
+    }
+    return os.Rename(path, target)
+}
+
+// Removes the entries of the removed files from the manifest of dir, if it has one, so the manifest doesn't list files that are gone.
+func forget(dir string, removed []string) error {
+    if _, err := os.Stat(filepath.Join(dir, imagesearch.ManifestName)); err != nil {
+        return nil
+    }
+    manifest, err := imagesearch.LoadManifest(dir)
+    if err != nil {
+        return err
+    }
+
+    names := map[string]bool{}
+    for _, path := range removed {
+        names[filepath.Base(path)] = true
+    }
+    for url, entry := range manifest.Files {
+        if names[entry.File] {
+            delete(manifest.Files, url)
+        }
+    }
+    return manifest.Save()
+}
+
+func duplicates(groups [][]string) int {
+    var n int
+    for _, group := range groups {
+        n += len(group) - 1
+    }
+    return n
+}
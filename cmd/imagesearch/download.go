@@ -0,0 +1,161 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "strings"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+func runDownload(args []string) error {
+    set := flag.NewFlagSet("download", flag.ContinueOnError)
+    var flags searchFlags
+    flags.register(set)
+    dir := set.String("dir", ".", "directory to download the images into")
+    manifest := set.Bool("manifest", false, "keep a manifest in the directory, so downloading into it again only transfers images that changed")
+    progress := set.Bool("progress", true, "draw progress bars while downloading and print a summary to stderr")
+    batch := set.String("batch", "", "file of queries to download instead of the query, one per line or a csv of queries and limits")
+    concurrency := set.Int("concurrency", 4, "queries of a -batch to download at once")
+    report := set.String("report", "", "file to write a json report of every query of a -batch to")
+    state := set.String("state", "", "file to keep the state of the download in, so it can be resumed, "+jobStateName+" in -dir by default")
+    resume := set.Bool("resume", false, "resume the interrupted download kept in the -state file, or every query of a -batch that has one, without searching again")
+    dryRun := set.Bool("dry-run", false, "print the images that would be downloaded, with their paths and sizes, without downloading them")
+    minSize := set.Int64("min-size", 0, "skip images smaller than this many bytes, like 10240 to skip tracking pixels and placeholders under 10 KB")
+    summary := set.String("summary", "", "print the stats of the download, or the plan of a -dry-run, as text or json. The json summary includes the paths, which are then not printed on their own")
+
+    var query string
+    if positional, err := parse(set, args); err != nil {
+        return err
+    } else if len(positional) > 0 {
+        query = strings.Join(positional, " ")
+    } else if *batch == "" && !*resume {
+        set.Usage()
+        return usagef("download needs a query or -batch")
+    }
+
+    if *summary != "" && *summary != "text" && *summary != "json" {
+        return usagef("invalid -summary %q, expected one of: text, json", *summary)
+    }
+    arguments, err := flags.arguments()
+    if err != nil {
+        return err
+    }
+    var options []imagesearch.Option
+    if *manifest {
+        options = append(options, imagesearch.WithManifest())
+    }
+    if *minSize > 0 {
+        options = append(options, imagesearch.WithMinSize(*minSize))
+    }
+    if *batch != "" {
+        if query != "" {
+            return usagef("download takes either a query or -batch, not both")
+        }
+        if *dryRun || *summary != "" {
+            return usagef("-dry-run and -summary only work with a single query, -batch reports with -report")
+        }
+        queries, err := readBatch(*batch, flags.limit)
+        if err != nil {
+            return err
+        }
+        client, err := flags.client(options...)
+        if err != nil {
+            return err
+        }
+
+        ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+        defer stop()
+        reports := downloadBatch(ctx, client, queries, *dir, *concurrency, arguments, *resume, !*progress || quiet)
+        for _, report := range reports {
+            for _, path := range report.paths {
+                fmt.Println(path)
+            }
+        }
+        writeBatchSummary(stderr, reports)
+        if *report != "" {
+            if err := writeBatchReport(*report, reports); err != nil {
+                return err
+            }
+        }
+        return batchError(reports)
+    }
+
+    var bars *progressBars
+    if *progress && !quiet {
+        bars = newProgressBars(os.Stderr)
+    }
+    client, err := flags.client(options...)
+    if err != nil {
+        return err
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    if *state == "" {
+        *state = filepath.Join(*dir, jobStateName)
+    }
+    if *dryRun {
+        var job *jobState
+        if *resume {
+            job, err = loadJob(*state)
+        } else {
+            job, err = searchJob(ctx, client, *state, query, flags.limit, arguments)
+        }
+        if err != nil {
+            return err
+        }
+        abs, err := filepath.Abs(*dir)
+        if err != nil {
+            return err
+        }
+        return writePlan(os.Stdout, *summary, planJob(ctx, client, job, abs))
+    }
+
+    job, err := startJob(ctx, client, *state, query, flags.limit, arguments, *resume)
+    if err != nil {
+        return err
+    }
+    stats := newSummary(job.Query, len(job.Completed))
+    hook := stats.update
+    if bars != nil {
+        hook = func(progress imagesearch.Progress) {
+            stats.update(progress)
+            bars.update(progress)
+        }
+    }
+    paths, missing, err := job.run(ctx, client, *dir, hook)
+    stats.finish(paths, missing)
+    if bars != nil {
+        bars.finish(*summary == "")
+    }
+    if *summary == "json" {
+        if err := stats.write(os.Stdout, *summary); err != nil {
+            return err
+        }
+    } else {
+        for _, path := range paths {
+            fmt.Println(path)
+        }
+        if *summary == "text" {
+            stats.write(stderr, *summary)
+        }
+    }
+    if err != nil {
+        return err
+    }
+    if len(paths) == 0 && len(job.Completed) == 0 && len(job.Failures) == 0 {
+        return noResults(job.Query)
+    }
+    if missing > 0 {
+        return partialf("%d of %d images could not be downloaded", missing, job.Limit)
+    }
+    if job.Limit == 0 && len(job.Failures) > 0 {
+        return partialf("%d of %d images could not be downloaded", len(job.Failures), len(job.Completed)+len(job.Failures))
+    }
+    return nil
+}
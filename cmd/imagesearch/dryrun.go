@@ -0,0 +1,99 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// A download -dry-run would make.
+type plannedDownload struct {
+    Url string `json:"url"`
+
+    // Path the image would be saved to. The extension is only known once the image is downloaded, so it is guessed from the Content-Type, or * if there is none.
+    Path string `json:"path"`
+
+    // Size the server reported, -1 if it didn't
+    Size int64 `json:"size"`
+}
+
+// Finds the images downloading the job would save without downloading them: the first of the pending urls that are alive, up to the limit of the job.
+// Every url is checked with a HEAD request, which also gives the size of the image if the server reports it.
+func planJob(ctx context.Context, client *imagesearch.Client, job *jobState, dir string) []plannedDownload {
+    limit := len(job.Pending)
+    if job.Limit > 0 {
+        limit = job.Limit - len(job.Completed)
+    }
+
+    var plan []plannedDownload
+    var suffix int
+    pending := job.Pending
+    for len(plan) < limit && len(pending) > 0 {
+        // Checking only as many urls as are still needed keeps the requests down, and the dead ones are replaced by the next round
+        n := limit - len(plan)
+        if n > len(pending) {
+            n = len(pending)
+        }
+        for _, status := range client.ValidateUrls(ctx, pending[:n], 8) {
+            if !status.Alive {
+                continue
+            }
+            for exists(dir, job.Query+strconv.Itoa(suffix)) {
+                suffix++
+            }
+            extension := "*"
+            if mimetype := strings.TrimPrefix(strings.Split(status.ContentType, ";")[0], "image/"); mimetype != status.ContentType && mimetype != "" {
+                extension = mimetype
+            }
+            plan = append(plan, plannedDownload{Url: status.Url, Path: filepath.Join(dir, job.Query+strconv.Itoa(suffix)+"."+extension), Size: status.Size})
+            suffix++
+        }
+        pending = pending[n:]
+    }
+    return plan
+}
+
+// Checks if a file with the name, of any extension, exists in dir, like the names downloads choose.
+func exists(dir, name string) bool {
+    matches, _ := filepath.Glob(filepath.Join(dir, name) + ".*")
+    return len(matches) > 0
+}
+
+// Writes the plan as JSON, or as a table for people with the estimated total size.
+func writePlan(w io.Writer, format string, plan []plannedDownload) error {
+    if format == "json" {
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(plan)
+    }
+
+    table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(table, "PATH\tSIZE\tURL")
+    var total int64
+    var unknown int
+    for _, download := range plan {
+        size := "?"
+        if download.Size >= 0 {
+            size = bytes(download.Size)
+            total += download.Size
+        } else {
+            unknown++
+        }
+        fmt.Fprintf(table, "%s\t%s\t%s\n", download.Path, size, download.Url)
+    }
+    table.Flush()
+
+    fmt.Fprintf(w, "%d images, about %s", len(plan), bytes(total))
+    if unknown > 0 {
+        fmt.Fprintf(w, " plus %d of unknown size", unknown)
+    }
+    fmt.Fprintln(w)
+    return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// The exit codes of the command, so scripts can tell failures apart without parsing messages.
+const (
+    exitOK = 0
+
+    // Any failure without a code of its own, like a network error
+    exitFailure = 1
+
+    // Invalid flags, arguments, config file, or batch file
+    exitUsage = 2
+
+    // The engine refused to search, like Google showing a captcha, see imagesearch.IsBlocked
+    exitBlocked = 3
+
+    // The search found no images
+    exitNoResults = 4
+
+    // Some of the images or queries failed, and the rest succeeded
+    exitPartial = 5
+
+    // Reading or writing a file failed
+    exitIO = 6
+
+    // The results page couldn't be parsed, usually because Google changed its structure, see imagesearch.IsUnpackErr
+    exitParse = 7
+
+    // The command was interrupted with Ctrl+C
+    exitInterrupted = 130
+)
+
+// An error along with the exit code and kind it is reported with.
+type exitError struct {
+    code int
+    kind string
+    err  error
+}
+
+func (e *exitError) Error() string {
+    return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+    return e.err
+}
+
+// Creates an error reported as invalid usage.
+func usagef(format string, args ...interface{}) error {
+    return &exitError{code: exitUsage, kind: "usage", err: fmt.Errorf(format, args...)}
+}
+
+func noResults(query string) error {
+    return &exitError{code: exitNoResults, kind: "no_results", err: fmt.Errorf("no images found for %q", query)}
+}
+
+func partialf(format string, args ...interface{}) error {
+    return &exitError{code: exitPartial, kind: "partial", err: fmt.Errorf(format, args...)}
+}
+
+// Returns the exit code and kind of an error.
+func classify(err error) (int, string) {
+    var exit *exitError
+    var pathErr *fs.PathError
+    var linkErr *os.LinkError
+    switch {
+    case errors.As(err, &exit):
+        return exit.code, exit.kind
+    case errors.Is(err, context.Canceled):
+        return exitInterrupted, "interrupted"
+    case imagesearch.IsBlocked(err):
+        return exitBlocked, "blocked"
+    case imagesearch.IsUnpackErr(err) || imagesearch.IsMissingFields(err):
+        return exitParse, "parse"
+    case errors.As(err, &pathErr) || errors.As(err, &linkErr):
+        return exitIO, "io"
+    default:
+        return exitFailure, "error"
+    }
+}
+
+// Reports the error and returns the exit code for it. In -quiet mode the error is a single JSON object, like {"error": "...", "kind": "blocked", "code": 3}.
+func report(w io.Writer, err error) int {
+    code, kind := classify(err)
+    if quiet {
+        json.NewEncoder(w).Encode(struct {
+            Error string `json:"error"`
+            Kind  string `json:"kind"`
+            Code  int    `json:"code"`
+        }{err.Error(), kind, code})
+    } else {
+        fmt.Fprintln(w, "imagesearch:", err)
+    }
+    return code
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+    "flag"
+    "io"
+    "os"
+    "strings"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// The formats of the export command
+var exportFormats = []string{"csv", "coco"}
+
+func runExport(args []string) error {
+    set := flag.NewFlagSet("export", flag.ContinueOnError)
+    format := set.String("format", "csv", "format to export the dataset as: "+strings.Join(exportFormats, ", "))
+    output := set.String("o", "", "file to write the export to instead of stdout")
+    split := set.String("split", "", "only export the images of a split of the dataset: train, val, or test")
+    positional, err := parse(set, args)
+    if err != nil {
+        return err
+    }
+    if len(positional) != 1 {
+        set.Usage()
+        return usagef("export needs the directory of a dataset")
+    }
+    if !contains(exportFormats, *format) {
+        return usagef("invalid -format %q, expected one of: %s", *format, strings.Join(exportFormats, ", "))
+    }
+
+    dataset, err := imagesearch.LoadDataset(positional[0])
+    if err != nil {
+        return err
+    }
+    if *split != "" {
+        if dataset.Splits == nil {
+            return usagef("%s isn't split, download it with -split to split it", positional[0])
+        }
+        dataset = dataset.Split(*split)
+    }
+    var w io.Writer = os.Stdout
+    if *output != "" {
+        file, err := os.Create(*output)
+        if err != nil {
+            return err
+        }
+        defer file.Close()
+        w = file
+    }
+    if *format == "coco" {
+        return dataset.ExportCOCO(w)
+    }
+    return dataset.ExportCSV(w)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+    "flag"
+    "net/url"
+    "os"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// The flags every command that searches shares.
+type searchFlags struct {
+    limit          int
+    engine         string
+    commercialSafe bool
+    diverse        bool
+    maxPerDomain   int
+
+    color     string
+    colorType string
+    license   string
+    kind      string
+    time      string
+    aspect    string
+    format    string
+    size      string
+    safe      string
+    lang      string
+    region    string
+    site      string
+
+    proxy     string
+    userAgent string
+    timeout   time.Duration
+    retries   int
+    rate      float64
+}
+
+func (f *searchFlags) register(set *flag.FlagSet) {
+    set.IntVar(&f.limit, "limit", 0, "most images to return, 0 for all images found")
+    set.StringVar(&f.engine, "engine", "", "engine to search with, or a comma separated list of engines to fall back through, google by default: "+strings.Join(names(engines), ", "))
+    set.BoolVar(&f.diverse, "diverse", false, "order the results so consecutive images come from different domains, before -limit applies")
+    set.IntVar(&f.maxPerDomain, "max-per-domain", 0, "most results from the pages of any one domain, 0 for no cap")
+    set.BoolVar(&f.commercialSafe, "commercial-safe", false, "only return Creative Commons images with a license that allows commercial use, searching openverse and wikimedia before google unless -engine is set, and record their licenses in the manifest")
+
+    set.StringVar(&f.color, "color", "", "dominant color: "+strings.Join(names(colors), ", "))
+    set.StringVar(&f.colorType, "color-type", "", "color type: "+strings.Join(names(colorTypes), ", "))
+    set.StringVar(&f.license, "license", "", "usage rights: "+strings.Join(names(licenses), ", "))
+    set.StringVar(&f.kind, "type", "", "type of image: "+strings.Join(names(types), ", "))
+    set.StringVar(&f.time, "time", "", "time the image was published in: "+strings.Join(names(times), ", "))
+    set.StringVar(&f.aspect, "aspect", "", "aspect ratio: "+strings.Join(names(aspectRatios), ", "))
+    set.StringVar(&f.format, "filetype", "", "file format: "+strings.Join(names(formats), ", "))
+    set.StringVar(&f.size, "size", "", "size of the images: "+strings.Join(names(sizes), ", "))
+    set.StringVar(&f.safe, "safe", "", "SafeSearch: "+strings.Join(names(safeSearch), ", "))
+    set.StringVar(&f.lang, "lang", "", "two letter code of the language of the pages, like en")
+    set.StringVar(&f.region, "region", "", "two letter code of the country of the pages, like us")
+    set.StringVar(&f.site, "site", "", "domain of the pages, like example.com")
+
+    set.StringVar(&f.proxy, "proxy", "", "proxy to send every request through, like http://proxy:8080 or socks5://localhost:1080")
+    set.StringVar(&f.userAgent, "user-agent", "", "User-Agent to send instead of the browsers imagesearch imitates")
+    set.DurationVar(&f.timeout, "timeout", 0, "longest a single request may take, like 30s, 0 for no timeout")
+    set.IntVar(&f.retries, "retries", 0, "times to retry requests that fail with a network error, 429, or 5xx")
+    set.Float64Var(&f.rate, "rate", 0, "most requests per second, 0 for no limit")
+}
+
+var (
+    colors = map[string]string{
+        "red": imagesearch.Color.Red, "orange": imagesearch.Color.Orange, "yellow": imagesearch.Color.Yellow, "green": imagesearch.Color.Green,
+        "teal": imagesearch.Color.Teal, "blue": imagesearch.Color.Blue, "purple": imagesearch.Color.Purple, "pink": imagesearch.Color.Pink,
+        "white": imagesearch.Color.White, "gray": imagesearch.Color.Gray, "black": imagesearch.Color.Black, "brown": imagesearch.Color.Brown,
+    }
+    colorTypes   = map[string]string{"color": imagesearch.ColorType.Color, "grayscale": imagesearch.ColorType.Grayscale, "transparent": imagesearch.ColorType.Transparent}
+    licenses     = map[string]string{"cc": imagesearch.License.CreativeCommons, "other": imagesearch.License.Other}
+    types        = map[string]string{"face": imagesearch.Type.Face, "photo": imagesearch.Type.Photo, "clipart": imagesearch.Type.Clipart, "lineart": imagesearch.Type.Lineart, "animated": imagesearch.Type.Animated}
+    times        = map[string]string{"day": imagesearch.Time.PastDay, "week": imagesearch.Time.PastWeek, "month": imagesearch.Time.PastMonth, "year": imagesearch.Time.PastYear}
+    aspectRatios = map[string]string{"tall": imagesearch.AspectRatio.Tall, "square": imagesearch.AspectRatio.Square, "wide": imagesearch.AspectRatio.Wide, "panoramic": imagesearch.AspectRatio.Panoramic}
+    formats      = map[string]string{
+        "jpg": imagesearch.Format.Jpg, "gif": imagesearch.Format.Gif, "png": imagesearch.Format.Png, "bmp": imagesearch.Format.Bmp,
+        "svg": imagesearch.Format.Svg, "webp": imagesearch.Format.Webp, "ico": imagesearch.Format.Ico, "raw": imagesearch.Format.Raw,
+    }
+    sizes      = map[string]string{"large": imagesearch.Size.Large, "medium": imagesearch.Size.Medium, "icon": imagesearch.Size.Icon}
+    safeSearch = map[string]string{"on": imagesearch.SafeSearch.On, "off": imagesearch.SafeSearch.Off}
+
+    codePattern   = regexp.MustCompile(`^[a-zA-Z]{2}$`)
+    domainPattern = regexp.MustCompile(`^([a-zA-Z0-9-]+\.)+[a-zA-Z0-9-]+$`)
+)
+
+// Returns the arguments the filter flags stand for.
+func (f *searchFlags) arguments() ([]string, error) {
+    filters := []struct {
+        flag, value string
+        values      map[string]string
+    }{
+        {"color", f.color, colors},
+        {"color-type", f.colorType, colorTypes},
+        {"license", f.license, licenses},
+        {"type", f.kind, types},
+        {"time", f.time, times},
+        {"aspect", f.aspect, aspectRatios},
+        {"filetype", f.format, formats},
+        {"size", f.size, sizes},
+        {"safe", f.safe, safeSearch},
+    }
+
+    var arguments []string
+    for _, filter := range filters {
+        if filter.value == "" {
+            continue
+        }
+        argument, ok := filter.values[strings.ToLower(filter.value)]
+        if !ok {
+            return nil, usagef("invalid -%s %q, expected one of: %s", filter.flag, filter.value, strings.Join(names(filter.values), ", "))
+        }
+        arguments = append(arguments, argument)
+    }
+
+    if f.lang != "" {
+        if !codePattern.MatchString(f.lang) {
+            return nil, usagef("invalid -lang %q, expected a two letter language code like en, de, or ja", f.lang)
+        }
+        arguments = append(arguments, imagesearch.Language(f.lang))
+    }
+    if f.region != "" {
+        if !codePattern.MatchString(f.region) {
+            return nil, usagef("invalid -region %q, expected a two letter country code like us, gb, or jp", f.region)
+        }
+        arguments = append(arguments, imagesearch.Region(f.region))
+    }
+    if f.site != "" {
+        if !domainPattern.MatchString(f.site) {
+            return nil, usagef("invalid -site %q, expected a domain like example.com, without a scheme or path", f.site)
+        }
+        arguments = append(arguments, imagesearch.Site(f.site))
+    }
+    return arguments, nil
+}
+
+// Constructors of the engines by name. Engines that need keys read them from the environment.
+var engines = map[string]func() (func(*imagesearch.Client) imagesearch.Engine, error){
+    "google":     static(imagesearch.Google),
+    "bing":       static(imagesearch.Bing),
+    "duckduckgo": static(imagesearch.DuckDuckGo),
+    "wikimedia":  static(imagesearch.Wikimedia),
+    "openverse":  static(imagesearch.Openverse),
+    "brave": func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+        return imagesearch.Brave(os.Getenv("BRAVE_API_KEY")), nil
+    },
+    "flickr": func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+        key, err := env("FLICKR_API_KEY")
+        return imagesearch.Flickr(key), err
+    },
+    "googlecse": func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+        key, err := env("GOOGLE_API_KEY")
+        if err != nil {
+            return nil, err
+        }
+        cx, err := env("GOOGLE_CX")
+        return imagesearch.GoogleCSE(key, cx), err
+    },
+    "serpapi": func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+        key, err := env("SERPAPI_KEY")
+        return imagesearch.SerpAPI(key), err
+    },
+    "searxng": func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+        instance, err := env("SEARXNG_URL")
+        return imagesearch.SearxNG(instance), err
+    },
+}
+
+func static(engine func(*imagesearch.Client) imagesearch.Engine) func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+    return func() (func(*imagesearch.Client) imagesearch.Engine, error) {
+        return engine, nil
+    }
+}
+
+func env(name string) (string, error) {
+    value := os.Getenv(name)
+    if value == "" {
+        return "", usagef("the environment variable %s must be set", name)
+    }
+    return value, nil
+}
+
+// Returns the option setting the engine flag, falling back through the engines in order if more than one is given.
+func (f *searchFlags) engineOption() (imagesearch.Option, error) {
+    var chain []func(*imagesearch.Client) imagesearch.Engine
+    for _, name := range strings.Split(f.engine, ",") {
+        newEngine, ok := engines[strings.TrimSpace(strings.ToLower(name))]
+        if !ok {
+            return nil, usagef("unknown engine %q", name)
+        }
+        engine, err := newEngine()
+        if err != nil {
+            return nil, err
+        }
+        chain = append(chain, engine)
+    }
+    if len(chain) == 1 {
+        return imagesearch.WithEngine(chain[0]), nil
+    }
+    return imagesearch.WithEngine(imagesearch.FallbackTo(chain...)), nil
+}
+
+// Creates the Client the flags configure, with the given options on top.
+func (f *searchFlags) client(options ...imagesearch.Option) (*imagesearch.Client, error) {
+    var flagOptions []imagesearch.Option
+    if f.engine != "" {
+        engine, err := f.engineOption()
+        if err != nil {
+            return nil, err
+        }
+        flagOptions = append(flagOptions, engine)
+    }
+    network, err := f.networkOptions()
+    if err != nil {
+        return nil, err
+    }
+    flagOptions = append(flagOptions, network...)
+    if f.commercialSafe {
+        flagOptions = append(flagOptions, imagesearch.WithCommercialSafe())
+    }
+    if f.diverse {
+        flagOptions = append(flagOptions, imagesearch.WithDomainDiversity())
+    }
+    if f.maxPerDomain < 0 {
+        return nil, usagef("invalid -max-per-domain %d, expected 0 or more", f.maxPerDomain)
+    } else if f.maxPerDomain > 0 {
+        flagOptions = append(flagOptions, imagesearch.WithMaxPerDomain(f.maxPerDomain))
+    }
+    return imagesearch.NewClient(append(flagOptions, options...)...), nil
+}
+
+// Returns the options of the network flags.
+func (f *searchFlags) networkOptions() ([]imagesearch.Option, error) {
+    var options []imagesearch.Option
+    if f.proxy != "" {
+        proxy, err := url.Parse(f.proxy)
+        if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+            return nil, usagef("invalid -proxy %q, expected a url like http://proxy:8080 or socks5://localhost:1080", f.proxy)
+        }
+        options = append(options, imagesearch.WithProxy(proxy))
+    }
+    if f.userAgent != "" {
+        options = append(options, imagesearch.WithUserAgent(f.userAgent))
+    }
+    if f.timeout < 0 {
+        return nil, usagef("invalid -timeout %s, expected a positive duration", f.timeout)
+    } else if f.timeout > 0 {
+        options = append(options, imagesearch.WithTimeout(f.timeout))
+    }
+    if f.retries < 0 {
+        return nil, usagef("invalid -retries %d, expected 0 or more", f.retries)
+    } else if f.retries > 0 {
+        options = append(options, imagesearch.WithRetries(f.retries))
+    }
+    if f.rate < 0 {
+        return nil, usagef("invalid -rate %g, expected 0 or more", f.rate)
+    } else if f.rate > 0 {
+        options = append(options, imagesearch.WithRate(f.rate, 1))
+    }
+    return options, nil
+}
+
+// Returns the keys of values in order.
+func names(values interface{}) []string {
+    var keys []string
+    switch values := values.(type) {
+    case map[string]string:
+        for key := range values {
+            keys = append(keys, key)
+        }
+    case map[string]func() (func(*imagesearch.Client) imagesearch.Engine, error):
+        for key := range values {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    return keys
+}
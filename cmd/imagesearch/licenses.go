@@ -0,0 +1,74 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "os/signal"
+)
+
+func runLicenses(args []string) error {
+    set := flag.NewFlagSet("licenses", flag.ContinueOnError)
+    var flags searchFlags
+    flags.register(set)
+    set.Lookup("license").DefValue = "cc"
+    set.Set("license", "cc")
+    format := set.String("format", "text", "format of the report: text, json")
+    query, err := parseQuery(set, args)
+    if err != nil {
+        return err
+    }
+    if *format != "text" && *format != "json" {
+        return usagef("invalid -format %q, expected one of: text, json", *format)
+    }
+
+    images, err := search(query, &flags)
+    if err != nil {
+        return err
+    }
+    if len(images) == 0 {
+        return noResults(query)
+    }
+    client, err := flags.client()
+    if err != nil {
+        return err
+    }
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    checks := client.VerifyLicenses(ctx, images)
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+
+    var unconfirmed int
+    for _, check := range checks {
+        if !check.Confirmed {
+            unconfirmed++
+        }
+    }
+    if *format == "json" {
+        encoder := json.NewEncoder(os.Stdout)
+        encoder.SetIndent("", "  ")
+        if err := encoder.Encode(checks); err != nil {
+            return err
+        }
+    } else {
+        for _, check := range checks {
+            if check.Confirmed {
+                name := check.License.Name
+                if name == "" {
+                    name = check.License.Url
+                }
+                fmt.Printf("confirmed\t%s\t%s\t%s\n", check.Image.Url, name, check.From)
+            } else {
+                fmt.Printf("unconfirmed\t%s\t%s\n", check.Image.Url, check.Problem)
+            }
+        }
+    }
+    if unconfirmed > 0 {
+        return partialf("the licenses of %d of %d images couldn't be confirmed", unconfirmed, len(checks))
+    }
+    return nil
+}
@@ -0,0 +1,164 @@
+// Command imagesearch searches for and downloads images from the command line, with every filter of the package available as a flag.
+//
+// Usage:
+//
+//	imagesearch search [flags] <query>      prints the images found
+//	imagesearch urls [flags] <query>        prints the url of every image found
+//	imagesearch download [flags] <query>    downloads the images found into a directory
+//	imagesearch download -batch <file>      downloads every query of a file into a directory per query
+//	imagesearch dedupe [flags] <dir>        removes duplicate and near duplicate images from a directory
+//	imagesearch verify <dir>                checks a directory against its manifest, exiting with 1 if anything is wrong
+//	imagesearch serve [flags]               serves searches and downloads over HTTP, see the httpserver package. gRPC isn't served, see the grpc module
+//	imagesearch watch -every 6h <query>     searches again on a schedule and downloads the new images
+//	imagesearch dataset [flags] <label=query>...  downloads a folder of images for every class of a dataset
+//	imagesearch export [flags] <dir>        exports the index of a dataset as csv or coco
+//	imagesearch licenses [flags] <query>    checks the license of every creative commons image found
+//
+// Run imagesearch <command> -h for the flags of a command. Flags may come before or after the query.
+//
+// Defaults for the flags can be kept in ~/.imagesearch.yaml, or the file given with -config, as flat key: value lines named after the flags:
+//
+//	engine: bing,google
+//	dir: /data/images
+//	license: cc
+//
+// The exit code tells scripts why a command failed: 1 for any other failure, 2 for invalid flags or arguments, 3 if the engine blocked the search,
+// 4 if the search found no images, 5 if only some of the images or queries were downloaded, 6 if reading or writing a file failed,
+// 7 if the results page couldn't be parsed, and 130 if interrupted.
+// With -quiet nothing but the results is printed, and a failure is reported on stderr as a single JSON object, like {"error": "...", "kind": "blocked", "code": 3}.
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+)
+
+const usage = `Usage: imagesearch <command> [flags] <query or directory>
+
+Commands:
+  search     print the images found
+  urls       print the url of every image found
+  download   download the images found into a directory
+  dedupe     remove duplicate and near duplicate images from a directory
+  verify     check a directory against its manifest
+  serve      serve searches and downloads over HTTP, without gRPC
+  watch      search again on a schedule and download the new images
+  dataset    download a folder of images for every class of a dataset
+  export     export the index of a dataset as csv or coco
+  licenses   check the license of every creative commons image found
+
+Run imagesearch <command> -h for the flags of a command.
+`
+
+// Set by the -quiet flag of every command, which prints nothing but the results and reports failures as JSON.
+var quiet bool
+
+// Where progress, summaries, and warnings are written, discarded with -quiet.
+var stderr io.Writer = os.Stderr
+
+// A subcommand, run with the arguments after its name.
+type command func(args []string) error
+
+var commands = map[string]command{
+    "search":   runSearch,
+    "urls":     runUrls,
+    "download": runDownload,
+    "dedupe":   runDedupe,
+    "verify":   runVerify,
+    "serve":    runServe,
+    "watch":    runWatch,
+    "dataset":  runDataset,
+    "export":   runExport,
+    "licenses": runLicenses,
+}
+
+func main() {
+    if len(os.Args) < 2 {
+        fmt.Fprint(os.Stderr, usage)
+        os.Exit(2)
+    }
+
+    name := os.Args[1]
+    if name == "help" || name == "-h" || name == "--help" {
+        fmt.Print(usage)
+        return
+    }
+    run, ok := commands[name]
+    if !ok {
+        fmt.Fprintf(os.Stderr, "imagesearch: unknown command %q\n\n%s", name, usage)
+        os.Exit(exitUsage)
+    }
+
+    // -quiet has to be known before parsing, since parsing can fail
+    quiet = quietFlag(os.Args[2:])
+    if quiet {
+        stderr = io.Discard
+    }
+    if err := run(os.Args[2:]); err != nil {
+        if err == flag.ErrHelp {
+            return
+        }
+        os.Exit(report(os.Stderr, err))
+    }
+}
+
+// Whether -quiet is among args, which are parsed for it again with the rest of the flags.
+func quietFlag(args []string) bool {
+    for _, arg := range args {
+        if arg == "--" {
+            return false
+        }
+        name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+        if !strings.HasPrefix(arg, "-") || name != "quiet" {
+            continue
+        }
+        return !hasValue || value == "true" || value == "1"
+    }
+    return false
+}
+
+// Parses args with the flags of set, allowing flags after positional arguments, and returns the positional arguments.
+// The flag package stops at the first positional argument, so parsing resumes after every one of them.
+// Defaults for the flags are read from the config file first, so the flags given override them.
+func parse(set *flag.FlagSet, args []string) ([]string, error) {
+    set.SetOutput(stderr)
+    set.String("config", "", "config file of flag defaults, ~/"+configName+" if it exists")
+    set.Bool("quiet", false, "print nothing but the results, and report a failure as a json object on stderr")
+    if path := configPath(args); path != "" {
+        if err := applyConfig(set, path); err != nil {
+            return nil, err
+        }
+    }
+
+    var positional []string
+    for {
+        if err := set.Parse(args); err != nil {
+            if err == flag.ErrHelp {
+                return nil, err
+            }
+            return nil, &exitError{code: exitUsage, kind: "usage", err: err}
+        }
+        args = set.Args()
+        if len(args) == 0 {
+            return positional, nil
+        }
+        positional = append(positional, args[0])
+        args = args[1:]
+    }
+}
+
+// Parses args and returns the query, which is every positional argument joined by spaces so it doesn't need quoting.
+func parseQuery(set *flag.FlagSet, args []string) (string, error) {
+    positional, err := parse(set, args)
+    if err != nil {
+        return "", err
+    }
+    if len(positional) == 0 {
+        set.Usage()
+        return "", usagef("%s needs a query", set.Name())
+    }
+    return strings.Join(positional, " "), nil
+}
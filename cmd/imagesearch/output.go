@@ -0,0 +1,94 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// The formats of the -format flag
+var outputFormats = []string{"text", "table", "json", "ndjson", "csv"}
+
+// The columns the csv format includes unless -columns says otherwise
+var csvColumns = []string{"url", "source", "base", "width", "height", "title"}
+
+// The flags that choose how the search and urls commands write their results.
+type outputFlags struct {
+    format  string
+    columns string
+}
+
+func (f *outputFlags) register(set *flag.FlagSet) {
+    set.StringVar(&f.format, "format", "text", "output format: "+strings.Join(outputFormats, ", "))
+    set.StringVar(&f.columns, "columns", strings.Join(csvColumns, ","), "comma separated columns of the csv format: "+strings.Join(imagesearch.ImageColumns, ", "))
+}
+
+// Checks the flags before searching, so a typo doesn't waste a search.
+func (f *outputFlags) check() error {
+    if !contains(outputFormats, f.format) {
+        return usagef("invalid -format %q, expected one of: %s", f.format, strings.Join(outputFormats, ", "))
+    }
+    if f.format == "csv" {
+        for _, column := range strings.Split(f.columns, ",") {
+            if !contains(imagesearch.ImageColumns, strings.TrimSpace(column)) {
+                return usagef("invalid -columns entry %q, expected any of: %s", column, strings.Join(imagesearch.ImageColumns, ", "))
+            }
+        }
+    }
+    return nil
+}
+
+// Writes images in the format of the -format flag: text for people, table for an aligned summary of every image, json as a single array, ndjson with one image per line for jq and data pipelines,
+// or csv with a header and the -columns for spreadsheets.
+// The text format is a line per image made by text, while json and ndjson include every field of the images.
+func (f *outputFlags) write(w io.Writer, images []imagesearch.Image, text func(imagesearch.Image) string) error {
+    if err := f.check(); err != nil {
+        return err
+    }
+
+    out := bufio.NewWriter(w)
+    switch f.format {
+    case "text":
+        for _, image := range images {
+            fmt.Fprintln(out, text(image))
+        }
+    case "table":
+        fmt.Fprint(out, imagesearch.FormatTable(images))
+    case "json":
+        encoder := json.NewEncoder(out)
+        encoder.SetIndent("", "  ")
+        if err := encoder.Encode(images); err != nil {
+            return err
+        }
+    case "ndjson":
+        encoder := json.NewEncoder(out)
+        for _, image := range images {
+            if err := encoder.Encode(image); err != nil {
+                return err
+            }
+        }
+    case "csv":
+        var columns []string
+        for _, column := range strings.Split(f.columns, ",") {
+            columns = append(columns, strings.TrimSpace(column))
+        }
+        if err := imagesearch.WriteCSV(out, images, columns...); err != nil {
+            return err
+        }
+    }
+    return out.Flush()
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "path"
+    "strings"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// Width of each progress bar in characters
+const barWidth = 20
+
+// How often the bars are redrawn while an image is read
+const redrawInterval = 100 * time.Millisecond
+
+// Renders the progress of a download as a bar for the current image and a bar for the whole download, and summarizes it once it is done.
+// The bars are only drawn to terminals, so redirecting stderr to a file keeps just the summary.
+type progressBars struct {
+    out      io.Writer
+    terminal bool
+
+    start     time.Time
+    fileStart time.Time
+    drawn     time.Time
+
+    // Bytes read of every finished image, and of the current one
+    total int64
+    read  int64
+
+    last     imagesearch.Progress
+    failures []imagesearch.Progress
+}
+
+func newProgressBars(out *os.File) *progressBars {
+    info, err := out.Stat()
+    terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+    now := time.Now()
+    return &progressBars{out: out, terminal: terminal, start: now, fileStart: now}
+}
+
+// Receives the progress of the download, passed to imagesearch.WithProgress.
+func (b *progressBars) update(progress imagesearch.Progress) {
+    b.last = progress
+    if progress.Done {
+        b.total += b.read
+        b.read = 0
+        b.fileStart = time.Now()
+        if progress.Err != nil {
+            b.failures = append(b.failures, progress)
+        }
+        b.draw(progress)
+        return
+    }
+
+    b.read = progress.Read
+    if time.Since(b.drawn) >= redrawInterval {
+        b.draw(progress)
+    }
+}
+
+func (b *progressBars) draw(progress imagesearch.Progress) {
+    if !b.terminal {
+        return
+    }
+    b.drawn = time.Now()
+
+    finished := progress.Downloaded + progress.Failed
+    overall := fmt.Sprintf("%d", finished)
+    eta := ""
+    if progress.Limit > 0 {
+        overall = bar(float64(finished)/float64(progress.Limit)) + fmt.Sprintf(" %d/%d", finished, progress.Limit)
+        if finished > 0 && finished < progress.Limit {
+            remaining := time.Since(b.start) / time.Duration(finished) * time.Duration(progress.Limit-finished)
+            eta = " ETA " + duration(remaining)
+        }
+    }
+
+    file := ""
+    if !progress.Done {
+        name := path.Base(progress.Url)
+        if len(name) > 24 {
+            name = name[:21] + "..."
+        }
+        if progress.Size > 0 {
+            file = fmt.Sprintf("  %s %s %3.0f%%", name, bar(float64(progress.Read)/float64(progress.Size)), 100*float64(progress.Read)/float64(progress.Size))
+        } else {
+            file = fmt.Sprintf("  %s %s", name, bytes(progress.Read))
+        }
+        file += fmt.Sprintf(" %s/s", bytes(speed(progress.Read, time.Since(b.fileStart))))
+    }
+
+    fmt.Fprintf(b.out, "\r\033[K%s%s%s", overall, file, eta)
+}
+
+// Clears the bars, and prints how many images were downloaded and why the rest failed if summarize is set.
+func (b *progressBars) finish(summarize bool) {
+    if b.terminal {
+        fmt.Fprint(b.out, "\r\033[K")
+    }
+    if !summarize {
+        return
+    }
+
+    elapsed := time.Since(b.start)
+    total := b.total + b.read
+    fmt.Fprintf(b.out, "downloaded %d images (%s) in %s, %s/s", b.last.Downloaded, bytes(total), duration(elapsed), bytes(speed(total, elapsed)))
+    if len(b.failures) == 0 {
+        fmt.Fprintln(b.out)
+        return
+    }
+    fmt.Fprintf(b.out, ", %d failed:\n", len(b.failures))
+    for _, failure := range b.failures {
+        fmt.Fprintf(b.out, "  %s: %v\n", failure.Url, failure.Err)
+    }
+}
+
+// Draws a bar filled to the fraction, which is clamped between 0 and 1.
+func bar(fraction float64) string {
+    if fraction < 0 {
+        fraction = 0
+    } else if fraction > 1 {
+        fraction = 1
+    }
+    filled := int(fraction * barWidth)
+    return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "]"
+}
+
+func speed(n int64, elapsed time.Duration) int64 {
+    if elapsed <= 0 {
+        return 0
+    }
+    return int64(float64(n) / elapsed.Seconds())
+}
+
+// Formats a number of bytes with the largest unit that keeps it above 1, like 1.2 MB.
+func bytes(n int64) string {
+    const unit = 1000
+    if n < unit {
+        return fmt.Sprintf("%d B", n)
+    }
+    value := float64(n)
+    prefixes := "kMGT"
+    i := -1
+    for value >= unit && i < len(prefixes)-1 {
+        value /= unit
+        i++
+    }
+    return fmt.Sprintf("%.1f %cB", value, prefixes[i])
+}
+
+func duration(d time.Duration) string {
+    if d < time.Second {
+        return d.Round(time.Millisecond).String()
+    }
+    return d.Round(time.Second).String()
+}
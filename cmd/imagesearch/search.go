@@ -0,0 +1,81 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "os/signal"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+func runSearch(args []string) error {
+    set := flag.NewFlagSet("search", flag.ContinueOnError)
+    var flags searchFlags
+    flags.register(set)
+    var output outputFlags
+    output.register(set)
+    query, err := parseQuery(set, args)
+    if err != nil {
+        return err
+    }
+    if err := output.check(); err != nil {
+        return err
+    }
+
+    images, err := search(query, &flags)
+    if err != nil {
+        return err
+    }
+    err = output.write(os.Stdout, images, func(image imagesearch.Image) string {
+        return fmt.Sprintf("%d\t%s\t%s\t%s", image.Rank, image.Url, image.Source, image.Title)
+    })
+    if err == nil && len(images) == 0 {
+        return noResults(query)
+    }
+    return err
+}
+
+func runUrls(args []string) error {
+    set := flag.NewFlagSet("urls", flag.ContinueOnError)
+    var flags searchFlags
+    flags.register(set)
+    var output outputFlags
+    output.register(set)
+    query, err := parseQuery(set, args)
+    if err != nil {
+        return err
+    }
+    if err := output.check(); err != nil {
+        return err
+    }
+
+    images, err := search(query, &flags)
+    if err != nil {
+        return err
+    }
+    err = output.write(os.Stdout, images, func(image imagesearch.Image) string {
+        return image.Url
+    })
+    if err == nil && len(images) == 0 {
+        return noResults(query)
+    }
+    return err
+}
+
+// Searches for the query with the client and arguments the flags configure.
+func search(query string, flags *searchFlags) ([]imagesearch.Image, error) {
+    arguments, err := flags.arguments()
+    if err != nil {
+        return nil, err
+    }
+    client, err := flags.client()
+    if err != nil {
+        return nil, err
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    return client.Images(ctx, query, flags.limit, arguments...)
+}
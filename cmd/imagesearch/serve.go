@@ -0,0 +1,74 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "net"
+    "net/http"
+    "os"
+    "os/signal"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+    "github.com/commonkestrel/imagesearch/httpserver"
+)
+
+func runServe(args []string) error {
+    set := flag.NewFlagSet("serve", flag.ContinueOnError)
+    host := set.String("host", "", "address to listen on, every interface if empty")
+    port := set.Int("port", 8080, "port to serve the REST API on. gRPC isn't served, run the Server of the grpc module from your own program for it")
+    token := set.String("token", os.Getenv("IMAGESEARCH_TOKEN"), "bearer token every request must send, $IMAGESEARCH_TOKEN by default")
+    allowed := set.String("engines", "google", "comma separated engines requests may choose, the first of which is used when a request doesn't name one: "+strings.Join(names(engines), ", "))
+    rate := set.Float64("rate", 0, "requests allowed per second across every caller, 0 for no limit")
+    burst := set.Int("burst", 1, "requests allowed at once over the -rate")
+    root := set.String("root", ".", "directory downloads are kept under")
+    maxLimit := set.Int("max-limit", 0, "most images a request may ask for, 0 for no maximum")
+    maxConcurrent := set.Int("max-concurrent", 0, "most requests handled at once, 0 for no maximum")
+    positional, err := parse(set, args)
+    if err != nil {
+        return err
+    }
+    if len(positional) > 0 {
+        set.Usage()
+        return usagef("serve takes no arguments")
+    }
+
+    config := httpserver.Config{Root: *root, Token: *token, MaxLimit: *maxLimit, MaxConcurrent: *maxConcurrent, Rate: *rate, Burst: *burst}
+    config.Engines = map[string]func(*imagesearch.Client) imagesearch.Engine{}
+    var first func(*imagesearch.Client) imagesearch.Engine
+    for _, name := range strings.Split(*allowed, ",") {
+        name = strings.TrimSpace(strings.ToLower(name))
+        newEngine, ok := engines[name]
+        if !ok {
+            return usagef("unknown engine %q, expected any of: %s", name, strings.Join(names(engines), ", "))
+        }
+        engine, err := newEngine()
+        if err != nil {
+            return err
+        }
+        config.Engines[name] = engine
+        if first == nil {
+            first = engine
+        }
+    }
+    config.Client = imagesearch.NewClient(imagesearch.WithEngine(first))
+
+    server := &http.Server{Addr: net.JoinHostPort(*host, strconv.Itoa(*port)), Handler: httpserver.New(config)}
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    go func() {
+        <-ctx.Done()
+        shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+        server.Shutdown(shutdown)
+    }()
+
+    fmt.Fprintf(stderr, "serving on %s\n", server.Addr)
+    if err := server.ListenAndServe(); err != http.ErrServerClosed {
+        return err
+    }
+    return nil
+}
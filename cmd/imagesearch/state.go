@@ -0,0 +1,178 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// Name of the job state file written into the download directory when -state isn't given
+const jobStateName = ".imagesearch-job.json"
+
+// The state of a download, saved after every image so an interrupted download can be resumed without searching or downloading again.
+type jobState struct {
+    Query     string   `json:"query"`
+    Limit     int      `json:"limit"`
+    Arguments []string `json:"arguments,omitempty"`
+
+    // Urls found by the search that haven't been tried yet, in the order they were found
+    Pending []string `json:"pending"`
+
+    // Search results of the pending urls, by url, so their source and license reach the captions and manifest.
+    // State saved before results were kept has none, and its urls are downloaded without them.
+    Results map[string]imagesearch.Image `json:"results,omitempty"`
+
+    // Paths of the images downloaded so far, by url
+    Completed map[string]string `json:"completed"`
+
+    // Errors of the images that failed, by url. Failed images aren't retried when resuming.
+    Failures map[string]string `json:"failures,omitempty"`
+
+    path string
+}
+
+// Loads the job state at path if resume is set, otherwise searches for the query and saves a new job state to path.
+func startJob(ctx context.Context, client *imagesearch.Client, path, query string, limit int, arguments []string, resume bool) (*jobState, error) {
+    if resume {
+        job, err := loadJob(path)
+        if err != nil {
+            return nil, fmt.Errorf("no job to resume: %w", err)
+        }
+        if query != "" && query != job.Query {
+            return nil, fmt.Errorf("%s is a download of %q, not %q", path, job.Query, query)
+        }
+        return job, nil
+    }
+
+    job, err := searchJob(ctx, client, path, query, limit, arguments)
+    if err != nil {
+        return nil, err
+    }
+    return job, job.save()
+}
+
+// Searches for the query and returns a new job state for downloading the results, without saving it.
+func searchJob(ctx context.Context, client *imagesearch.Client, path, query string, limit int, arguments []string) (*jobState, error) {
+    images, err := client.Images(ctx, query, 0, arguments...)
+    if err != nil {
+        return nil, err
+    }
+    job := &jobState{Query: query, Limit: limit, Arguments: arguments, Completed: map[string]string{}, path: path}
+    for _, image := range images {
+        job.add(image)
+    }
+    return job, nil
+}
+
+// Loads the job state at path.
+func loadJob(path string) (*jobState, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    job := &jobState{path: path}
+    if err := json.Unmarshal(data, job); err != nil {
+        return nil, fmt.Errorf("invalid job state %s: %w", path, err)
+    }
+    if job.Completed == nil {
+        job.Completed = map[string]string{}
+    }
+    return job, nil
+}
+
+// Adds a search result to the end of Pending.
+func (j *jobState) add(image imagesearch.Image) {
+    if j.Results == nil {
+        j.Results = map[string]imagesearch.Image{}
+    }
+    // The raw payload and thumbnail aren't needed to download the image, and would bloat the state
+    image.Raw, image.ThumbnailData = nil, nil
+    j.Pending = append(j.Pending, image.Url)
+    j.Results[image.Url] = image
+}
+
+// Whether the url was already downloaded or failed.
+func (j *jobState) seen(url string) bool {
+    _, completed := j.Completed[url]
+    _, failed := j.Failures[url]
+    return completed || failed
+}
+
+// Downloads the pending search results until the limit is reached, saving the state as every image is done, and calls hook with the progress if it isn't nil.
+// Returns the paths of the images downloaded by this run, and the number of images missing from the whole job.
+func (j *jobState) run(ctx context.Context, client *imagesearch.Client, dir string, hook func(imagesearch.Progress)) (paths []string, missing int, err error) {
+    limit := j.Limit
+    if limit > 0 {
+        limit -= len(j.Completed)
+        if limit <= 0 {
+            return nil, 0, nil
+        }
+    }
+
+    var saveErr error
+    client = client.With(imagesearch.WithProgress(func(progress imagesearch.Progress) {
+        // Images cut off by the download being interrupted stay pending, so resuming tries them again
+        if progress.Done && (progress.Err == nil || ctx.Err() == nil) {
+            j.finish(progress)
+            if err := j.save(); err != nil && saveErr == nil {
+                saveErr = err
+            }
+        }
+        if hook != nil {
+            hook(progress)
+        }
+    }))
+
+    images := make([]imagesearch.Image, len(j.Pending))
+    for i, url := range j.Pending {
+        image, ok := j.Results[url]
+        if !ok {
+            image = imagesearch.Image{Url: url}
+        }
+        images[i] = image
+    }
+    paths, missing, err = client.DownloadImages(ctx, images, limit, dir, j.Query, j.Arguments...)
+    if err == nil {
+        err = saveErr
+    }
+    return paths, missing, err
+}
+
+// Moves the url of a finished image from Pending to Completed or Failures.
+func (j *jobState) finish(progress imagesearch.Progress) {
+    for i, url := range j.Pending {
+        if url == progress.Url {
+            j.Pending = append(j.Pending[:i], j.Pending[i+1:]...)
+            break
+        }
+    }
+    delete(j.Results, progress.Url)
+    if progress.Err != nil {
+        if j.Failures == nil {
+            j.Failures = map[string]string{}
+        }
+        j.Failures[progress.Url] = progress.Err.Error()
+        return
+    }
+    j.Completed[progress.Url] = progress.Path
+}
+
+// Writes the state to a temporary file first, so an interrupted save never leaves a truncated state behind.
+func (j *jobState) save() error {
+    data, err := json.MarshalIndent(j, "", "  ")
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+        return err
+    }
+    tmp := j.path + ".tmp"
+    if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, j.path)
+}
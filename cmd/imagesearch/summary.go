@@ -0,0 +1,94 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// The final stats of a download, printed by -summary.
+type downloadSummary struct {
+    Query string `json:"query"`
+
+    // Images saved by this run
+    Downloaded int `json:"downloaded"`
+
+    // Images that didn't need downloading, because an earlier run of a resumed download finished them or the manifest shows they are unchanged
+    Skipped int `json:"skipped"`
+
+    Failed  int `json:"failed"`
+    Missing int `json:"missing"`
+
+    // Bytes transferred by this run
+    Bytes int64 `json:"bytes"`
+
+    Duration float64 `json:"duration_seconds"`
+
+    Paths    []string          `json:"paths"`
+    Failures map[string]string `json:"failures,omitempty"`
+
+    start   time.Time
+    current string
+    read    int64
+}
+
+func newSummary(query string, skipped int) *downloadSummary {
+    return &downloadSummary{Query: query, Skipped: skipped, Paths: []string{}, start: time.Now()}
+}
+
+// Counts the progress of the download, passed to imagesearch.WithProgress.
+func (s *downloadSummary) update(progress imagesearch.Progress) {
+    if !progress.Done {
+        s.current, s.read = progress.Url, progress.Read
+        return
+    }
+
+    var read int64
+    if s.current == progress.Url {
+        read = s.read
+    }
+    s.current, s.read = "", 0
+    s.Bytes += read
+
+    switch {
+    case progress.Err != nil:
+        s.Failed++
+        if s.Failures == nil {
+            s.Failures = map[string]string{}
+        }
+        s.Failures[progress.Url] = progress.Err.Error()
+    case read == 0:
+        // Images the manifest shows are unchanged are kept without reading anything
+        s.Skipped++
+    default:
+        s.Downloaded++
+    }
+}
+
+// Records how the download ended.
+func (s *downloadSummary) finish(paths []string, missing int) {
+    s.Paths = append(s.Paths, paths...)
+    s.Missing = missing
+    s.Duration = time.Since(s.start).Seconds()
+}
+
+// Writes the summary as JSON, or as text for people.
+func (s *downloadSummary) write(w io.Writer, format string) error {
+    if format == "json" {
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(s)
+    }
+
+    fmt.Fprintf(w, "query:      %s\n", s.Query)
+    fmt.Fprintf(w, "downloaded: %d\n", s.Downloaded)
+    fmt.Fprintf(w, "skipped:    %d\n", s.Skipped)
+    fmt.Fprintf(w, "failed:     %d\n", s.Failed)
+    fmt.Fprintf(w, "missing:    %d\n", s.Missing)
+    fmt.Fprintf(w, "bytes:      %s\n", bytes(s.Bytes))
+    fmt.Fprintf(w, "duration:   %s\n", duration(time.Duration(s.Duration*float64(time.Second))))
+    return nil
+}
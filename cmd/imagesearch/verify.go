@@ -0,0 +1,44 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+func runVerify(args []string) error {
+    set := flag.NewFlagSet("verify", flag.ContinueOnError)
+    positional, err := parse(set, args)
+    if err != nil {
+        return err
+    }
+    if len(positional) != 1 {
+        set.Usage()
+        return usagef("verify needs a directory")
+    }
+    dir := positional[0]
+
+    if _, err := os.Stat(filepath.Join(dir, imagesearch.ManifestName)); err != nil {
+        return fmt.Errorf("%s has no manifest, download into it with -manifest to keep one", dir)
+    }
+    manifest, err := imagesearch.LoadManifest(dir)
+    if err != nil {
+        return err
+    }
+    problems, err := manifest.Verify()
+    if err != nil {
+        return err
+    }
+
+    for _, problem := range problems {
+        fmt.Println(problem)
+    }
+    if len(problems) > 0 {
+        return fmt.Errorf("%d problems with the %d files of %s", len(problems), len(manifest.Files), dir)
+    }
+    fmt.Fprintf(stderr, "all %d files of %s match the manifest\n", len(manifest.Files), dir)
+    return nil
+}
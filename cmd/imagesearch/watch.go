@@ -0,0 +1,122 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// Name of the state file a watch keeps in its directory, separate from the job state of downloads
+const watchStateName = ".imagesearch-watch.json"
+
+func runWatch(args []string) error {
+    set := flag.NewFlagSet("watch", flag.ContinueOnError)
+    var flags searchFlags
+    flags.register(set)
+    every := set.Duration("every", 6*time.Hour, "time between searches, like 30m or 6h")
+    query := set.String("query", "", "query to watch, which may also be given as the arguments")
+    dir := set.String("dir", ".", "directory to download the new images into")
+    webhook := set.String("webhook", "", "url to POST a summary to whenever new images are downloaded")
+    positional, err := parse(set, args)
+    if err != nil {
+        return err
+    }
+    if *query == "" {
+        *query = strings.Join(positional, " ")
+    } else if len(positional) > 0 {
+        return usagef("watch takes either -query or a query, not both")
+    }
+    if *query == "" {
+        set.Usage()
+        return usagef("watch needs a query")
+    }
+    if *every < time.Minute {
+        return usagef("-every %s is too often, watch searches at most once a minute", *every)
+    }
+
+    arguments, err := flags.arguments()
+    if err != nil {
+        return err
+    }
+    var options []imagesearch.Option
+    if *webhook != "" {
+        options = append(options, imagesearch.WithWebhook(*webhook))
+    }
+    client, err := flags.client(options...)
+    if err != nil {
+        return err
+    }
+
+    path := filepath.Join(*dir, watchStateName)
+    job, err := loadJob(path)
+    if errors.Is(err, os.ErrNotExist) {
+        job = &jobState{Query: *query, Arguments: arguments, Completed: map[string]string{}, path: path}
+    } else if err != nil {
+        return err
+    } else if job.Query != *query {
+        return usagef("%s is watching %q, not %q", *dir, job.Query, *query)
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    ticker := time.NewTicker(*every)
+    defer ticker.Stop()
+    for {
+        // A failed search is reported and retried on the next tick, so a flaky connection doesn't end the watch
+        if err := watchOnce(ctx, client, job, *dir, flags.limit, arguments); err != nil {
+            if ctx.Err() != nil {
+                return nil
+            }
+            fmt.Fprintf(stderr, "%s %s: %v\n", time.Now().Format(time.RFC3339), *query, err)
+        }
+
+        select {
+        case <-ticker.C:
+        case <-ctx.Done():
+            return nil
+        }
+    }
+}
+
+// Searches for the query of the job and downloads the images that weren't seen by earlier searches.
+func watchOnce(ctx context.Context, client *imagesearch.Client, job *jobState, dir string, limit int, arguments []string) error {
+    images, err := client.Images(ctx, job.Query, limit, arguments...)
+    if err != nil {
+        return err
+    }
+
+    pending := map[string]bool{}
+    for _, url := range job.Pending {
+        pending[url] = true
+    }
+    var found int
+    for _, image := range images {
+        if !job.seen(image.Url) && !pending[image.Url] {
+            job.add(image)
+            found++
+        }
+    }
+    if len(job.Pending) == 0 {
+        fmt.Fprintf(stderr, "%s %s: no new images\n", time.Now().Format(time.RFC3339), job.Query)
+        return job.save()
+    }
+    if err := job.save(); err != nil {
+        return err
+    }
+
+    failed := len(job.Failures)
+    paths, _, err := job.run(ctx, client, dir, nil)
+    for _, path := range paths {
+        fmt.Println(path)
+    }
+    fmt.Fprintf(stderr, "%s %s: %d new images, %d downloaded, %d failed\n", time.Now().Format(time.RFC3339), job.Query, found, len(paths), len(job.Failures)-failed)
+    return err
+}
@@ -0,0 +1,205 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "html"
+    "io"
+    "net/http"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+// Where the license of a LicenseCheck was confirmed from.
+const (
+    // The license annotations of the search result, see Image.License
+    LicenseFromResult = "result"
+
+    // The schema.org markup or license links of the page the image was found on
+    LicenseFromPage = "page"
+)
+
+// Largest source page read when looking for its license
+const maxLicensePage = 4 << 20
+
+// The outcome of checking the license of an image, see VerifyLicenses.
+type LicenseCheck struct {
+    Image Image `json:"image"`
+
+    // Whether a license could be confirmed for the image, and if so where from, LicenseFromResult or LicenseFromPage
+    Confirmed bool   `json:"confirmed"`
+    From      string `json:"from,omitempty"`
+
+    // The license that was confirmed, nil if none was
+    License *LicenseInfo `json:"license,omitempty"`
+
+    // Whether the license is a Creative Commons license or public domain dedication
+    CreativeCommons bool `json:"creative_commons"`
+
+    // Why the license couldn't be confirmed, empty if it was
+    Problem string `json:"problem,omitempty"`
+}
+
+// Checks the license of every image and flags those whose license couldn't be confirmed, see Client.VerifyLicenses.
+// Searches with License.CreativeCommons are only as reliable as the engine's filter, so check their results before reusing them.
+func VerifyLicenses(ctx context.Context, images []Image) []LicenseCheck {
+    return defaultClient.VerifyLicenses(ctx, images)
+}
+
+// Checks the license of every image, and returns a report of the license confirmed for each, flagging the images whose license couldn't be confirmed.
+// A license is confirmed from the license annotations of the search result if a license was identified by name, and otherwise from the page the image was found on,
+// which is fetched and searched for schema.org license markup, in JSON-LD or microdata, and rel="license" links.
+// Only licenses named by a url or name count, since a page merely being licensable doesn't say under what terms.
+func (c *Client) VerifyLicenses(ctx context.Context, images []Image) []LicenseCheck {
+    checks := make([]LicenseCheck, len(images))
+    for i, image := range images {
+        check := LicenseCheck{Image: image}
+        if license := image.License; license != nil && license.Name != "" {
+            check.Confirmed, check.From, check.License = true, LicenseFromResult, license
+        } else if image.Source == "" {
+            check.Problem = "the result has no license and no source page to check"
+        } else if license, err := c.pageLicense(ctx, image.Source); err != nil {
+            check.Problem = "the source page couldn't be checked: " + err.Error()
+        } else if license == nil {
+            check.Problem = "the source page has no license markup"
+        } else {
+            check.Confirmed, check.From, check.License = true, LicenseFromPage, license
+        }
+        if check.License != nil {
+            check.CreativeCommons = ccLicenseName(check.License.Url) != "" || strings.HasPrefix(check.License.Name, "CC")
+        }
+        checks[i] = check
+    }
+    return checks
+}
+
+var (
+    jsonLDPattern      = regexp.MustCompile(`(?is)<script[^>]+type=["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+    licenseLinkPattern = regexp.MustCompile(`(?is)<(?:a|link)\b[^>]*\brel=["']?[^"'>]*\blicense\b[^>]*>`)
+    licensePropPattern = regexp.MustCompile(`(?is)<[a-z]+\b[^>]*\bitemprop=["']?license\b[^>]*>`)
+    attributePattern   = regexp.MustCompile(`(?is)\b(href|content)=["']([^"']+)["']`)
+)
+
+// Fetches the page at url and looks for the license of its content, returning nil if it doesn't name one.
+func (c *Client) pageLicense(ctx context.Context, url string) (*LicenseInfo, error) {
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("User-Agent", userAgent)
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return nil, &StatusError{Url: req.URL.Host + req.URL.Path, StatusCode: resp.StatusCode, Status: resp.Status}
+    }
+    page, err := io.ReadAll(io.LimitReader(resp.Body, maxLicensePage))
+    if err != nil {
+        return nil, err
+    }
+    return findPageLicense(string(page)), nil
+}
+
+// Looks for the license of a page in its JSON-LD, its microdata, and its rel="license" links, in that order.
+func findPageLicense(page string) *LicenseInfo {
+    for _, match := range jsonLDPattern.FindAllStringSubmatch(page, -1) {
+        var data interface{}
+        if json.Unmarshal([]byte(match[1]), &data) != nil {
+            continue
+        }
+        var license LicenseInfo
+        findLicenseFields(data, &license)
+        if strings.HasPrefix(license.Url, "http") {
+            license.Name = ccLicenseName(license.Url)
+            return &license
+        } else if license.Url != "" {
+            // Some pages name the license instead of linking it
+            license.Name, license.Url = license.Url, ""
+            return &license
+        }
+    }
+
+    for _, pattern := range []*regexp.Regexp{licensePropPattern, licenseLinkPattern} {
+        for _, tag := range pattern.FindAllString(page, -1) {
+            for _, attribute := range attributePattern.FindAllStringSubmatch(tag, -1) {
+                value := html.UnescapeString(attribute[2])
+                if strings.HasPrefix(value, "http") {
+                    return &LicenseInfo{Url: value, Name: ccLicenseName(value)}
+                }
+            }
+        }
+    }
+    return nil
+}
+
+// Fills in the license, acquireLicensePage, and creator or author of the first object in the JSON-LD that has them.
+func findLicenseFields(node interface{}, license *LicenseInfo) {
+    switch value := node.(type) {
+    case []interface{}:
+        for _, child := range value {
+            findLicenseFields(child, license)
+        }
+    case map[string]interface{}:
+        if license.Url == "" {
+            license.Url = linkedUrl(value["license"])
+        }
+        if license.AcquireUrl == "" {
+            license.AcquireUrl = linkedUrl(value["acquireLicensePage"])
+        }
+        if license.Author == "" {
+            for _, key := range []string{"creator", "author"} {
+                if name := personName(value[key]); name != "" {
+                    license.Author = name
+                    break
+                }
+            }
+        }
+        keys := make([]string, 0, len(value))
+        for key := range value {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        for _, key := range keys {
+            findLicenseFields(value[key], license)
+        }
+    }
+}
+
+// Returns the url a JSON-LD property links to, which is either the url itself or an object with a url or @id.
+func linkedUrl(node interface{}) string {
+    switch value := node.(type) {
+    case string:
+        return value
+    case []interface{}:
+        if len(value) > 0 {
+            return linkedUrl(value[0])
+        }
+    case map[string]interface{}:
+        for _, key := range []string{"url", "@id"} {
+            if url, ok := value[key].(string); ok {
+                return url
+            }
+        }
+    }
+    return ""
+}
+
+// Returns the name of a JSON-LD person or organization, which is either the name itself or an object with a name.
+func personName(node interface{}) string {
+    switch value := node.(type) {
+    case string:
+        return value
+    case []interface{}:
+        if len(value) > 0 {
+            return personName(value[0])
+        }
+    case map[string]interface{}:
+        if name, ok := value["name"].(string); ok {
+            return name
+        }
+    }
+    return ""
+}
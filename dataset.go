@@ -0,0 +1,511 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "hash/fnv"
+    "math/rand"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "sync"
+    "unicode"
+)
+
+// The name of the index file kept in the root of a dataset by DownloadDataset.
+const DatasetName = "dataset.json"
+
+// A class of a dataset, which is the images found for a single query.
+type DatasetClass struct {
+    // Name of the class, which is the name of its folder and the label of its images, like "golden_retriever".
+    // Defaults to the query made into a folder name with ClassLabel.
+    Label string `json:"label"`
+
+    Query     string   `json:"query"`
+    Arguments []string `json:"arguments,omitempty"`
+
+    // Other queries for the class, like synonyms, searched in turn when the query falls short of the limit and DatasetOptions.TopUp is set, before the variants of TopUpVariants
+    Variants []string `json:"variants,omitempty"`
+
+    // Most images downloaded for the class, 0 for every image found
+    Limit int `json:"limit"`
+}
+
+// The subfolders a dataset is split into.
+const (
+    SplitTrain = "train"
+    SplitVal   = "val"
+    SplitTest  = "test"
+)
+
+// Settings of a dataset download shared by every class.
+type DatasetOptions struct {
+    // Most classes downloaded at once. Below 1 downloads one class at a time.
+    Concurrency int
+
+    // Ratios of the images of every class that go into the train, val, and test subfolders of the dataset, like dir/train/cat/img_0001.jpeg.
+    // Only the ratios between them matter, so 0.8, 0.1, 0.1 and 8, 1, 1 split the same way. A zero Splits doesn't split the dataset.
+    Splits Splits
+
+    // Seed of the shuffle that picks the split of every image, so downloading the same images with the same seed always splits them the same way
+    Seed int64
+
+    // Drops images downloaded under more than one class once every class is downloaded, keeping only the largest copy, since an image labeled as two classes poisons a classifier.
+    // Duplicates are found like CrossClassDuplicates with DefaultHashDistance. Without this, use CrossClassDuplicates to report them.
+    DropCrossClassDuplicates bool
+
+    // Tops up classes that fall short of their limit by searching their Variants, and then the variants of the query TopUpVariants returns, until the limit is met or the variants run out.
+    // Classes still short afterwards are reported by Dataset.Shortfall.
+    TopUp bool
+}
+
+// Ratios to split a dataset by, see DatasetOptions.
+type Splits struct {
+    Train float64 `json:"train"`
+    Val   float64 `json:"val"`
+    Test  float64 `json:"test"`
+}
+
+func (s Splits) total() float64 {
+    return s.Train + s.Val + s.Test
+}
+
+// An image classification dataset: a folder of images for every class, laid out like dir/<label>/img_0001.jpeg, which is the layout most training tools load directly.
+// The dataset is indexed in the root directory under DatasetName, as JSON.
+type Dataset struct {
+    dir     string
+    Classes []DatasetClass `json:"classes"`
+
+    // The splits and seed the dataset was split with, nil if it isn't split
+    Splits *Splits `json:"splits,omitempty"`
+    Seed   int64   `json:"seed,omitempty"`
+
+    // Every image downloaded for the classes, along with those that failed, grouped by class in the order of Classes and then in the order they were found
+    Entries []DatasetEntry `json:"entries"`
+}
+
+// An image of a dataset.
+type DatasetEntry struct {
+    Label string `json:"label"`
+
+    // The variant of the class's query the image was found with when topping the class up, empty if it was found with the query itself
+    Query string `json:"query,omitempty"`
+
+    // The split the image is in, SplitTrain, SplitVal, or SplitTest. Empty if the dataset isn't split, or the download failed.
+    Split string `json:"split,omitempty"`
+
+    // Path of the file relative to the root of the dataset, with forward slashes, like "cat/img_0001.jpeg", or "train/cat/img_0001.jpeg" in a split dataset.
+    // Empty if the download failed.
+    File string `json:"file,omitempty"`
+
+    // Why the download failed, empty if it didn't
+    Error string `json:"error,omitempty"`
+
+    // File of the image this one duplicated in another class, if it was dropped by DropDuplicates. Its own File is then empty.
+    Duplicate string `json:"duplicate,omitempty"`
+
+    // The search result the image was downloaded from, without its Raw JSON
+    Image Image `json:"image"`
+}
+
+// Makes a query into a class label that is safe to use as a folder name, like "golden_retriever" for "Golden Retriever".
+// Letters and digits are lowercased and kept, and every run of anything else becomes a single underscore.
+func ClassLabel(query string) string {
+    var label strings.Builder
+    underscore := false
+    for _, r := range strings.TrimSpace(query) {
+        if unicode.IsLetter(r) || unicode.IsDigit(r) {
+            label.WriteRune(unicode.ToLower(r))
+            underscore = false
+        } else if !underscore && label.Len() > 0 {
+            label.WriteByte('_')
+            underscore = true
+        }
+    }
+    return strings.TrimSuffix(label.String(), "_")
+}
+
+// Searches for the query of every class and downloads its images into a folder named after its label inside dir, then writes the index of the dataset.
+// See Client.DownloadDataset.
+func DownloadDataset(ctx context.Context, dir string, classes []DatasetClass, options DatasetOptions) (*Dataset, error) {
+    return defaultClient.DownloadDataset(ctx, dir, classes, options)
+}
+
+// Searches for the query of every class and downloads its images into a folder named after its label inside dir, like dir/cat/img_0001.jpeg, then writes the index of the dataset.
+// Files are numbered after any that are already in a class folder, so downloading into an existing dataset doesn't overwrite it, although its index is replaced.
+// With Splits, the images of every class are then moved into the train, val, and test subfolders, like dir/train/cat/img_0001.jpeg.
+//
+// Classes are downloaded concurrently, so the progress hook of the Client may be called from several goroutines at once, with the label of each class as the Query.
+// If the search of a class fails, the other classes are still downloaded and indexed, and the first error is returned along with the dataset.
+func (c *Client) DownloadDataset(ctx context.Context, dir string, classes []DatasetClass, options DatasetOptions) (*Dataset, error) {
+    dir, err := filepath.Abs(strings.ReplaceAll(dir, "\\", "/"))
+    if err != nil {
+        return nil, err
+    }
+    classes, err = labelClasses(classes, options.Splits.total() > 0)
+    if err != nil {
+        return nil, err
+    }
+    splits := options.Splits
+    if splits.Train < 0 || splits.Val < 0 || splits.Test < 0 {
+        return nil, fmt.Errorf("invalid splits %v, ratios can't be negative", splits)
+    }
+
+    concurrency := options.Concurrency
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    entries := make([][]DatasetEntry, len(classes))
+    errs := make([]error, len(classes))
+    semaphore := make(chan struct{}, concurrency)
+    var wg sync.WaitGroup
+    for i, class := range classes {
+        wg.Add(1)
+        semaphore <- struct{}{}
+        go func(i int, class DatasetClass) {
+            defer wg.Done()
+            defer func() { <-semaphore }()
+            var variants func(string) []string
+            if options.TopUp {
+                variants = TopUpVariants
+            }
+            entries[i], errs[i] = c.downloadClass(ctx, dir, class, variants)
+            if errs[i] == nil && splits.total() > 0 {
+                errs[i] = splitClass(dir, class.Label, entries[i], splits, options.Seed)
+            }
+        }(i, class)
+    }
+    wg.Wait()
+
+    dataset := &Dataset{dir: dir, Classes: classes}
+    if splits.total() > 0 {
+        dataset.Splits, dataset.Seed = &splits, options.Seed
+    }
+    for _, class := range entries {
+        dataset.Entries = append(dataset.Entries, class...)
+    }
+    if options.DropCrossClassDuplicates {
+        groups, err := dataset.CrossClassDuplicates(DefaultHashDistance)
+        if err != nil {
+            return dataset, err
+        }
+        if err := dataset.dropDuplicates(groups); err != nil {
+            return dataset, err
+        }
+    }
+    if err := dataset.Save(); err != nil {
+        return dataset, err
+    }
+    if c.captions {
+        if err := dataset.writeCaptions(); err != nil {
+            return dataset, err
+        }
+    }
+    for i, err := range errs {
+        if err != nil {
+            return dataset, fmt.Errorf("%s: %w", classes[i].Label, err)
+        }
+    }
+    return dataset, ctx.Err()
+}
+
+// Fills in the default labels of the classes, and checks that every label is a distinct folder name.
+// If split is set, labels can't be the names of the split folders either, since a class folder would be moved into or over them.
+func labelClasses(classes []DatasetClass, split bool) ([]DatasetClass, error) {
+    labeled := make([]DatasetClass, len(classes))
+    seen := map[string]bool{}
+    for i, class := range classes {
+        if class.Label == "" {
+            class.Label = ClassLabel(class.Query)
+        }
+        if class.Label == "" || class.Label == "." || class.Label == ".." || strings.ContainsAny(class.Label, `/\`) {
+            return nil, fmt.Errorf("invalid label %q for %q, labels are used as folder names", class.Label, class.Query)
+        }
+        if split && (class.Label == SplitTrain || class.Label == SplitVal || class.Label == SplitTest) {
+            return nil, fmt.Errorf("invalid label %q for %q, labels can't be the name of a split folder in a split dataset", class.Label, class.Query)
+        }
+        if seen[class.Label] {
+            return nil, fmt.Errorf("more than one class is labeled %q", class.Label)
+        }
+        seen[class.Label] = true
+        labeled[i] = class
+    }
+    return labeled, nil
+}
+
+// Searches for a class and downloads its images into its folder, returning an entry for every image tried.
+// If the class falls short of its limit and variants isn't nil, its variants are searched in turn for the rest, skipping images that were already tried.
+func (c *Client) downloadClass(ctx context.Context, dir string, class DatasetClass, variants func(string) []string) ([]DatasetEntry, error) {
+    images, err := c.Images(ctx, class.Query, c.overfetch(class.Limit), class.Arguments...)
+    if err != nil {
+        return nil, err
+    }
+
+    folder := path.Join(dir, class.Label)
+    progress := c.tracker(class.Label, class.Limit)
+    tried := map[string]bool{}
+    var entries []DatasetEntry
+    downloaded, number := 0, 1
+    download := func(query string, images []Image) {
+        for _, image := range images {
+            if class.Limit > 0 && downloaded >= class.Limit {
+                return
+            }
+            if ctx.Err() != nil || tried[image.Url] {
+                continue
+            }
+            tried[image.Url] = true
+
+            for exists(folder, datasetFile(number)) {
+                number++
+            }
+            image.Raw = nil
+            entry := DatasetEntry{Label: class.Label, Image: image}
+            if query != class.Query {
+                entry.Query = query
+            }
+            source := ImageData{Url: image.Url, Query: query, Arguments: class.Arguments, Image: image}
+            file, err := c.download(ctx, source, folder, datasetFile(number), nil, nil, progress)
+            progress.done(image.Url, file, err)
+            if err != nil {
+                entry.Error = err.Error()
+            } else {
+                entry.File = class.Label + "/" + path.Base(file)
+                downloaded++
+            }
+            entries = append(entries, entry)
+        }
+    }
+
+    download(class.Query, images)
+    if variants == nil || class.Limit == 0 {
+        return entries, nil
+    }
+    for _, variant := range append(append([]string{}, class.Variants...), variants(class.Query)...) {
+        if downloaded >= class.Limit || ctx.Err() != nil {
+            break
+        }
+        // A variant failing only means the class may stay short, which Shortfall reports
+        if images, err := c.Images(ctx, variant, c.overfetch(class.Limit-downloaded), class.Arguments...); err == nil {
+            download(variant, images)
+        }
+    }
+    return entries, nil
+}
+
+// Returns variants of a query that find more images of the same subject, for topping up classes that fall short of their limit, like "cat photo" and "cat picture" for "cat".
+func TopUpVariants(query string) []string {
+    return []string{query + " photo", query + " picture", query + " image", query + " close up", query + " high resolution"}
+}
+
+// Moves the downloaded images of a class from its folder into the split subfolders, shuffled with the seed and the label so every class is shuffled differently.
+// The entries are updated with their split and new file, and the class folder is removed once it's empty.
+func splitClass(dir, label string, entries []DatasetEntry, splits Splits, seed int64) error {
+    var downloaded []int
+    for i, entry := range entries {
+        if entry.File != "" {
+            downloaded = append(downloaded, i)
+        }
+    }
+    hash := fnv.New64a()
+    hash.Write([]byte(label))
+    random := rand.New(rand.NewSource(seed ^ int64(hash.Sum64())))
+    random.Shuffle(len(downloaded), func(i, j int) {
+        downloaded[i], downloaded[j] = downloaded[j], downloaded[i]
+    })
+
+    n := float64(len(downloaded))
+    train := int(n*splits.Train/splits.total() + 0.5)
+    val := int(n*splits.Val/splits.total() + 0.5)
+    if train+val > len(downloaded) {
+        val = len(downloaded) - train
+    }
+    for position, i := range downloaded {
+        split := SplitTest
+        if position < train {
+            split = SplitTrain
+        } else if position < train+val {
+            split = SplitVal
+        }
+
+        // Files already in the split folder from an earlier download keep their names, so the moved file may need another number
+        folder := path.Join(dir, split, label)
+        if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+            return err
+        }
+        file := path.Base(entries[i].File)
+        extension := path.Ext(file)
+        name := strings.TrimSuffix(file, extension)
+        for number := 1; exists(folder, name); number++ {
+            name = datasetFile(number)
+        }
+        if err := os.Rename(path.Join(dir, entries[i].File), path.Join(folder, name+extension)); err != nil {
+            return err
+        }
+        entries[i].Split = split
+        entries[i].File = split + "/" + label + "/" + name + extension
+    }
+    os.Remove(path.Join(dir, label))
+    return nil
+}
+
+// Returns the name of the numbered file of a class, without its extension.
+func datasetFile(number int) string {
+    return fmt.Sprintf("img_%04d", number)
+}
+
+// Loads the index of the dataset in the given directory.
+func LoadDataset(dir string) (*Dataset, error) {
+    dir, err := filepath.Abs(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    data, err := os.ReadFile(path.Join(dir, DatasetName))
+    if err != nil {
+        return nil, err
+    }
+    dataset := &Dataset{dir: dir}
+    if err := json.Unmarshal(data, dataset); err != nil {
+        return nil, err
+    }
+    return dataset, nil
+}
+
+// Returns the absolute path of the root directory of the dataset.
+func (d *Dataset) Dir() string {
+    return d.dir
+}
+
+// Returns the absolute path of the file of an entry, or an empty string if it failed to download.
+func (d *Dataset) Path(entry DatasetEntry) string {
+    if entry.File == "" {
+        return ""
+    }
+    return filepath.Join(d.dir, filepath.FromSlash(entry.File))
+}
+
+// Writes the captions of the downloaded entries into the root of the dataset, for a Client created WithCaptions.
+func (d *Dataset) writeCaptions() error {
+    queries := map[string]string{}
+    for _, class := range d.Classes {
+        queries[class.Label] = class.Query
+    }
+    var captions []Caption
+    for _, entry := range d.Entries {
+        if entry.File != "" {
+            query := entry.Query
+            if query == "" {
+                query = queries[entry.Label]
+            }
+            captions = append(captions, Caption{File: entry.File, Query: query, Title: entry.Image.Title, Description: entry.Image.Description})
+        }
+    }
+    return writeCaptions(d.dir, captions)
+}
+
+// Returns how many images every class with a limit is short of it, by label. Classes that met their limit aren't included.
+func (d *Dataset) Shortfall() map[string]int {
+    downloaded := map[string]int{}
+    for _, entry := range d.Entries {
+        if entry.File != "" {
+            downloaded[entry.Label]++
+        }
+    }
+    shortfall := map[string]int{}
+    for _, class := range d.Classes {
+        if class.Limit > 0 && downloaded[class.Label] < class.Limit {
+            shortfall[class.Label] = class.Limit - downloaded[class.Label]
+        }
+    }
+    return shortfall
+}
+
+// Finds the images that were downloaded under more than one class, either identical or with perceptual hashes at most maxDistance bits apart, like FindDuplicates.
+// Each group holds the entries of images that are duplicates of each other with at least two different labels, with the largest image first.
+// Returns an error if any of the files can't be read.
+func (d *Dataset) CrossClassDuplicates(maxDistance int) ([][]DatasetEntry, error) {
+    var paths []string
+    entries := map[string]DatasetEntry{}
+    for _, entry := range d.Entries {
+        if entry.File != "" {
+            paths = append(paths, d.Path(entry))
+            entries[d.Path(entry)] = entry
+        }
+    }
+    groups, err := FindDuplicates(paths, maxDistance)
+    if err != nil {
+        return nil, err
+    }
+
+    var duplicates [][]DatasetEntry
+    for _, group := range groups {
+        labels := map[string]bool{}
+        collision := make([]DatasetEntry, len(group))
+        for i, path := range group {
+            collision[i] = entries[path]
+            labels[collision[i].Label] = true
+        }
+        if len(labels) > 1 {
+            duplicates = append(duplicates, collision)
+        }
+    }
+    return duplicates, nil
+}
+
+// Drops every image of each group but the first, like the groups of CrossClassDuplicates, deleting its file and marking its entry as a Duplicate of the first.
+// The index of the dataset is saved afterwards.
+func (d *Dataset) DropDuplicates(groups [][]DatasetEntry) error {
+    if err := d.dropDuplicates(groups); err != nil {
+        return err
+    }
+    return d.Save()
+}
+
+func (d *Dataset) dropDuplicates(groups [][]DatasetEntry) error {
+    dropped := map[string]string{}
+    for _, group := range groups {
+        for _, entry := range group[1:] {
+            dropped[entry.File] = group[0].File
+        }
+    }
+    for i, entry := range d.Entries {
+        kept, ok := dropped[entry.File]
+        if !ok || entry.File == "" {
+            continue
+        }
+        if err := os.Remove(d.Path(entry)); err != nil && !os.IsNotExist(err) {
+            return err
+        }
+        d.Entries[i].File, d.Entries[i].Split, d.Entries[i].Duplicate = "", "", kept
+    }
+    return nil
+}
+
+// Returns a copy of the dataset with only the entries of a split, like SplitTrain, for exporting every split on its own.
+func (d *Dataset) Split(split string) *Dataset {
+    subset := &Dataset{dir: d.dir, Classes: d.Classes, Splits: d.Splits, Seed: d.Seed}
+    for _, entry := range d.Entries {
+        if entry.Split == split {
+            subset.Entries = append(subset.Entries, entry)
+        }
+    }
+    return subset
+}
+
+// Writes the index of the dataset to its root directory, creating the directory if it does not exist.
+func (d *Dataset) Save() error {
+    err := os.MkdirAll(d.dir, os.ModePerm)
+    if err != nil {
+        return err
+    }
+
+    data, err := json.MarshalIndent(d, "", "    ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(path.Join(d.dir, DatasetName), data, 0666)
+}
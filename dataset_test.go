@@ -0,0 +1,16 @@
+package imagesearch
+
+import "testing"
+
+// Labels named like a split folder are only rejected in a split dataset.
+func TestSplitLabels(t *testing.T) {
+    for _, label := range []string{SplitTrain, SplitVal, SplitTest} {
+        classes := []DatasetClass{{Query: "cat"}, {Query: "example", Label: label}}
+        if _, err := labelClasses(classes, true); err == nil {
+            t.Errorf("accepted the label %q in a split dataset", label)
+        }
+        if _, err := labelClasses(classes, false); err != nil {
+            t.Errorf("rejected the label %q in a dataset that isn't split: %v", label, err)
+        }
+    }
+}
@@ -0,0 +1,115 @@
+package imagesearch
+
+import (
+    "encoding/json"
+    "html"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// Reads the results pages of the shipped fixtures, leaving out the basic pages, which have no callback payload.
+// parsertest can't be used here, since it imports this package.
+func callbackPages(b *testing.B) []string {
+    dir := filepath.Join("parsertest", "fixtures")
+    index, err := os.ReadFile(filepath.Join(dir, "fixtures.json"))
+    if err != nil {
+        b.Fatal(err)
+    }
+    var fixtures []struct {
+        Name  string `json:"name"`
+        Basic bool   `json:"basic"`
+    }
+    if err := json.Unmarshal(index, &fixtures); err != nil {
+        b.Fatal(err)
+    }
+
+    var pages []string
+    for _, fixture := range fixtures {
+        if fixture.Basic {
+            continue
+        }
+        page, err := os.ReadFile(filepath.Join(dir, fixture.Name+".html"))
+        if err != nil {
+            b.Fatal(err)
+        }
+        pages = append(pages, string(page))
+    }
+    return pages
+}
+
+// A fixture page with the results callback padded by about 1.7 MB of data that isn't results, the size of the payload of a live results page.
+// The shipped fixtures only hold a few results each, which understates what decoding the payload costs.
+func paddedPage(b *testing.B) string {
+    page, err := os.ReadFile(filepath.Join("parsertest", "fixtures", "callback.html"))
+    if err != nil {
+        b.Fatal(err)
+    }
+    results := strings.Index(string(page), "key: 'ds:1'")
+    data := results + strings.Index(string(page[results:]), "data:[") + len("data:[")
+    padding := strings.Repeat(`[null,"text that isn't a result",[1,2,3,{"a":"b","c":[4,5,6]}],true,12345.678],`, 20000)
+    return string(page[:data]) + padding + string(page[data:])
+}
+
+// Runs decode over every fixture page, and over a padded page, reporting the allocations of each.
+func benchmarkPayload(b *testing.B, decode func(page string)) {
+    pages := callbackPages(b)
+    padded := paddedPage(b)
+    b.Run("fixtures", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            for _, page := range pages {
+                decode(page)
+            }
+        }
+    })
+    b.Run("padded", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            decode(padded)
+        }
+    })
+}
+
+// Decodes the callback payload by streaming through it, which is how pages are parsed. Compare with BenchmarkUnmarshalPayload.
+func BenchmarkReadPayload(b *testing.B) {
+    benchmarkPayload(b, func(page string) {
+        decodeCallback(page, &Diagnostics{})
+    })
+}
+
+// Decodes the callback payload the way pages were parsed before the payload was streamed:
+// every callback mentioning the envelope key is unescaped whole, decoded into a tree of interfaces, and walked for its envelopes.
+func BenchmarkUnmarshalPayload(b *testing.B) {
+    benchmarkPayload(b, func(page string) {
+        for _, block := range callbackBlocks(page) {
+            if !strings.Contains(block.data, envelopeKey) {
+                continue
+            }
+            var decoded []interface{}
+            if err := json.NewDecoder(strings.NewReader(html.UnescapeString(block.data))).Decode(&decoded); err != nil {
+                continue
+            }
+            treeEnvelopes(decoded, nil)
+        }
+    })
+}
+
+// Finds the result envelopes anywhere in a decoded tree, like the envelope strategy did before the payload was streamed.
+func treeEnvelopes(node interface{}, envelopes []interface{}) []interface{} {
+    switch value := node.(type) {
+    case []interface{}:
+        for _, child := range value {
+            envelopes = treeEnvelopes(child, envelopes)
+        }
+    case map[string]interface{}:
+        if envelope, ok := value[envelopeKey]; ok {
+            return append(envelopes, envelope)
+        }
+        for _, child := range value {
+            envelopes = treeEnvelopes(child, envelopes)
+        }
+    }
+    return envelopes
+}
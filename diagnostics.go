@@ -0,0 +1,144 @@
+package imagesearch
+
+import (
+    "context"
+    "fmt"
+    "strings"
+)
+
+// Stage identifies a single step of extracting images from a Google Images results page.
+type Stage int
+
+const (
+    // StageNone means no stage failed.
+    StageNone Stage = iota
+    // StageCallback locates the AF_initDataCallback script holding the image data.
+    StageCallback
+    // StageDecode decodes the JSON payload of the callback.
+    StageDecode
+    // StageIndex searches the payload for the result envelopes.
+    StageIndex
+    // StageFields reads the url, source, and base fields of every result.
+    StageFields
+)
+
+func (s Stage) String() string {
+    switch s {
+    case StageNone:
+        return "none"
+    case StageCallback:
+        return "callback marker"
+    case StageDecode:
+        return "json decode"
+    case StageIndex:
+        return "result envelopes"
+    case StageFields:
+        return "field types"
+    default:
+        return "unknown"
+    }
+}
+
+// Step records the outcome of a single extraction stage, along with a human readable description of what was found.
+type Step struct {
+    // Name of the strategy the stage belongs to
+    Strategy string
+    Stage    Stage
+    Ok       bool
+    Found    string
+}
+
+// Diagnostics describes how far the extraction of images from a results page got.
+// Several extraction strategies are tried in order, and Strategy is the name of the one that succeeded, if any.
+// Failed is the stage that failed in the last strategy, or StageNone if a strategy succeeded, in which case Err is nil.
+// Steps contains every stage of every strategy that was attempted, in order, so the last step is the one that failed.
+//
+// This is meant to make unpacking errors actionable. If you open an issue about an unpacking error, please include the Steps.
+type Diagnostics struct {
+    Strategy string
+    Failed   Stage
+    Err      error
+    Steps    []Step
+    // Number of images that were successfully extracted.
+    Images int
+    // Identifiable markers of the page structure
+    Schema Schema
+    // Results the successful strategy skipped because of their unexpected shape
+    Warnings []Warning
+    // Whether the page says the query has no results. If so, no strategy succeeded but Failed is StageNone, since there was nothing to extract.
+    NoResults bool
+
+    // Strategy currently being run, used to label the steps
+    strategy string
+    // Skeleton of the decoded payload, used for the Fingerprint
+    shape string
+    // Part of the page around where a stage failed, if a stage knows it
+    context string
+}
+
+func (d Diagnostics) String() string {
+    var b strings.Builder
+    for _, step := range d.Steps {
+        status := "ok"
+        if !step.Ok {
+            status = "FAILED"
+        }
+        fmt.Fprintf(&b, "%s %s: %s (%s)\n", step.Strategy, step.Stage, status, step.Found)
+    }
+    if d.Failed == StageNone {
+        for _, warning := range d.Warnings {
+            fmt.Fprintf(&b, "skipped %s\n", warning)
+        }
+        if d.NoResults {
+            fmt.Fprintf(&b, "the query has no results\n")
+        } else {
+            fmt.Fprintf(&b, "extracted %d images with the %s strategy\n", d.Images, d.Strategy)
+        }
+    }
+    return b.String()
+}
+
+// Describes a single result that was skipped because it had an unexpected shape, while the rest of the page was extracted.
+type Warning struct {
+    // Strategy that skipped the result
+    Strategy string
+    // Position of the result among the results the strategy found, starting at 0
+    Index int
+    Message string
+}
+
+func (w Warning) String() string {
+    return fmt.Sprintf("%s result %d: %s", w.Strategy, w.Index, w.Message)
+}
+
+func (d *Diagnostics) warn(index int, format string, args ...interface{}) {
+    d.Warnings = append(d.Warnings, Warning{Strategy: d.strategy, Index: index, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *Diagnostics) pass(stage Stage, format string, args ...interface{}) {
+    d.Steps = append(d.Steps, Step{Strategy: d.strategy, Stage: stage, Ok: true, Found: fmt.Sprintf(format, args...)})
+}
+
+func (d *Diagnostics) fail(stage Stage, err error, format string, args ...interface{}) {
+    d.Steps = append(d.Steps, Step{Strategy: d.strategy, Stage: stage, Ok: false, Found: fmt.Sprintf(format, args...)})
+    d.Failed = stage
+    d.Err = err
+}
+
+// Searches for the query along with the given arguments, and reports which extraction stage failed and what was found at each step.
+// The returned error is only non-nil if the page itself could not be fetched.
+func Diagnose(query string, arguments ...string) (Diagnostics, error) {
+    page, _, err := defaultClient.getPage(context.Background(), buildUrl(query, arguments), userAgent)
+    if err != nil {
+        return Diagnostics{}, err
+    }
+
+    return DiagnosePage(page), nil
+}
+
+// Runs the extraction on an already fetched results page, and reports which stage failed and what was found at each step.
+// This is useful for checking saved pages, since Diagnose fetches a new page every time.
+func DiagnosePage(page string) Diagnostics {
+    _, diagnostics := extract(page)
+    return diagnostics
+}
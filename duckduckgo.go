@@ -0,0 +1,132 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+var errNoVqd = errors.New("no vqd token on the DuckDuckGo search page; DuckDuckGo probably changed how it hands out tokens")
+
+// Returns the DuckDuckGo Images engine, which makes its requests through the given Client.
+// DuckDuckGo serves its image results as json, which is far more stable than scraping a results page, and never shows a consent page.
+// Arguments it has no equivalent for, like Format, are ignored.
+func DuckDuckGo(c *Client) Engine {
+    return &duckDuckGo{client: c}
+}
+
+// Searches DuckDuckGo Images through the json endpoint its results page loads the images from.
+type duckDuckGo struct {
+    client *Client
+}
+
+func (d *duckDuckGo) Name() string {
+    return "duckduckgo"
+}
+
+// DuckDuckGo only answers image requests that carry the vqd token of a search page for the same query
+var vqdPattern = regexp.MustCompile(`vqd=["']?([\d-]+)["']?`)
+
+// A result of the i.js endpoint.
+type duckDuckGoResult struct {
+    // Full size image
+    Image string `json:"image"`
+    // Page the image was found on
+    Url    string `json:"url"`
+    Title  string `json:"title"`
+    Width  int    `json:"width"`
+    Height int    `json:"height"`
+    // Where DuckDuckGo got the result from, usually Bing
+    Source string `json:"source"`
+}
+
+func (d *duckDuckGo) Search(ctx context.Context, query Query) ([]Image, error) {
+    page, _, err := d.client.getPage(ctx, "https://duckduckgo.com/?iax=images&ia=images&q="+url.QueryEscape(query.Text), userAgent)
+    if err != nil {
+        return nil, err
+    }
+    vqd := vqdPattern.FindStringSubmatch(page)
+    if vqd == nil {
+        return nil, errNoVqd
+    }
+
+    var response struct {
+        Results []json.RawMessage `json:"results"`
+    }
+    header := http.Header{"Referer": {"https://duckduckgo.com/"}}
+    if err := d.client.getJSON(ctx, buildDuckDuckGoUrl(query, vqd[1]), header, &response); err != nil {
+        return nil, err
+    }
+
+    images := make([]Image, 0, len(response.Results))
+    for _, raw := range response.Results {
+        var result duckDuckGoResult
+        if err := json.Unmarshal(raw, &result); err != nil || result.Image == "" {
+            continue
+        }
+        images = append(images, Image{Url: result.Image, Source: result.Url, Title: result.Title, Width: result.Width, Height: result.Height, Raw: raw})
+    }
+    return images, nil
+}
+
+// The filters of DuckDuckGo equivalent to the arguments, as the kind of filter and its value.
+var duckDuckGoFilters = map[string][2]string{
+    Color.Red:    {"color", "Red"},
+    Color.Orange: {"color", "Orange"},
+    Color.Yellow: {"color", "Yellow"},
+    Color.Green:  {"color", "Green"},
+    Color.Teal:   {"color", "Teal"},
+    Color.Blue:   {"color", "Blue"},
+    Color.Purple: {"color", "Purple"},
+    Color.Pink:   {"color", "Pink"},
+    Color.White:  {"color", "White"},
+    Color.Gray:   {"color", "Gray"},
+    Color.Black:  {"color", "Black"},
+    Color.Brown:  {"color", "Brown"},
+
+    ColorType.Color:       {"color", "color"},
+    ColorType.Grayscale:   {"color", "Monochrome"},
+    ColorType.Transparent: {"type", "transparent"},
+
+    License.CreativeCommons: {"license", "Any"},
+
+    Type.Photo:    {"type", "photo"},
+    Type.Clipart:  {"type", "clipart"},
+    Type.Lineart:  {"type", "line"},
+    Type.Animated: {"type", "gif"},
+
+    Time.PastDay:   {"time", "Day"},
+    Time.PastWeek:  {"time", "Week"},
+    Time.PastMonth: {"time", "Month"},
+    Time.PastYear:  {"time", "Year"},
+
+    AspectRatio.Tall:   {"layout", "Tall"},
+    AspectRatio.Square: {"layout", "Square"},
+    AspectRatio.Wide:   {"layout", "Wide"},
+
+    Size.Large:  {"size", "Large"},
+    Size.Medium: {"size", "Medium"},
+    Size.Icon:   {"size", "Small"},
+}
+
+// The order of the filters in the f parameter
+var duckDuckGoFilterKinds = []string{"time", "size", "color", "type", "layout", "license"}
+
+func buildDuckDuckGoUrl(query Query, vqd string) string {
+    values := map[string]string{}
+    for _, argument := range query.Arguments {
+        if filter, ok := duckDuckGoFilters[argument]; ok {
+            values[filter[0]] = filter[0] + ":" + filter[1]
+        }
+    }
+    filters := make([]string, len(duckDuckGoFilterKinds))
+    for i, kind := range duckDuckGoFilterKinds {
+        filters[i] = values[kind]
+    }
+
+    return "https://duckduckgo.com/i.js?l=us-en&o=json&p=1&q=" + url.QueryEscape(query.Text) + "&vqd=" + url.QueryEscape(vqd) + "&f=" + url.QueryEscape(strings.Join(filters, ","))
+}
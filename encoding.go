@@ -0,0 +1,290 @@
+package imagesearch
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/gob"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+    "time"
+    "unicode/utf8"
+)
+
+// The version of the JSON encoding of Image, written to the "schema" field of every encoded image.
+// The encoding is stable: fields are only ever added, so images stored by older versions of this package, including those from before the field existed, keep decoding.
+// The version only changes if a field has to change its meaning, in which case decoding converts images of older versions.
+const ImageSchemaVersion = 1
+
+// Image without its methods, so encoding it doesn't recurse into MarshalJSON
+type imageFields Image
+
+// The JSON encoding of Image, which is the fields of the image along with the version of the encoding
+type imageJSON struct {
+    Schema int `json:"schema"`
+    imageFields
+}
+
+// Encodes the image as a JSON object of its fields, named by their json tags, along with the ImageSchemaVersion as "schema".
+func (i Image) MarshalJSON() ([]byte, error) {
+    return json.Marshal(newImageJSON(i))
+}
+
+// Decodes an image encoded by MarshalJSON by this or any older version of this package. Unknown fields are ignored.
+// Fails if the image was encoded with a newer ImageSchemaVersion, since its fields may mean something else.
+func (i *Image) UnmarshalJSON(data []byte) error {
+    var decoded imageJSON
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        return err
+    }
+    image, err := decoded.image()
+    if err != nil {
+        return err
+    }
+    *i = image
+    return nil
+}
+
+func newImageJSON(image Image) imageJSON {
+    return imageJSON{Schema: ImageSchemaVersion, imageFields: imageFields(image)}
+}
+
+// Returns the decoded image, or an error if it was encoded with a newer ImageSchemaVersion.
+func (j imageJSON) image() (Image, error) {
+    if j.Schema > ImageSchemaVersion {
+        return Image{}, fmt.Errorf("image has schema version %d, newer than the supported version %d", j.Schema, ImageSchemaVersion)
+    }
+    return Image(j.imageFields), nil
+}
+
+// The JSON encoding of Video, which is that of its image along with the fields of the video.
+// Video needs its own, since it would otherwise take over the MarshalJSON of the Image it embeds and drop the fields of the video.
+type videoJSON struct {
+    imageJSON
+    Duration string `json:"duration,omitempty"`
+}
+
+// Encodes the video as the JSON object of its image, see Image.MarshalJSON, along with its "duration".
+func (v Video) MarshalJSON() ([]byte, error) {
+    return json.Marshal(videoJSON{imageJSON: newImageJSON(v.Image), Duration: v.Duration})
+}
+
+// Decodes a video encoded by MarshalJSON, failing like Image.UnmarshalJSON for newer versions.
+func (v *Video) UnmarshalJSON(data []byte) error {
+    var decoded videoJSON
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        return err
+    }
+    image, err := decoded.image()
+    if err != nil {
+        return err
+    }
+    *v = Video{Image: image, Duration: decoded.Duration}
+    return nil
+}
+
+// The JSON encoding of Article, which is that of its image along with the fields of the article, like videoJSON.
+type articleJSON struct {
+    imageJSON
+    Headline  string    `json:"headline"`
+    Publisher string    `json:"publisher,omitempty"`
+    Published time.Time `json:"published"`
+}
+
+// Encodes the article as the JSON object of its image, see Image.MarshalJSON, along with its "headline", "publisher", and "published" time.
+func (a Article) MarshalJSON() ([]byte, error) {
+    return json.Marshal(articleJSON{imageJSON: newImageJSON(a.Image), Headline: a.Headline, Publisher: a.Publisher, Published: a.Published})
+}
+
+// Decodes an article encoded by MarshalJSON, failing like Image.UnmarshalJSON for newer versions.
+func (a *Article) UnmarshalJSON(data []byte) error {
+    var decoded articleJSON
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        return err
+    }
+    image, err := decoded.image()
+    if err != nil {
+        return err
+    }
+    *a = Article{Image: image, Headline: decoded.Headline, Publisher: decoded.Publisher, Published: decoded.Published}
+    return nil
+}
+
+// The header WriteGob writes before the images, so ReadGob can tell which version of the encoding they were written with
+type gobHeader struct {
+    Schema int
+}
+
+// Writes the images to w in the gob format, which is faster to write and read than JSON and keeps every field exactly,
+// for long running services that persist large cached result sets. Read them back with ReadGob.
+// Like the JSON encoding, the images are preceded by the ImageSchemaVersion, and fields are matched by name, so images written by older versions keep decoding.
+func WriteGob(w io.Writer, images []Image) error {
+    encoder := gob.NewEncoder(w)
+    if err := encoder.Encode(gobHeader{Schema: ImageSchemaVersion}); err != nil {
+        return err
+    }
+    return encoder.Encode(images)
+}
+
+// Reads images written by WriteGob from r. Fails if they were written with a newer ImageSchemaVersion.
+func ReadGob(r io.Reader) ([]Image, error) {
+    decoder := gob.NewDecoder(r)
+    var header gobHeader
+    if err := decoder.Decode(&header); err != nil {
+        return nil, err
+    }
+    if header.Schema > ImageSchemaVersion {
+        return nil, fmt.Errorf("images have schema version %d, newer than the supported version %d", header.Schema, ImageSchemaVersion)
+    }
+    var images []Image
+    if err := decoder.Decode(&images); err != nil {
+        return nil, err
+    }
+    return images, nil
+}
+
+// The columns WriteCSV can write, named like the fields of the JSON encoding of Image, in the order it writes them by default.
+// The license columns hold the name, url, and author of the license.
+var ImageColumns = []string{
+    "rank", "url", "source", "base", "favicon", "title", "description", "engine", "id", "width", "height",
+    "license", "license_url", "author", "best_effort",
+}
+
+// Reads a column of ImageColumns from an image.
+var imageColumns = map[string]func(image Image) string{
+    "rank":        func(image Image) string { return strconv.Itoa(image.Rank) },
+    "url":         func(image Image) string { return image.Url },
+    "source":      func(image Image) string { return image.Source },
+    "base":        func(image Image) string { return image.Base },
+    "favicon":     func(image Image) string { return image.Favicon },
+    "title":       func(image Image) string { return image.Title },
+    "description": func(image Image) string { return image.Description },
+    "engine":      func(image Image) string { return image.Engine },
+    "id":          func(image Image) string { return image.Id },
+    "width":       func(image Image) string { return strconv.Itoa(image.Width) },
+    "height":      func(image Image) string { return strconv.Itoa(image.Height) },
+    "license":     func(image Image) string { return licenseField(image, func(license *LicenseInfo) string { return license.Name }) },
+    "license_url": func(image Image) string { return licenseField(image, func(license *LicenseInfo) string { return license.Url }) },
+    "author":      func(image Image) string { return licenseField(image, func(license *LicenseInfo) string { return license.Author }) },
+    "best_effort": func(image Image) string { return strconv.FormatBool(image.BestEffort) },
+}
+
+// Reads a field of the license of the image, or returns an empty string if it has none.
+func licenseField(image Image, field func(license *LicenseInfo) string) string {
+    if image.License == nil {
+        return ""
+    }
+    return field(image.License)
+}
+
+// Writes the images to w as a CSV with a header of the columns and a row per image, so services can stream exports straight into an HTTP response or a file.
+// The columns are any of ImageColumns, in the order given, and default to all of them. Fails without writing anything if a column is unknown.
+func WriteCSV(w io.Writer, images []Image, columns ...string) error {
+    if len(columns) == 0 {
+        columns = ImageColumns
+    }
+    fields := make([]func(image Image) string, len(columns))
+    for i, column := range columns {
+        field, ok := imageColumns[column]
+        if !ok {
+            return fmt.Errorf("unknown column %q, expected any of: %s", column, strings.Join(ImageColumns, ", "))
+        }
+        fields[i] = field
+    }
+
+    out := bufio.NewWriter(w)
+    writer := csv.NewWriter(out)
+    writer.Write(columns)
+    record := make([]string, len(columns))
+    for _, image := range images {
+        for i, field := range fields {
+            record[i] = field(image)
+        }
+        writer.Write(record)
+    }
+    writer.Flush()
+    if err := writer.Error(); err != nil {
+        return err
+    }
+    return out.Flush()
+}
+
+// Longest title and data: url String and FormatTable show, in characters, before cutting them short
+const (
+    maxShownTitle = 48
+    maxShownUrl   = 96
+)
+
+// Returns a one line summary of the image for debugging and logs, like:
+//	#3 1920x1080 https://example.com/image.jpg (example.com) "Title of the page"
+// The rank, dimensions, domain, and title are left out if unknown. Inline data: urls and long titles are cut short.
+func (i Image) String() string {
+    var parts []string
+    if i.Rank > 0 {
+        parts = append(parts, "#"+strconv.Itoa(i.Rank))
+    }
+    if i.Width > 0 && i.Height > 0 {
+        parts = append(parts, dimensions(i))
+    }
+    parts = append(parts, shownUrl(i.Url))
+    if domain := imageDomain(i); domain != "" && !strings.HasPrefix(i.Url, "data:") {
+        parts = append(parts, "("+domain+")")
+    }
+    if i.Title != "" {
+        parts = append(parts, strconv.Quote(shorten(i.Title, maxShownTitle)))
+    }
+    return strings.Join(parts, " ")
+}
+
+// Formats the images as a table with a header and aligned columns of their rank, dimensions, domain, title, and url, for debugging sessions and command line output.
+// Unknown values are shown as -, and inline data: urls and long titles are cut short.
+func FormatTable(images []Image) string {
+    var table strings.Builder
+    writer := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, "RANK\tSIZE\tDOMAIN\tTITLE\tURL")
+    for _, image := range images {
+        rank, size, domain, title := "-", "-", imageDomain(image), shorten(image.Title, maxShownTitle)
+        if image.Rank > 0 {
+            rank = strconv.Itoa(image.Rank)
+        }
+        if image.Width > 0 && image.Height > 0 {
+            size = dimensions(image)
+        }
+        if domain == "" {
+            domain = "-"
+        }
+        if title == "" {
+            title = "-"
+        }
+        // Tabs and line breaks in titles would break the alignment
+        title = strings.Join(strings.Fields(title), " ")
+        fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", rank, size, domain, title, shownUrl(image.Url))
+    }
+    writer.Flush()
+    return table.String()
+}
+
+// Returns the dimensions of the image, like 1920x1080.
+func dimensions(image Image) string {
+    return strconv.Itoa(image.Width) + "x" + strconv.Itoa(image.Height)
+}
+
+// Returns the url to show for an image, which is cut short if it is an inline data: url.
+func shownUrl(url string) string {
+    if strings.HasPrefix(url, "data:") {
+        return shorten(url, maxShownUrl)
+    }
+    return url
+}
+
+// Cuts text down to max characters, ending it with ... if it was cut.
+func shorten(text string, max int) string {
+    if utf8.RuneCountInString(text) <= max {
+        return text
+    }
+    runes := []rune(text)
+    return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,67 @@
+package imagesearch
+
+import (
+    "encoding/json"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestVideoJSON(t *testing.T) {
+    video := Video{Image: Image{Url: "https://example.com/thumbnail.jpg", Source: "https://example.com/watch", Title: "Example video", Rank: 2}, Duration: "3:45"}
+    data, err := json.Marshal(video)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, field := range []string{`"schema":1`, `"url":"https://example.com/thumbnail.jpg"`, `"duration":"3:45"`} {
+        if !strings.Contains(string(data), field) {
+            t.Errorf("%s is missing %s", data, field)
+        }
+    }
+
+    var decoded Video
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(decoded, video) {
+        t.Errorf("decoded %+v, expected %+v", decoded, video)
+    }
+}
+
+func TestArticleJSON(t *testing.T) {
+    article := Article{
+        Image:     Image{Url: "https://example.com/photo.jpg", Source: "https://example.com/news/1", Title: "Example headline"},
+        Headline:  "Example headline",
+        Publisher: "Example News",
+        Published: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+    }
+    data, err := json.Marshal(article)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, field := range []string{`"schema":1`, `"url":"https://example.com/photo.jpg"`, `"headline":"Example headline"`, `"publisher":"Example News"`, `"published":"2024-05-01T12:30:00Z"`} {
+        if !strings.Contains(string(data), field) {
+            t.Errorf("%s is missing %s", data, field)
+        }
+    }
+
+    var decoded Article
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        t.Fatal(err)
+    }
+    if !decoded.Published.Equal(article.Published) {
+        t.Errorf("decoded published %v, expected %v", decoded.Published, article.Published)
+    }
+    decoded.Published = article.Published
+    if !reflect.DeepEqual(decoded, article) {
+        t.Errorf("decoded %+v, expected %+v", decoded, article)
+    }
+}
+
+func TestNewerSchema(t *testing.T) {
+    var video Video
+    if err := json.Unmarshal([]byte(`{"schema":2,"url":"https://example.com/thumbnail.jpg"}`), &video); err == nil {
+        t.Error("decoded a video of a newer schema version")
+    }
+}
@@ -0,0 +1,57 @@
+package imagesearch
+
+import "context"
+
+// A search as passed to an Engine.
+type Query struct {
+    // What to search for
+    Text string
+
+    // Arguments narrowing down the search, like Color.Red. Engines ignore arguments they don't support.
+    Arguments []string
+
+    // Number of images wanted, 0 for as many as the engine can find. Engines that page through their results use it to make no more requests than needed, others ignore it.
+    // Engines may return more or fewer images than the limit.
+    Limit int
+}
+
+// An Engine searches a source of images, like Google Images.
+// Clients search with Google unless they were created WithEngine, so every function of the package works the same regardless of the engine behind it.
+type Engine interface {
+    // Searches for images matching the query, ordered from most to least relevant.
+    // A query without any results returns an empty slice rather than an error.
+    Search(ctx context.Context, query Query) ([]Image, error)
+
+    // Short lowercase name of the engine, like "google"
+    Name() string
+}
+
+// An Engine that requests its results a page at a time, and can hand over the images of every page as soon as it has them, so StreamImages sends them without waiting for the rest.
+type PagingEngine interface {
+    Engine
+
+    // Searches like Search, calling page with the images of every page in order. Stops requesting pages as soon as page returns an error, which is returned.
+    SearchPages(ctx context.Context, query Query, page func(images []Image) error) error
+}
+
+// Searches every page of a PagingEngine, for implementing its Search.
+func collectPages(ctx context.Context, engine PagingEngine, query Query) ([]Image, error) {
+    images := []Image{}
+    err := engine.SearchPages(ctx, query, func(page []Image) error {
+        images = append(images, page...)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return images, nil
+}
+
+// Searches with the engine created by newEngine instead of Google.
+// newEngine is passed the Client once all of its options are applied, so the engine can make its requests through the Client and share its HTTP settings. For example:
+//	client := imagesearch.NewClient(imagesearch.WithEngine(imagesearch.Bing))
+func WithEngine(newEngine func(*Client) Engine) Option {
+    return func(c *Client) {
+        c.newEngine = newEngine
+    }
+}
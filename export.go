@@ -0,0 +1,165 @@
+package imagesearch
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/json"
+    "image"
+    "io"
+    "os"
+    "strconv"
+    "time"
+)
+
+// The columns of the CSV written by Dataset.ExportCSV, in order.
+var DatasetColumns = []string{
+    "label", "query", "split", "file", "downloaded", "error", "duplicate",
+    "url", "source", "base", "title", "description", "engine", "rank", "width", "height",
+    "license", "license_url", "author", "best_effort",
+}
+
+// Writes a row for every entry of the dataset, with its label, the query that found it, its split, file, and download outcome followed by the metadata of its image, under a header of DatasetColumns.
+// Entries that failed to download are included with downloaded set to false and the error, so the provenance of the whole search can be loaded into pandas or DuckDB.
+func (d *Dataset) ExportCSV(w io.Writer) error {
+    out := bufio.NewWriter(w)
+    writer := csv.NewWriter(out)
+    writer.Write(DatasetColumns)
+    queries := map[string]string{}
+    for _, class := range d.Classes {
+        queries[class.Label] = class.Query
+    }
+    for _, entry := range d.Entries {
+        query := entry.Query
+        if query == "" {
+            query = queries[entry.Label]
+        }
+        image := entry.Image
+        var license LicenseInfo
+        if image.License != nil {
+            license = *image.License
+        }
+        writer.Write([]string{
+            entry.Label, query, entry.Split, entry.File, strconv.FormatBool(entry.File != ""), entry.Error, entry.Duplicate,
+            image.Url, image.Source, image.Base, image.Title, image.Description, image.Engine,
+            strconv.Itoa(image.Rank), strconv.Itoa(image.Width), strconv.Itoa(image.Height),
+            license.Name, license.Url, license.Author, strconv.FormatBool(image.BestEffort),
+        })
+    }
+    writer.Flush()
+    if err := writer.Error(); err != nil {
+        return err
+    }
+    return out.Flush()
+}
+
+// A dataset descriptor in the COCO format, as written by Dataset.ExportCOCO.
+type cocoDataset struct {
+    Info        cocoInfo         `json:"info"`
+    Licenses    []cocoLicense    `json:"licenses"`
+    Images      []cocoImage      `json:"images"`
+    Annotations []cocoAnnotation `json:"annotations"`
+    Categories  []cocoCategory   `json:"categories"`
+}
+
+type cocoInfo struct {
+    Description string `json:"description"`
+    DateCreated string `json:"date_created"`
+}
+
+type cocoLicense struct {
+    Id   int    `json:"id"`
+    Name string `json:"name"`
+    Url  string `json:"url"`
+}
+
+type cocoImage struct {
+    Id       int    `json:"id"`
+    FileName string `json:"file_name"`
+    Width    int    `json:"width"`
+    Height   int    `json:"height"`
+    License  int    `json:"license,omitempty"`
+    CocoUrl  string `json:"coco_url"`
+}
+
+type cocoAnnotation struct {
+    Id         int       `json:"id"`
+    ImageId    int       `json:"image_id"`
+    CategoryId int       `json:"category_id"`
+    Bbox       []float64 `json:"bbox"`
+    Area       float64   `json:"area"`
+    IsCrowd    int       `json:"iscrowd"`
+}
+
+type cocoCategory struct {
+    Id            int    `json:"id"`
+    Name          string `json:"name"`
+    Supercategory string `json:"supercategory"`
+    Query         string `json:"query"`
+}
+
+// Writes the dataset as a COCO descriptor: an image for every downloaded entry with its file name relative to the root of the dataset and its dimensions,
+// a category for every class named after its label with its query, and an annotation labeling each image with its class.
+// Every annotation boxes the whole image, since the images are only labeled as a whole, and images with a license get a COCO license of its name and url.
+// The dimensions are read from the files, falling back to the dimensions the search reported for formats the standard library can't decode.
+func (d *Dataset) ExportCOCO(w io.Writer) error {
+    coco := cocoDataset{
+        Info:        cocoInfo{Description: "imagesearch dataset of " + d.dir, DateCreated: time.Now().UTC().Format(time.RFC3339)},
+        Licenses:    []cocoLicense{},
+        Images:      []cocoImage{},
+        Annotations: []cocoAnnotation{},
+        Categories:  []cocoCategory{},
+    }
+    categories := map[string]int{}
+    for i, class := range d.Classes {
+        categories[class.Label] = i + 1
+        coco.Categories = append(coco.Categories, cocoCategory{Id: i + 1, Name: class.Label, Query: class.Query})
+    }
+    licenses := map[string]int{}
+
+    for _, entry := range d.Entries {
+        if entry.File == "" {
+            continue
+        }
+        image := cocoImage{Id: len(coco.Images) + 1, FileName: entry.File, Width: entry.Image.Width, Height: entry.Image.Height, CocoUrl: entry.Image.Url}
+        if width, height, err := imageSize(d.Path(entry)); err == nil {
+            image.Width, image.Height = width, height
+        }
+        if license := entry.Image.License; license != nil && (license.Name != "" || license.Url != "") {
+            key := license.Name + "\x00" + license.Url
+            if licenses[key] == 0 {
+                licenses[key] = len(coco.Licenses) + 1
+                coco.Licenses = append(coco.Licenses, cocoLicense{Id: licenses[key], Name: license.Name, Url: license.Url})
+            }
+            image.License = licenses[key]
+        }
+        coco.Images = append(coco.Images, image)
+
+        width, height := float64(image.Width), float64(image.Height)
+        coco.Annotations = append(coco.Annotations, cocoAnnotation{
+            Id:         len(coco.Annotations) + 1,
+            ImageId:    image.Id,
+            CategoryId: categories[entry.Label],
+            Bbox:       []float64{0, 0, width, height},
+            Area:       width * height,
+        })
+    }
+
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "    ")
+    return encoder.Encode(coco)
+}
+
+// Reads the dimensions of an image file from its header, without decoding the pixels.
+func imageSize(path string) (width, height int, err error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return 0, 0, err
+    }
+    defer file.Close()
+
+    config, _, err := image.DecodeConfig(file)
+    if err != nil {
+        return 0, 0, err
+    }
+    return config.Width, config.Height, nil
+}
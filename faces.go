@@ -0,0 +1,143 @@
+package imagesearch
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "image"
+    "image/color"
+)
+
+// Counts the faces in an image, see WithFaces and WithoutFaces.
+type FaceDetector interface {
+    Faces(img ImageData) (int, error)
+}
+
+// Adapts a function into a FaceDetector, for example to plug in a face detection model:
+//	imagesearch.WithFaces(imagesearch.FaceDetectorFunc(func(img imagesearch.ImageData) (int, error) {
+//	    return len(model.Detect(img.Data)), nil
+//	}))
+type FaceDetectorFunc func(img ImageData) (int, error)
+
+func (f FaceDetectorFunc) Faces(img ImageData) (int, error) {
+    return f(img)
+}
+
+// Keeps only the images the detector finds at least one face in, which is more reliable than the Type.Face argument, since engines apply it loosely.
+// A nil detector uses the built-in heuristic of SkinToneFaces. The detector runs in PhaseFilter.
+func WithFaces(detector FaceDetector) Option {
+    return faceStage(detector, true)
+}
+
+// Discards the images the detector finds a face in, like for datasets of scenes or objects that must not show people. A nil detector uses the built-in heuristic of SkinToneFaces.
+func WithoutFaces(detector FaceDetector) Option {
+    return faceStage(detector, false)
+}
+
+// Returns the filter stage keeping the images that have faces, or those that don't.
+func faceStage(detector FaceDetector, faces bool) Option {
+    if detector == nil {
+        detector = SkinToneFaces()
+    }
+    return WithStage(PipelineStage{Name: "faces", Phase: PhaseFilter, Process: func(ctx context.Context, img *ImageData) error {
+        count, err := detector.Faces(*img)
+        if err != nil {
+            return err
+        }
+        if faces && count == 0 {
+            return fmt.Errorf("%w: no faces", errFiltered)
+        } else if !faces && count > 0 {
+            return fmt.Errorf("%w: %d faces", errFiltered, count)
+        }
+        return nil
+    }})
+}
+
+// The side of the grid SkinToneFaces samples images into
+const skinGrid = 64
+
+// Returns the built-in FaceDetector, a lightweight heuristic without any model that counts the face shaped regions of skin tones in an image.
+// The image is sampled into a 64x64 grid, and every connected region of skin colored cells covering at least 1% of the image,
+// about as tall as it is wide or taller, and filling at least 40% of its bounding box counts as a face.
+// It finds the faces of portraits and close ups, but also counts bare skin, like arms, and misses small faces and faces in unusual lighting,
+// so plug in a real detector through FaceDetectorFunc where accuracy matters. Images in formats the standard library can't decode have no faces.
+func SkinToneFaces() FaceDetector {
+    return FaceDetectorFunc(func(img ImageData) (int, error) {
+        decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+        if err != nil {
+            return 0, nil
+        }
+        return countSkinRegions(decoded), nil
+    })
+}
+
+// Counts the face shaped regions of skin colored cells of the image sampled into a skinGrid square grid.
+func countSkinRegions(img image.Image) int {
+    bounds := img.Bounds()
+    if bounds.Dx() < 16 || bounds.Dy() < 16 {
+        return 0
+    }
+    var skin [skinGrid][skinGrid]bool
+    sampleGrid(img, skinGrid, func(x, y int, c color.Color) {
+        r, g, b, _ := c.RGBA()
+        skin[y][x] = isSkinTone(int(r>>8), int(g>>8), int(b>>8))
+    })
+
+    // Flood fill every region of skin cells, measuring its area and bounding box
+    var seen [skinGrid][skinGrid]bool
+    var faces int
+    for y := 0; y < skinGrid; y++ {
+        for x := 0; x < skinGrid; x++ {
+            if !skin[y][x] || seen[y][x] {
+                continue
+            }
+            area, minX, minY, maxX, maxY := 0, x, y, x, y
+            stack := []image.Point{{X: x, Y: y}}
+            seen[y][x] = true
+            for len(stack) > 0 {
+                cell := stack[len(stack)-1]
+                stack = stack[:len(stack)-1]
+                area++
+                if cell.X < minX {
+                    minX = cell.X
+                } else if cell.X > maxX {
+                    maxX = cell.X
+                }
+                if cell.Y > maxY {
+                    maxY = cell.Y
+                }
+                for _, next := range []image.Point{{X: cell.X + 1, Y: cell.Y}, {X: cell.X - 1, Y: cell.Y}, {X: cell.X, Y: cell.Y + 1}, {X: cell.X, Y: cell.Y - 1}} {
+                    if next.X >= 0 && next.X < skinGrid && next.Y >= 0 && next.Y < skinGrid && skin[next.Y][next.X] && !seen[next.Y][next.X] {
+                        seen[next.Y][next.X] = true
+                        stack = append(stack, next)
+                    }
+                }
+            }
+
+            width, height := maxX-minX+1, maxY-minY+1
+            ratio := float64(height) / float64(width)
+            if area*100 >= skinGrid*skinGrid && ratio >= 0.8 && ratio <= 2.2 && float64(area) >= 0.4*float64(width*height) {
+                faces++
+            }
+        }
+    }
+    return faces
+}
+
+// Checks if a color is a skin tone under daylight, by the RGB rule of Kovač, Peer, and Solina.
+func isSkinTone(r, g, b int) bool {
+    high, low := r, r
+    for _, channel := range []int{g, b} {
+        if channel > high {
+            high = channel
+        }
+        if channel < low {
+            low = channel
+        }
+    }
+    difference := r - g
+    if difference < 0 {
+        difference = -difference
+    }
+    return r > 95 && g > 40 && b > 20 && high-low > 15 && difference > 15 && r > g && r > b
+}
@@ -0,0 +1,64 @@
+package imagesearch
+
+import (
+    "context"
+    "fmt"
+    "strings"
+)
+
+// A Fallback is an Engine that searches with the first of its engines, and whenever an engine fails, like when it can't unpack a results page or is blocked, retries the search with the next one.
+// The Engine of every image is set to the engine that found it, so you can tell which engine answered.
+type Fallback struct {
+    engines []Engine
+}
+
+// Returns a constructor of a Fallback through the given engines in order, to be passed into WithEngine. For example:
+//	client := imagesearch.NewClient(imagesearch.WithEngine(imagesearch.FallbackTo(imagesearch.Google, imagesearch.DuckDuckGo, imagesearch.Bing)))
+func FallbackTo(engines ...func(*Client) Engine) func(*Client) Engine {
+    return func(c *Client) Engine {
+        fallback := &Fallback{}
+        for _, newEngine := range engines {
+            fallback.engines = append(fallback.engines, newEngine(c))
+        }
+        return fallback
+    }
+}
+
+// Creates a Fallback through engines that were already created, in order.
+func NewFallback(engines ...Engine) *Fallback {
+    return &Fallback{engines: engines}
+}
+
+// Returns the names of the engines joined by >, like "google>bing".
+func (f *Fallback) Name() string {
+    names := make([]string, len(f.engines))
+    for i, engine := range f.engines {
+        names[i] = engine.Name()
+    }
+    return strings.Join(names, ">")
+}
+
+// Searches with each engine in order until one succeeds. If every engine fails, the error of the first one is returned, since the later engines are only fallbacks.
+// A canceled context is never retried.
+func (f *Fallback) Search(ctx context.Context, query Query) ([]Image, error) {
+    var firstErr error
+    for _, engine := range f.engines {
+        images, err := engine.Search(ctx, query)
+        if err == nil {
+            for i := range images {
+                if images[i].Engine == "" {
+                    images[i].Engine = engine.Name()
+                }
+            }
+            return images, nil
+        }
+
+        if firstErr == nil {
+            firstErr = fmt.Errorf("%s: %w", engine.Name(), err)
+        }
+        if ctx.Err() != nil {
+            break
+        }
+    }
+    return nil, firstErr
+}
@@ -0,0 +1,49 @@
+package imagesearch
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+var errFiltered = errors.New("rejected by a filter")
+
+// An image fetched into memory, which the filters and other stages of a Client process before it is saved.
+type ImageData struct {
+    // Url the image was fetched from
+    Url string
+
+    // The query and arguments the image was found with, and the search result it was found as. Empty for images that weren't found by a search, like those of DownloadUrls or DownloadImage.
+    Query     string
+    Arguments []string
+    Image     Image
+
+    // Content of the image, and its format, like "jpeg"
+    Data   []byte
+    Format string
+
+    // Absolute path of the saved file, only set for stages of PhaseStore
+    Path string
+}
+
+// Adds a filter that decides whether every image fetched by the Client is kept, before it is saved to the directory of a download or written anywhere else, like into TFRecords.
+// This is the place for content policies, like NSFW or violence classifiers. Filters run in the order they were added, and an image is only kept if every filter keeps it.
+// The filter runs in PhaseFilter, so an error from it rejects the image as well.
+// Images that a Manifest shows are unchanged since they were last downloaded aren't fetched, so they aren't filtered again.
+func WithFilter(filter func(img ImageData) (keep bool, err error)) Option {
+    return WithStage(PipelineStage{Name: "filter", Phase: PhaseFilter, Process: func(ctx context.Context, img *ImageData) error {
+        keep, err := filter(*img)
+        if err != nil {
+            return fmt.Errorf("%w: %v", errFiltered, err)
+        }
+        if !keep {
+            return errFiltered
+        }
+        return nil
+    }})
+}
+
+// Checks if an error is the error of an image rejected by a filter added WithFilter, or by a stage of PhaseFilter.
+func IsFiltered(err error) bool {
+    return errors.Is(err, errFiltered)
+}
@@ -0,0 +1,69 @@
+package imagesearch_test
+
+import (
+    "context"
+    "flag"
+    "reflect"
+    "strings"
+    "testing"
+
+    "github.com/commonkestrel/imagesearch"
+    "github.com/commonkestrel/imagesearch/parsertest"
+)
+
+var capture = flag.String("capture", "", "query to capture the live results page and basic results page of into parsertest/fixtures, see TestCapture")
+
+// Checks that every fixture page shipped with parsertest still parses, so changes to the parser can't break a supported page layout unnoticed.
+func TestFixtures(t *testing.T) {
+    parsertest.CheckFixtures(t)
+}
+
+// Elements of live pages that Trim removes
+const clutter = `<style>.rg_i{display:block}</style><script nonce="n0nce">(function(){window.google={kEI:'x'};})();</script><svg viewBox="0 0 24 24"><path d="M0 0h24v24H0z"></path></svg>`
+
+// Trimming a page mustn't change the images extracted from it, but must remove the clutter of live pages.
+func TestTrim(t *testing.T) {
+    fixtures, err := parsertest.Fixtures()
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, fixture := range fixtures {
+        parse := imagesearch.ParsePage
+        if fixture.Basic {
+            parse = imagesearch.ParseBasicPage
+        }
+        want, _ := parse(fixture.Page)
+        trimmed := parsertest.Trim(clutter + fixture.Page + clutter)
+        if trimmed != parsertest.Trim(fixture.Page) {
+            t.Errorf("%s: the clutter wasn't trimmed", fixture.Name)
+        }
+        got, _ := parse(trimmed)
+        if !reflect.DeepEqual(got, want) {
+            t.Errorf("%s: extracted %d images from the trimmed page, expected the %d of the page", fixture.Name, len(got), len(want))
+        }
+    }
+}
+
+// Captures the live results page and basic results page for the -capture query, trims them, and saves them into the shipped fixtures as captured-<query> and captured-basic-<query>.
+// Every fixture expects as many images as were extracted from it when it was captured. Skipped unless -capture is given, since it needs network access.
+func TestCapture(t *testing.T) {
+    if *capture == "" {
+        t.Skip("no -capture query")
+    }
+
+    name := strings.Join(strings.Fields(strings.ToLower(*capture)), "-")
+    captures := []func(ctx context.Context, name, query string, minImages int, arguments ...string) (parsertest.Fixture, error){parsertest.Capture, parsertest.CaptureBasic}
+    names := []string{"captured-" + name, "captured-basic-" + name}
+    for i, fetch := range captures {
+        fixture, err := fetch(context.Background(), names[i], *capture, 0)
+        if err != nil {
+            t.Fatal(err)
+        }
+        fixture.Page = parsertest.Trim(fixture.Page)
+        fixture.MinImages = len(parsertest.Check(t, fixture))
+        if err := parsertest.Save("parsertest/fixtures", fixture); err != nil {
+            t.Fatal(err)
+        }
+        t.Logf("saved %s with %d images", fixture.Name, fixture.MinImages)
+    }
+}
@@ -0,0 +1,170 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/url"
+    "strconv"
+    "time"
+)
+
+// Returns a constructor of the Flickr engine, to be passed into WithEngine. Searching Flickr requires an apiKey, see https://www.flickr.com/services/api/misc.api_keys.html.
+// Flickr knows the license of every photo, so License.CreativeCommons only returns Creative Commons and public domain photos, and every image has its exact License along with the owner as its Author.
+// Of the other arguments, only ColorType.Grayscale and Time are supported, the rest are ignored.
+func Flickr(apiKey string) func(*Client) Engine {
+    return func(c *Client) Engine {
+        return &flickr{client: c, apiKey: apiKey}
+    }
+}
+
+// Searches Flickr through the flickr.photos.search method of its API.
+type flickr struct {
+    client *Client
+    apiKey string
+}
+
+func (f *flickr) Name() string {
+    return "flickr"
+}
+
+// A photo as returned by flickr.photos.search, with the extras the engine asks for.
+type flickrPhoto struct {
+    Id        string `json:"id"`
+    Owner     string `json:"owner"`
+    OwnerName string `json:"ownername"`
+    Title     string `json:"title"`
+    License   string `json:"license"`
+    // Original and large sizes, either of which may be missing depending on the owner's settings
+    UrlO    string      `json:"url_o"`
+    WidthO  json.Number `json:"width_o"`
+    HeightO json.Number `json:"height_o"`
+    UrlL    string      `json:"url_l"`
+    WidthL  json.Number `json:"width_l"`
+    HeightL json.Number `json:"height_l"`
+
+    Description struct {
+        Content string `json:"_content"`
+    } `json:"description"`
+}
+
+func (f *flickr) Search(ctx context.Context, query Query) ([]Image, error) {
+    var response struct {
+        Stat    string `json:"stat"`
+        Message string `json:"message"`
+        Photos  struct {
+            Photo []json.RawMessage `json:"photo"`
+        } `json:"photos"`
+    }
+    if err := f.client.getJSON(ctx, f.buildUrl(query), nil, &response); err != nil {
+        return nil, err
+    }
+    if response.Stat != "ok" {
+        return nil, errors.New("flickr: " + response.Message)
+    }
+
+    images := make([]Image, 0, len(response.Photos.Photo))
+    for _, raw := range response.Photos.Photo {
+        var photo flickrPhoto
+        if err := json.Unmarshal(raw, &photo); err != nil {
+            continue
+        }
+
+        image := Image{
+            Source:      "https://www.flickr.com/photos/" + photo.Owner + "/" + photo.Id,
+            Title:       photo.Title,
+            Description: photo.Description.Content,
+            Id:          photo.Id,
+            License:     flickrLicense(photo.License),
+            Raw:         raw,
+        }
+        if image.License != nil {
+            image.License.Author = photo.OwnerName
+        }
+        if photo.UrlO != "" {
+            image.Url = photo.UrlO
+            image.Width, _ = strconv.Atoi(photo.WidthO.String())
+            image.Height, _ = strconv.Atoi(photo.HeightO.String())
+        } else if photo.UrlL != "" {
+            image.Url = photo.UrlL
+            image.Width, _ = strconv.Atoi(photo.WidthL.String())
+            image.Height, _ = strconv.Atoi(photo.HeightL.String())
+        } else {
+            continue
+        }
+        images = append(images, image)
+    }
+    return images, nil
+}
+
+// Ids of Flickr's Creative Commons and public domain licenses
+const flickrOpenLicenses = "1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16"
+
+// How far back each Time argument reaches
+var flickrTimes = map[string]time.Duration{
+    Time.PastDay:   24 * time.Hour,
+    Time.PastWeek:  7 * 24 * time.Hour,
+    Time.PastMonth: 30 * 24 * time.Hour,
+    Time.PastYear:  365 * 24 * time.Hour,
+}
+
+func (f *flickr) buildUrl(query Query) string {
+    values := url.Values{
+        "method":         {"flickr.photos.search"},
+        "api_key":        {f.apiKey},
+        "text":           {query.Text},
+        "sort":           {"relevance"},
+        "content_type":   {"1"},
+        "media":          {"photos"},
+        "per_page":       {"100"},
+        "extras":         {"url_o,url_l,owner_name,license,description"},
+        "format":         {"json"},
+        "nojsoncallback": {"1"},
+    }
+    for _, argument := range query.Arguments {
+        switch {
+        case argument == License.CreativeCommons:
+            values.Set("license", flickrOpenLicenses)
+        case argument == ColorType.Grayscale:
+            values.Set("styles", "blackandwhite")
+        case flickrTimes[argument] != 0:
+            values.Set("min_upload_date", strconv.FormatInt(time.Now().Add(-flickrTimes[argument]).Unix(), 10))
+        }
+    }
+    return "https://api.flickr.com/services/rest/?" + values.Encode()
+}
+
+// The licenses Flickr's license ids stand for, as listed by flickr.photos.licenses.getInfo.
+var flickrLicenses = map[string]LicenseInfo{
+    "0":  {Name: "All Rights Reserved"},
+    "1":  {Url: "https://creativecommons.org/licenses/by-nc-sa/2.0/"},
+    "2":  {Url: "https://creativecommons.org/licenses/by-nc/2.0/"},
+    "3":  {Url: "https://creativecommons.org/licenses/by-nc-nd/2.0/"},
+    "4":  {Url: "https://creativecommons.org/licenses/by/2.0/"},
+    "5":  {Url: "https://creativecommons.org/licenses/by-sa/2.0/"},
+    "6":  {Url: "https://creativecommons.org/licenses/by-nd/2.0/"},
+    "7":  {Name: "No known copyright restrictions", Url: "https://www.flickr.com/commons/usage/"},
+    "8":  {Name: "United States Government Work", Url: "http://www.usa.gov/copyright.shtml"},
+    "9":  {Url: "https://creativecommons.org/publicdomain/zero/1.0/"},
+    "10": {Url: "https://creativecommons.org/publicdomain/mark/1.0/"},
+    "11": {Url: "https://creativecommons.org/licenses/by/4.0/"},
+    "12": {Url: "https://creativecommons.org/licenses/by-sa/4.0/"},
+    "13": {Url: "https://creativecommons.org/licenses/by-nd/4.0/"},
+    "14": {Url: "https://creativecommons.org/licenses/by-nc/4.0/"},
+    "15": {Url: "https://creativecommons.org/licenses/by-nc-sa/4.0/"},
+    "16": {Url: "https://creativecommons.org/licenses/by-nc-nd/4.0/"},
+}
+
+// Returns the license of a Flickr license id, or nil if the id is unknown.
+func flickrLicense(id string) *LicenseInfo {
+    license, ok := flickrLicenses[id]
+    if !ok {
+        return nil
+    }
+    if license.Name == "" {
+        license.Name = ccLicenseName(license.Url)
+    }
+    // Flickr provides licensing details for every photo
+    license.Licensable = true
+    return &license
+}
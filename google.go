@@ -0,0 +1,105 @@
+package imagesearch
+
+import "context"
+
+// Returns the Google Images engine, which makes its requests through the given Client.
+// This is the engine every Client uses unless it was created WithEngine.
+func Google(c *Client) Engine {
+    return &google{client: c}
+}
+
+// Searches Google Images by unpacking its results page, see Client.searchGoogle.
+type google struct {
+    client *Client
+}
+
+func (g *google) Search(ctx context.Context, query Query) ([]Image, error) {
+    results, err := g.client.searchGoogle(ctx, query.Text, query.Arguments)
+    return results.Images, err
+}
+
+func (g *google) Name() string {
+    return "google"
+}
+
+// Searches Google Images for the query along with the given arguments, and returns every image found.
+// The basic results page is used instead if the Client was created WithBasicResults, or if the full page can't be unpacked.
+func (c *Client) searchGoogle(ctx context.Context, query string, arguments []string) (Results, error) {
+    var results Results
+    var err error
+    if !c.basic {
+        results, err = c.searchPage(ctx, query, buildUrl(query, arguments), userAgent, strategies)
+    }
+    if c.basic || IsUnpackErr(err) {
+        var basicErr error
+        results, basicErr = c.searchPage(ctx, query, buildBasicUrl(query, arguments), basicUserAgent, basicStrategies)
+        if c.basic || basicErr == nil {
+            err = basicErr
+        }
+    }
+    if err != nil {
+        return Results{}, err
+    }
+    for i := range results.Images {
+        results.Images[i].Engine = "google"
+    }
+    return results, nil
+}
+
+func (c *Client) searchPage(ctx context.Context, query, url, agent string, strategies []strategy) (Results, error) {
+    page, final, err := c.getPage(ctx, url, agent)
+    if err != nil {
+        return Results{}, err
+    }
+
+    images, diagnostics := extractWith(page, strategies)
+    if diagnostics.Failed != StageNone {
+        if c.failureHook != nil {
+            c.failureHook(diagnostics.Fingerprint())
+        }
+        return Results{}, newUnpackError(query, final, page, diagnostics)
+    }
+    if c.strict {
+        if err := checkFields(images); err != nil {
+            return Results{}, err
+        }
+    }
+    return Results{Images: images, Warnings: diagnostics.Warnings, Schema: diagnostics.Schema}, nil
+}
+
+// Fetches the raw results page for the query along with the given arguments, without unpacking it.
+// This is the page Images would unpack with the Google engine, or the basic results page if the Client was created WithBasicResults. Useful for saving pages that fail to parse.
+func (c *Client) ResultsPage(ctx context.Context, query string, arguments ...string) (string, error) {
+    var page string
+    var err error
+    if c.basic {
+        page, _, err = c.getPage(ctx, buildBasicUrl(query, arguments), basicUserAgent)
+    } else {
+        page, _, err = c.getPage(ctx, buildUrl(query, arguments), userAgent)
+    }
+    return page, err
+}
+
+// Builds the url of the full results page.
+// Arguments containing "=", like SafeSearch or Site, are added as parameters of the url and the rest as filters in tbs.
+func buildUrl(query string, arguments []string) string {
+    url := "https://www.google.com/search?tbm=isch&q=" + query
+
+    filters, parameters := splitArguments(arguments)
+    if len(filters) > 0 {
+        url += "&tbs=ic:specific"
+    }
+    for _, argument := range filters {
+        url += "%2C" + argument
+    }
+    if len(parameters) > 0 {
+        url += "&" + parameters.Encode()
+    }
+
+    return url
+}
+
+// Builds the url of the basic results page, which Google serves to browsers without JavaScript.
+func buildBasicUrl(query string, arguments []string) string {
+    return buildUrl(query, arguments) + "&gbv=1"
+}
@@ -0,0 +1,141 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "net/url"
+    "strconv"
+)
+
+// Returns a constructor of the engine for Google's official Custom Search JSON API, to be passed into WithEngine.
+// Requires an apiKey and the cx id of a Programmable Search Engine with image search enabled, see https://developers.google.com/custom-search/v1/overview.
+// Unlike the default Google engine this never breaks when Google changes its results page and complies with Google's terms of service, but every request counts against the API's daily quota.
+// The API returns 10 images per request and at most 100 per search, so searches without a limit make 10 requests.
+// Color, ColorType, License.CreativeCommons, Type, Time, and Format arguments are supported, and the rest, like AspectRatio, Size, SafeSearch, Language, Region, and Site, are ignored.
+func GoogleCSE(apiKey, cx string) func(*Client) Engine {
+    return func(c *Client) Engine {
+        return &googleCSE{client: c, apiKey: apiKey, cx: cx}
+    }
+}
+
+// Searches through the Custom Search JSON API.
+type googleCSE struct {
+    client *Client
+    apiKey string
+    cx     string
+}
+
+func (g *googleCSE) Name() string {
+    return "googlecse"
+}
+
+// An item as returned by the Custom Search JSON API with searchType=image.
+type googleCSEItem struct {
+    Title   string `json:"title"`
+    Link    string `json:"link"`
+    Snippet string `json:"snippet"`
+    Image   struct {
+        // Page the image was found on
+        ContextLink string `json:"contextLink"`
+        Width       int    `json:"width"`
+        Height      int    `json:"height"`
+    } `json:"image"`
+}
+
+// The API doesn't return results past the 100th
+const googleCSEMax = 100
+
+func (g *googleCSE) Search(ctx context.Context, query Query) ([]Image, error) {
+    return collectPages(ctx, g, query)
+}
+
+func (g *googleCSE) SearchPages(ctx context.Context, query Query, found func(images []Image) error) error {
+    limit := query.Limit
+    if limit <= 0 || limit > googleCSEMax {
+        limit = googleCSEMax
+    }
+
+    for start := 1; start <= limit; start += 10 {
+        var response struct {
+            Items []json.RawMessage `json:"items"`
+        }
+        if err := g.client.getJSON(ctx, g.buildUrl(query, start), nil, &response); err != nil {
+            return err
+        }
+
+        images := []Image{}
+        for _, raw := range response.Items {
+            var item googleCSEItem
+            if err := json.Unmarshal(raw, &item); err != nil || item.Link == "" {
+                continue
+            }
+            images = append(images, Image{Url: item.Link, Source: item.Image.ContextLink, Title: item.Title, Description: item.Snippet, Width: item.Image.Width, Height: item.Image.Height, Raw: raw})
+        }
+        if err := found(images); err != nil {
+            return err
+        }
+        if len(response.Items) < 10 {
+            break
+        }
+    }
+    return nil
+}
+
+// The API parameters equivalent to the arguments, as the parameter and its value.
+var googleCSEParameters = map[string][2]string{
+    Color.Red:    {"imgDominantColor", "red"},
+    Color.Orange: {"imgDominantColor", "orange"},
+    Color.Yellow: {"imgDominantColor", "yellow"},
+    Color.Green:  {"imgDominantColor", "green"},
+    Color.Teal:   {"imgDominantColor", "teal"},
+    Color.Blue:   {"imgDominantColor", "blue"},
+    Color.Purple: {"imgDominantColor", "purple"},
+    Color.Pink:   {"imgDominantColor", "pink"},
+    Color.White:  {"imgDominantColor", "white"},
+    Color.Gray:   {"imgDominantColor", "gray"},
+    Color.Black:  {"imgDominantColor", "black"},
+    Color.Brown:  {"imgDominantColor", "brown"},
+
+    ColorType.Color:       {"imgColorType", "color"},
+    ColorType.Grayscale:   {"imgColorType", "gray"},
+    ColorType.Transparent: {"imgColorType", "trans"},
+
+    License.CreativeCommons: {"rights", "cc_publicdomain|cc_attribute|cc_sharealike|cc_noncommercial|cc_nonderived"},
+
+    Type.Face:     {"imgType", "face"},
+    Type.Photo:    {"imgType", "photo"},
+    Type.Clipart:  {"imgType", "clipart"},
+    Type.Lineart:  {"imgType", "lineart"},
+    Type.Animated: {"imgType", "animated"},
+
+    Time.PastDay:   {"dateRestrict", "d1"},
+    Time.PastWeek:  {"dateRestrict", "w1"},
+    Time.PastMonth: {"dateRestrict", "m1"},
+    Time.PastYear:  {"dateRestrict", "y1"},
+
+    Format.Jpg:  {"fileType", "jpg"},
+    Format.Gif:  {"fileType", "gif"},
+    Format.Png:  {"fileType", "png"},
+    Format.Bmp:  {"fileType", "bmp"},
+    Format.Svg:  {"fileType", "svg"},
+    Format.Webp: {"fileType", "webp"},
+    Format.Ico:  {"fileType", "ico"},
+    Format.Raw:  {"fileType", "raw"},
+}
+
+func (g *googleCSE) buildUrl(query Query, start int) string {
+    values := url.Values{
+        "key":        {g.apiKey},
+        "cx":         {g.cx},
+        "q":          {query.Text},
+        "searchType": {"image"},
+        "num":        {"10"},
+        "start":      {strconv.Itoa(start)},
+    }
+    for _, argument := range query.Arguments {
+        if parameter, ok := googleCSEParameters[argument]; ok {
+            values.Set(parameter[0], parameter[1])
+        }
+    }
+    return "https://www.googleapis.com/customsearch/v1?" + values.Encode()
+}
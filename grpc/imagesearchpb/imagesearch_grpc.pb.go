@@ -0,0 +1,201 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: imagesearch.proto
+
+package imagesearchpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ImageSearch_Search_FullMethodName        = "/imagesearch.v1.ImageSearch/Search"
+	ImageSearch_Download_FullMethodName      = "/imagesearch.v1.ImageSearch/Download"
+	ImageSearch_StreamResults_FullMethodName = "/imagesearch.v1.ImageSearch/StreamResults"
+)
+
+// ImageSearchClient is the client API for ImageSearch service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ImageSearchClient interface {
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	Download(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (*DownloadResponse, error)
+	StreamResults(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Image], error)
+}
+
+type imageSearchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewImageSearchClient(cc grpc.ClientConnInterface) ImageSearchClient {
+	return &imageSearchClient{cc}
+}
+
+func (c *imageSearchClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, ImageSearch_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageSearchClient) Download(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (*DownloadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DownloadResponse)
+	err := c.cc.Invoke(ctx, ImageSearch_Download_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageSearchClient) StreamResults(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Image], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImageSearch_ServiceDesc.Streams[0], ImageSearch_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, Image]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageSearch_StreamResultsClient = grpc.ServerStreamingClient[Image]
+
+// ImageSearchServer is the server API for ImageSearch service.
+// All implementations must embed UnimplementedImageSearchServer
+// for forward compatibility.
+type ImageSearchServer interface {
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	Download(context.Context, *DownloadRequest) (*DownloadResponse, error)
+	StreamResults(*SearchRequest, grpc.ServerStreamingServer[Image]) error
+	mustEmbedUnimplementedImageSearchServer()
+}
+
+// UnimplementedImageSearchServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedImageSearchServer struct{}
+
+func (UnimplementedImageSearchServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedImageSearchServer) Download(context.Context, *DownloadRequest) (*DownloadResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedImageSearchServer) StreamResults(*SearchRequest, grpc.ServerStreamingServer[Image]) error {
+	return status.Error(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedImageSearchServer) mustEmbedUnimplementedImageSearchServer() {}
+func (UnimplementedImageSearchServer) testEmbeddedByValue()                     {}
+
+// UnsafeImageSearchServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ImageSearchServer will
+// result in compilation errors.
+type UnsafeImageSearchServer interface {
+	mustEmbedUnimplementedImageSearchServer()
+}
+
+func RegisterImageSearchServer(s grpc.ServiceRegistrar, srv ImageSearchServer) {
+	// If the following call panics, it indicates UnimplementedImageSearchServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ImageSearch_ServiceDesc, srv)
+}
+
+func _ImageSearch_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageSearchServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageSearch_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageSearchServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageSearch_Download_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DownloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageSearchServer).Download(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageSearch_Download_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageSearchServer).Download(ctx, req.(*DownloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageSearch_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ImageSearchServer).StreamResults(m, &grpc.GenericServerStream[SearchRequest, Image]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImageSearch_StreamResultsServer = grpc.ServerStreamingServer[Image]
+
+// ImageSearch_ServiceDesc is the grpc.ServiceDesc for ImageSearch service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ImageSearch_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "imagesearch.v1.ImageSearch",
+	HandlerType: (*ImageSearchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _ImageSearch_Search_Handler,
+		},
+		{
+			MethodName: "Download",
+			Handler:    _ImageSearch_Download_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _ImageSearch_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "imagesearch.proto",
+}
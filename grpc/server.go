@@ -0,0 +1,132 @@
+// Package grpc serves an imagesearch.Client over gRPC, so that it can run as a sidecar and be called from any language with gRPC support.
+// The service is defined in imagesearchpb/imagesearch.proto, which clients in other languages can generate their code from.
+// It is a module of its own, so that only users of the service depend on gRPC. For example, with this package imported as imagesearchgrpc:
+//
+//	server := grpc.NewServer()
+//	imagesearchpb.RegisterImageSearchServer(server, imagesearchgrpc.NewServer(imagesearch.NewClient(), "/srv/images"))
+//	listener, err := net.Listen("tcp", ":50051")
+//	if err != nil {
+//	    return err
+//	}
+//	return server.Serve(listener)
+package grpc
+
+import (
+    "context"
+    "errors"
+    "path/filepath"
+    "strings"
+
+    "github.com/commonkestrel/imagesearch"
+    "github.com/commonkestrel/imagesearch/grpc/imagesearchpb"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// A Server implements the ImageSearch service with a Client.
+type Server struct {
+    imagesearchpb.UnimplementedImageSearchServer
+
+    client *imagesearch.Client
+    root   string
+}
+
+// Creates a Server that searches and downloads with client. Downloads go into directories under root, since the directory of a download is chosen by the caller.
+func NewServer(client *imagesearch.Client, root string) *Server {
+    return &Server{client: client, root: root}
+}
+
+func (s *Server) Search(ctx context.Context, req *imagesearchpb.SearchRequest) (*imagesearchpb.SearchResponse, error) {
+    images, err := s.client.Images(ctx, req.Query, int(req.Limit), req.Arguments...)
+    if err != nil {
+        return nil, toStatus(err)
+    }
+
+    response := &imagesearchpb.SearchResponse{Images: make([]*imagesearchpb.Image, len(images))}
+    for i, image := range images {
+        response.Images[i] = toProto(image)
+    }
+    return response, nil
+}
+
+// Sends every image as soon as the Client finds it, see imagesearch.Client.StreamImages.
+func (s *Server) StreamResults(req *imagesearchpb.SearchRequest, stream imagesearchpb.ImageSearch_StreamResultsServer) error {
+    var sendErr error
+    err := s.client.StreamImages(stream.Context(), req.Query, int(req.Limit), func(image imagesearch.Image) error {
+        sendErr = stream.Send(toProto(image))
+        return sendErr
+    }, req.Arguments...)
+    if err != nil && err == sendErr {
+        // Errors of the stream already carry their status
+        return err
+    } else if err != nil {
+        return toStatus(err)
+    }
+    return nil
+}
+
+func (s *Server) Download(ctx context.Context, req *imagesearchpb.DownloadRequest) (*imagesearchpb.DownloadResponse, error) {
+    dir, err := s.dir(req.Dir)
+    if err != nil {
+        return nil, err
+    }
+
+    paths, missing, err := s.client.Download(ctx, req.Query, int(req.Limit), dir, req.Arguments...)
+    if err != nil {
+        return nil, toStatus(err)
+    }
+    return &imagesearchpb.DownloadResponse{Paths: paths, Missing: int32(missing)}, nil
+}
+
+// Resolves the directory of a download request under the root, refusing directories outside of it.
+func (s *Server) dir(dir string) (string, error) {
+    root, err := filepath.Abs(s.root)
+    if err != nil {
+        return "", status.Error(codes.Internal, err.Error())
+    }
+    resolved := filepath.Join(root, filepath.Clean("/"+dir))
+    if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+        return "", status.Errorf(codes.InvalidArgument, "dir %q is outside of the download root", dir)
+    }
+    return resolved, nil
+}
+
+// Converts an error of the Client into a gRPC status.
+func toStatus(err error) error {
+    switch {
+    case errors.Is(err, context.Canceled):
+        return status.Error(codes.Canceled, err.Error())
+    case errors.Is(err, context.DeadlineExceeded):
+        return status.Error(codes.DeadlineExceeded, err.Error())
+    case imagesearch.IsBlocked(err):
+        return status.Error(codes.ResourceExhausted, err.Error())
+    case imagesearch.IsUnpackErr(err):
+        return status.Error(codes.Unavailable, err.Error())
+    case imagesearch.IsCacheMiss(err):
+        return status.Error(codes.NotFound, err.Error())
+    default:
+        return status.Error(codes.Internal, err.Error())
+    }
+}
+
+func toProto(image imagesearch.Image) *imagesearchpb.Image {
+    message := &imagesearchpb.Image{
+        Url:           image.Url,
+        Source:        image.Source,
+        Base:          image.Base,
+        Favicon:       image.Favicon,
+        Title:         image.Title,
+        Description:   image.Description,
+        Engine:        image.Engine,
+        Rank:          int32(image.Rank),
+        Width:         int32(image.Width),
+        Height:        int32(image.Height),
+        BestEffort:    image.BestEffort,
+        ThumbnailData: image.ThumbnailData,
+        Id:            image.Id,
+    }
+    if license := image.License; license != nil {
+        message.License = &imagesearchpb.License{Licensable: license.Licensable, Name: license.Name, Url: license.Url, AcquireUrl: license.AcquireUrl, Author: license.Author}
+    }
+    return message
+}
@@ -0,0 +1,207 @@
+package imagesearch
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "image"
+    "image/color"
+    _ "image/gif"
+    _ "image/jpeg"
+    _ "image/png"
+    "math"
+    "math/bits"
+    "os"
+    "sort"
+)
+
+// The side of the grayscale image a perceptual hash is computed from, and of the block of its lowest frequencies the hash keeps
+const (
+    phashSize  = 32
+    phashBlock = 8
+)
+
+// The default Hamming distance under which two perceptual hashes are considered near duplicates.
+// Resized and recompressed copies of an image are usually within a few bits of each other, while unrelated images are around 32 bits apart.
+const DefaultHashDistance = 6
+
+// Hashes of an image file: the SHA-256 of its bytes for exact duplicates, and the perceptual hash of its pixels for near duplicates.
+type ImageHash struct {
+    // Hex encoded SHA-256 checksum of the file, like the checksums of a Manifest
+    Sha256 string
+
+    // Perceptual hash of the image, which is close for images that look alike. Only valid if Decoded is true.
+    PHash   uint64
+    Decoded bool
+
+    // Dimensions of the image in pixels, 0 if it couldn't be decoded
+    Width, Height int
+}
+
+// Hashes the image file at path. The perceptual hash is only computed for the formats the standard library decodes, JPEG, PNG, and GIF,
+// so other files, like WebP images, only have a Sha256 and are only matched by ExactDuplicate.
+func HashFile(path string) (ImageHash, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return ImageHash{}, err
+    }
+    return HashImage(data), nil
+}
+
+// Hashes the bytes of an image file, like HashFile.
+func HashImage(data []byte) ImageHash {
+    sum := sha256.Sum256(data)
+    hash := ImageHash{Sha256: hex.EncodeToString(sum[:])}
+
+    img, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return hash
+    }
+    hash.PHash = PHash(img)
+    hash.Decoded = true
+    hash.Width, hash.Height = img.Bounds().Dx(), img.Bounds().Dy()
+    return hash
+}
+
+// Whether the files are identical.
+func (h ImageHash) ExactDuplicate(other ImageHash) bool {
+    return h.Sha256 == other.Sha256
+}
+
+// Whether the files are identical, or both images decoded and their perceptual hashes are at most maxDistance bits apart.
+func (h ImageHash) Duplicate(other ImageHash, maxDistance int) bool {
+    if h.ExactDuplicate(other) {
+        return true
+    }
+    return h.Decoded && other.Decoded && HashDistance(h.PHash, other.PHash) <= maxDistance
+}
+
+// Divides the image into a size by size grid, and calls fn with the color at the center of every cell, by the column and row of the cell.
+func sampleGrid(img image.Image, size int, fn func(x, y int, c color.Color)) {
+    bounds := img.Bounds()
+    for y := 0; y < size; y++ {
+        for x := 0; x < size; x++ {
+            px := bounds.Min.X + (2*x+1)*bounds.Dx()/(2*size)
+            py := bounds.Min.Y + (2*y+1)*bounds.Dy()/(2*size)
+            fn(x, y, img.At(px, py))
+        }
+    }
+}
+
+// Computes the perceptual hash of an image: the signs of the lowest frequencies of the discrete cosine transform of a 32x32 grayscale copy, compared to their median.
+// Resizing, recompressing, and small edits barely change the hash, so images that look alike have hashes a small HashDistance apart.
+func PHash(img image.Image) uint64 {
+    // Nearest neighbour sampling of the center of each cell is enough, since only the low frequencies are kept
+    var pixels [phashSize][phashSize]float64
+    sampleGrid(img, phashSize, func(x, y int, c color.Color) {
+        r, g, b, _ := c.RGBA()
+        pixels[y][x] = 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+    })
+
+    var coefficients []float64
+    for v := 0; v < phashBlock; v++ {
+        for u := 0; u < phashBlock; u++ {
+            var sum float64
+            for y := 0; y < phashSize; y++ {
+                for x := 0; x < phashSize; x++ {
+                    sum += pixels[y][x] * dctCos[u][x] * dctCos[v][y]
+                }
+            }
+            coefficients = append(coefficients, sum)
+        }
+    }
+
+    // The DC coefficient only holds the average brightness, so it is left out of the median
+    sorted := append([]float64{}, coefficients[1:]...)
+    sort.Float64s(sorted)
+    median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+    var hash uint64
+    for i, coefficient := range coefficients {
+        if coefficient > median {
+            hash |= 1 << uint(i)
+        }
+    }
+    return hash
+}
+
+// The cosines of the discrete cosine transform, by frequency and position
+var dctCos = func() (table [phashBlock][phashSize]float64) {
+    for u := range table {
+        for x := range table[u] {
+            table[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSize))
+        }
+    }
+    return table
+}()
+
+// Returns the number of bits two perceptual hashes differ in, from 0 for images that look the same to 64.
+func HashDistance(a, b uint64) int {
+    return bits.OnesCount64(a ^ b)
+}
+
+// Groups the image files that are duplicates of each other, either identical or with perceptual hashes at most maxDistance bits apart.
+// Each group has at least two paths, and its first path is the one to keep: the image with the most pixels, or the first one given if they are the same size.
+// A negative maxDistance only groups identical files. Returns an error if any of the files can't be read.
+func FindDuplicates(paths []string, maxDistance int) ([][]string, error) {
+    hashes := make([]ImageHash, len(paths))
+    for i, path := range paths {
+        hash, err := HashFile(path)
+        if err != nil {
+            return nil, err
+        }
+        hashes[i] = hash
+    }
+    return groupDuplicates(paths, hashes, maxDistance), nil
+}
+
+// Groups the paths whose hashes are duplicates, with the largest image of each group first.
+// Near duplicates are transitive, so a group can hold images further than maxDistance apart if others link them.
+func groupDuplicates(paths []string, hashes []ImageHash, maxDistance int) [][]string {
+    parent := make([]int, len(hashes))
+    for i := range parent {
+        parent[i] = i
+    }
+    find := func(i int) int {
+        for parent[i] != i {
+            parent[i] = parent[parent[i]]
+            i = parent[i]
+        }
+        return i
+    }
+
+    for i := range hashes {
+        for j := i + 1; j < len(hashes); j++ {
+            if hashes[i].Duplicate(hashes[j], maxDistance) {
+                parent[find(j)] = find(i)
+            }
+        }
+    }
+
+    members := map[int][]int{}
+    var roots []int
+    for i := range hashes {
+        root := find(i)
+        if _, ok := members[root]; !ok {
+            roots = append(roots, root)
+        }
+        members[root] = append(members[root], i)
+    }
+
+    var groups [][]string
+    for _, root := range roots {
+        indices := members[root]
+        if len(indices) < 2 {
+            continue
+        }
+        sort.SliceStable(indices, func(a, b int) bool {
+            return hashes[indices[a]].Width*hashes[indices[a]].Height > hashes[indices[b]].Width*hashes[indices[b]].Height
+        })
+        group := make([]string, len(indices))
+        for i, index := range indices {
+            group[i] = paths[index]
+        }
+        groups = append(groups, group)
+    }
+    return groups
+}
@@ -0,0 +1,332 @@
+// Package httpserver serves an imagesearch.Client over HTTP with JSON requests and responses, turning the package into a drop-in microservice.
+//
+// POST /search takes {"query": "example", "limit": 10, "arguments": ["isc:red"]} and responds with {"images": [...]}.
+// Both requests may also name one of the configured Engines to search with, like {"query": "example", "engine": "bing"}.
+// POST /download takes {"query": "example", "limit": 10, "dir": "examples", "arguments": []} and responds with {"paths": [...], "missing": 0}.
+// Downloads requested with "Accept: text/event-stream" stream a progress event for every image read or done instead, followed by a result event with the response.
+//
+// For example:
+//	server := httpserver.New(httpserver.Config{Client: imagesearch.NewClient(), Root: "/srv/images", Token: os.Getenv("TOKEN")})
+//	http.ListenAndServe(":8080", server)
+package httpserver
+
+import (
+    "context"
+    "crypto/subtle"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// Configures the server.
+type Config struct {
+    // The Client searching and downloading, the default configuration if nil
+    Client *imagesearch.Client
+
+    // Downloads go into directories under Root, since the directory of a download is chosen by the caller. Defaults to the working directory.
+    Root string
+
+    // Required as a bearer token in the Authorization header of every request if not empty
+    Token string
+
+    // Most images a single request may ask for, which also applies to requests with a limit of 0. 0 means no maximum.
+    MaxLimit int
+
+    // Most requests handled at once, requests over it fail with 503 Service Unavailable. 0 means no maximum.
+    MaxConcurrent int
+
+    // Engines requests may choose with their engine field, by name. Requests without an engine search with the Client's engine,
+    // and requests naming an engine that isn't here fail with 400 Bad Request.
+    Engines map[string]func(*imagesearch.Client) imagesearch.Engine
+
+    // Requests allowed per second on average across every caller, with bursts of up to Burst requests.
+    // Requests over it fail with 429 Too Many Requests. 0 means no limit.
+    Rate  float64
+    Burst int
+}
+
+// The body of a /search request.
+type SearchRequest struct {
+    Query     string   `json:"query"`
+    Limit     int      `json:"limit"`
+    Arguments []string `json:"arguments"`
+    Engine    string   `json:"engine,omitempty"`
+}
+
+// The body of a /search response.
+type SearchResponse struct {
+    Images []imagesearch.Image `json:"images"`
+}
+
+// The body of a /download request.
+type DownloadRequest struct {
+    Query     string   `json:"query"`
+    Limit     int      `json:"limit"`
+    Dir       string   `json:"dir"`
+    Arguments []string `json:"arguments"`
+    Engine    string   `json:"engine,omitempty"`
+}
+
+// The body of a /download response, and the data of the result event when streaming.
+type DownloadResponse struct {
+    Paths   []string `json:"paths"`
+    Missing int      `json:"missing"`
+}
+
+// The data of a progress event, which is imagesearch.Progress with its error as a string.
+type ProgressEvent struct {
+    Url        string `json:"url"`
+    Read       int64  `json:"read"`
+    Size       int64  `json:"size"`
+    Done       bool   `json:"done,omitempty"`
+    Path       string `json:"path,omitempty"`
+    Error      string `json:"error,omitempty"`
+    Downloaded int    `json:"downloaded"`
+    Failed     int    `json:"failed"`
+}
+
+// The body of every error response, and the data of an error event when streaming.
+type ErrorResponse struct {
+    Error string `json:"error"`
+}
+
+// Largest request body accepted
+const maxBody = 1 << 20
+
+type server struct {
+    config    Config
+    mux       *http.ServeMux
+    semaphore chan struct{}
+    clients   map[string]*imagesearch.Client
+    bucket    *bucket
+}
+
+// Creates the handler of the server with the given configuration.
+func New(config Config) http.Handler {
+    if config.Client == nil {
+        config.Client = imagesearch.NewClient()
+    }
+    s := &server{config: config, mux: http.NewServeMux()}
+    if config.MaxConcurrent > 0 {
+        s.semaphore = make(chan struct{}, config.MaxConcurrent)
+    }
+    s.clients = map[string]*imagesearch.Client{}
+    for name, engine := range config.Engines {
+        s.clients[name] = config.Client.With(imagesearch.WithEngine(engine))
+    }
+    if config.Rate > 0 {
+        s.bucket = newBucket(config.Rate, config.Burst)
+    }
+    s.mux.HandleFunc("/search", s.search)
+    s.mux.HandleFunc("/download", s.download)
+    return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if s.config.Token != "" {
+        token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+        if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+            writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+            return
+        }
+    }
+    if r.Method != http.MethodPost {
+        writeError(w, http.StatusMethodNotAllowed, "use POST")
+        return
+    }
+    if !s.bucket.allow() {
+        writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+        return
+    }
+    if s.semaphore != nil {
+        select {
+        case s.semaphore <- struct{}{}:
+            defer func() { <-s.semaphore }()
+        default:
+            writeError(w, http.StatusServiceUnavailable, "too many requests at once")
+            return
+        }
+    }
+    r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+    s.mux.ServeHTTP(w, r)
+}
+
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+    var req SearchRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    client, err := s.client(req.Engine)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    images, err := client.Images(r.Context(), req.Query, s.limit(req.Limit), req.Arguments...)
+    if err != nil {
+        writeError(w, status(err), err.Error())
+        return
+    }
+    writeJSON(w, http.StatusOK, SearchResponse{Images: images})
+}
+
+func (s *server) download(w http.ResponseWriter, r *http.Request) {
+    var req DownloadRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    dir, err := s.dir(req.Dir)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    client, err := s.client(req.Engine)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    flusher, ok := w.(http.Flusher)
+    if !ok || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+        paths, missing, err := client.Download(r.Context(), req.Query, s.limit(req.Limit), dir, req.Arguments...)
+        if err != nil {
+            writeError(w, status(err), err.Error())
+            return
+        }
+        writeJSON(w, http.StatusOK, DownloadResponse{Paths: paths, Missing: missing})
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.WriteHeader(http.StatusOK)
+
+    var mu sync.Mutex
+    send := func(event string, data interface{}) {
+        encoded, _ := json.Marshal(data)
+        mu.Lock()
+        defer mu.Unlock()
+        fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+        flusher.Flush()
+    }
+    client = client.With(imagesearch.WithProgress(func(progress imagesearch.Progress) {
+        event := ProgressEvent{Url: progress.Url, Read: progress.Read, Size: progress.Size, Done: progress.Done, Path: progress.Path, Downloaded: progress.Downloaded, Failed: progress.Failed}
+        if progress.Err != nil {
+            event.Error = progress.Err.Error()
+        }
+        send("progress", event)
+    }))
+
+    paths, missing, err := client.Download(r.Context(), req.Query, s.limit(req.Limit), dir, req.Arguments...)
+    if err != nil {
+        send("error", ErrorResponse{Error: err.Error()})
+        return
+    }
+    send("result", DownloadResponse{Paths: paths, Missing: missing})
+}
+
+// Returns the Client searching with the engine a request named, or the configured Client if it didn't name one.
+func (s *server) client(engine string) (*imagesearch.Client, error) {
+    if engine == "" {
+        return s.config.Client, nil
+    }
+    client, ok := s.clients[engine]
+    if !ok {
+        return nil, fmt.Errorf("engine %q isn't allowed", engine)
+    }
+    return client, nil
+}
+
+// Caps the limit of a request at the configured maximum.
+func (s *server) limit(limit int) int {
+    if s.config.MaxLimit > 0 && (limit <= 0 || limit > s.config.MaxLimit) {
+        return s.config.MaxLimit
+    }
+    return limit
+}
+
+// Resolves the directory of a download request under the root, refusing directories outside of it.
+func (s *server) dir(dir string) (string, error) {
+    root, err := filepath.Abs(s.config.Root)
+    if err != nil {
+        return "", err
+    }
+    resolved := filepath.Join(root, filepath.Clean("/"+dir))
+    if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+        return "", fmt.Errorf("dir %q is outside of the download root", dir)
+    }
+    return resolved, nil
+}
+
+// Returns the status code of an error of the Client.
+func status(err error) int {
+    switch {
+    case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+        return http.StatusGatewayTimeout
+    case imagesearch.IsBlocked(err):
+        return http.StatusServiceUnavailable
+    case imagesearch.IsUnpackErr(err):
+        return http.StatusBadGateway
+    case imagesearch.IsCacheMiss(err):
+        return http.StatusNotFound
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+    writeJSON(w, code, ErrorResponse{Error: message})
+}
+
+// A token bucket refusing requests over its rate, instead of waiting for them like the limiter of imagesearch, so callers get an answer right away.
+// A nil bucket allows every request.
+type bucket struct {
+    mu     sync.Mutex
+    rate   float64
+    burst  float64
+    tokens float64
+    last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+    if burst < 1 {
+        burst = 1
+    }
+    return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Takes a token if one is left.
+func (b *bucket) allow() bool {
+    if b == nil {
+        return true
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    now := time.Now()
+    b.tokens += now.Sub(b.last).Seconds() * b.rate
+    if b.tokens > b.burst {
+        b.tokens = b.burst
+    }
+    b.last = now
+    if b.tokens < 1 {
+        return false
+    }
+    b.tokens--
+    return true
+}
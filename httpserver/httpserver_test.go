@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// An engine returning a fixed number of images for every query.
+type fixedEngine int
+
+func (e fixedEngine) Search(ctx context.Context, query imagesearch.Query) ([]imagesearch.Image, error) {
+    images := make([]imagesearch.Image, e)
+    for i := range images {
+        images[i] = imagesearch.Image{Url: "https://example.com/" + strconv.Itoa(i) + ".png", Source: "https://example.com/" + strconv.Itoa(i)}
+    }
+    return images, nil
+}
+
+func (e fixedEngine) Name() string {
+    return "fixed"
+}
+
+// Creates a server searching with an engine that finds 10 images for every query.
+func newServer(config Config) http.Handler {
+    config.Client = imagesearch.NewClient(imagesearch.WithEngine(func(*imagesearch.Client) imagesearch.Engine { return fixedEngine(10) }))
+    return New(config)
+}
+
+// Sends a search request for up to limit images with the given Authorization header, if it isn't empty.
+func search(handler http.Handler, limit int, authorization string) *httptest.ResponseRecorder {
+    body := `{"query": "example", "limit": ` + strconv.Itoa(limit) + `}`
+    req := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(body))
+    if authorization != "" {
+        req.Header.Set("Authorization", authorization)
+    }
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, req)
+    return recorder
+}
+
+func TestAuth(t *testing.T) {
+    handler := newServer(Config{Token: "secret"})
+    tests := []struct {
+        authorization string
+        want          int
+    }{
+        {"", http.StatusUnauthorized},
+        {"Bearer wrong", http.StatusUnauthorized},
+        {"Bearer secret", http.StatusOK},
+    }
+    for _, test := range tests {
+        if got := search(handler, 1, test.authorization).Code; got != test.want {
+            t.Errorf("Authorization %q: status %d, expected %d", test.authorization, got, test.want)
+        }
+    }
+
+    // Unauthorized requests are refused before anything else is checked
+    req := httptest.NewRequest(http.MethodGet, "/search", nil)
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, req)
+    if recorder.Code != http.StatusUnauthorized {
+        t.Errorf("unauthorized GET: status %d, expected %d", recorder.Code, http.StatusUnauthorized)
+    }
+}
+
+// Download directories always resolve under the root, however they try to leave it.
+func TestDir(t *testing.T) {
+    root := t.TempDir()
+    s := newServer(Config{Root: root}).(*server)
+    tests := map[string]string{
+        "":                  root,
+        "cats":              filepath.Join(root, "cats"),
+        "cats/../dogs":      filepath.Join(root, "dogs"),
+        "..":                root,
+        "../outside":        filepath.Join(root, "outside"),
+        "../../etc/passwd":  filepath.Join(root, "etc", "passwd"),
+        "/etc":              filepath.Join(root, "etc"),
+        "cats/../../../etc": filepath.Join(root, "etc"),
+    }
+    for dir, want := range tests {
+        got, err := s.dir(dir)
+        if err != nil {
+            t.Errorf("%q: %v", dir, err)
+        } else if got != want {
+            t.Errorf("%q resolved to %s, expected %s", dir, got, want)
+        }
+    }
+}
+
+func TestMaxLimit(t *testing.T) {
+    handler := newServer(Config{MaxLimit: 3})
+    tests := []struct {
+        limit, want int
+    }{
+        {0, 3},
+        {2, 2},
+        {3, 3},
+        {10, 3},
+    }
+    for _, test := range tests {
+        recorder := search(handler, test.limit, "")
+        if recorder.Code != http.StatusOK {
+            t.Fatalf("limit %d: status %d: %s", test.limit, recorder.Code, recorder.Body)
+        }
+        var response SearchResponse
+        if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+            t.Fatal(err)
+        }
+        if len(response.Images) != test.want {
+            t.Errorf("limit %d: %d images, expected %d", test.limit, len(response.Images), test.want)
+        }
+    }
+}
+
+func TestRate(t *testing.T) {
+    // Slow enough that no token is added back during the test
+    handler := newServer(Config{Rate: 0.001, Burst: 2})
+    for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+        if got := search(handler, 1, "").Code; got != want {
+            t.Errorf("request %d: status %d, expected %d", i, got, want)
+        }
+    }
+}
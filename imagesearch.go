@@ -1,16 +1,11 @@
-// A package designed to search Google Images based on the input query and arguments. Due to the limitations of using only a single request to fetch images, only a max of about 100 images can be found per request. If you need to find more than 100, one of the many packages using simulated browsers may work better. These images may be protected under copyright, and you shouldn't do anything punishable with them, like using them for commercial use.
+// A package designed to search Google Images based on the input query and arguments. Due to the limitations of using only a single request to fetch images, only a max of about 100 images can be found per request. If you need to find more than 100, search with the Headless engine, which scrolls through the results in a real browser such as the one in the github.com/commonkestrel/imagesearch/chromedp module. These images may be protected under copyright, and you shouldn't do anything punishable with them, like using them for commercial use.
 package imagesearch
 
 import (
+    "context"
     "encoding/json"
     "errors"
-    "html"
-    "io"
-    "net/http"
-    "os"
-    "path"
-    "path/filepath"
-    "strconv"
+    "net/url"
     "strings"
 )
 
@@ -34,6 +29,64 @@ type Image struct {
 
     // Base of the source URL
     Base   string `json:"base"`
+
+    // Url of the favicon of the website the image was found on, served by Google's favicon service so it always resolves to an icon
+    Favicon string `json:"favicon,omitempty"`
+
+    // Title of the page the image was found on
+    Title string `json:"title,omitempty"`
+
+    // Description or snippet Google shows for the image, if any
+    Description string `json:"description,omitempty"`
+
+    // Name of the engine the image was found with, like "google". Empty if the image didn't come from a search.
+    Engine string `json:"engine,omitempty"`
+
+    // Position of the image in Google's ordering of the results, starting at 1 for the most relevant result. 0 if the image didn't come from a search.
+    // Ranks are assigned before any results are filtered, so they may have gaps.
+    Rank int `json:"rank,omitempty"`
+
+    // Original dimensions of the image in pixels, as reported by Google. 0 if unknown.
+    Width  int `json:"width,omitempty"`
+    Height int `json:"height,omitempty"`
+
+    // Usage rights of the image, if the results annotate it with any. nil if there is no license information.
+    License *LicenseInfo `json:"license,omitempty"`
+
+    // Whether the image was read from the page in a way that may be inaccurate, like scraping it as a last resort after every structured way of reading the results failed.
+    // Best effort images may not be actual results, may only link a thumbnail, and their Source and Base may be wrong or empty.
+    BestEffort bool `json:"best_effort,omitempty"`
+
+    // The JSON the image was extracted from, for reading fields this package doesn't extract.
+    // For the current page layout this is the array inside the result's 444383007 envelope, and for the legacy layout the rg_meta object.
+    // For engines other than Google it is the metadata the engine returned for the result, like the m attribute of Bing's result links.
+    // Empty for images that were not extracted from JSON, like those from the basic results page.
+    Raw json.RawMessage `json:"raw,omitempty"`
+
+    // The small thumbnail Google inlines into the page for the first few results, usually a JPEG.
+    // Empty for the rest of the results, and whenever the thumbnail couldn't be matched to its result.
+    ThumbnailData []byte `json:"thumbnail_data,omitempty"`
+
+    // Google's document id of the result, which stays the same for the same image across searches, or the id other engines give the image.
+    // Use it to correlate results between searches or sessions, or to look up visually similar images. Empty if the results didn't include one.
+    Id string `json:"id,omitempty"`
+}
+
+// The results of a search, along with information about how they were extracted.
+type Results struct {
+    Images []Image
+
+    // Results that were skipped because they had an unexpected shape. Empty unless Google changed the structure of some results.
+    Warnings []Warning
+
+    // Identifiable markers of the results page's structure, empty for engines other than Google
+    Schema Schema
+}
+
+func (r Results) copy() Results {
+    r.Images = append([]Image{}, r.Images...)
+    r.Warnings = append([]Warning{}, r.Warnings...)
+    return r
 }
 
 // These variables are all of the possible arguments that can be passed into Images, Download, and Urls. These are used by passing imagesearch.{Argument}.{Option} into the arguments parameter. For example:
@@ -66,54 +119,60 @@ var (
     Format = struct {
         Jpg, Gif, Png, Bmp, Svg, Webp, Ico, Raw string
     }{Jpg: "ift:jpg", Gif: "ift:gif", Png: "ift:png", Bmp: "ift:bmp", Svg: "ift:svg", Webp: "webp", Ico: "ift:ico", Raw: "ift:craw"}
+
+    Size = struct {
+        Large, Medium, Icon string
+    }{Large: "isz:l", Medium: "isz:m", Icon: "isz:i"}
+
+    // SafeSearch is passed as a parameter of the search url rather than a filter, so it is only supported by Google and engines that take Google's parameters, like SerpAPI.
+    SafeSearch = struct {
+        On, Off string
+    }{On: "safe=active", Off: "safe=off"}
 )
 
-// Searches for the query along with the given arguments, and returns a slice of Image objects.
-// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
-func Images(query string, limit int, arguments ...string) (images []Image, err error) {
-    url := buildUrl(query, arguments)
+// Returns the argument restricting the results to pages in the language, given as a two letter code like "en". Only supported by Google and engines that take Google's parameters.
+func Language(code string) string {
+    return "lr=lang_" + strings.ToLower(code)
+}
 
-    page, err := getPage(url)
-    if err != nil {
-        return []Image{}, err
-    }
+// Returns the argument restricting the results to pages from the country, given as a two letter code like "us". Only supported by Google and engines that take Google's parameters.
+func Region(code string) string {
+    return "cr=country" + strings.ToUpper(code)
+}
 
-    images, err = unpack(page)
-    if err != nil {
-        return []Image{}, err
-    }
+// Returns the argument restricting the results to pages of the domain, like "example.com", and its subdomains. Only supported by Google and engines that take Google's parameters.
+func Site(domain string) string {
+    return "as_sitesearch=" + url.QueryEscape(domain)
+}
 
-    if len(images) > limit && limit > 0 {
-        images = images[:limit]
+// Splits arguments into the filters passed in tbs and the parameters of the search url, which are the arguments containing "=".
+func splitArguments(arguments []string) (filters []string, parameters url.Values) {
+    parameters = url.Values{}
+    for _, argument := range arguments {
+        key, value, ok := strings.Cut(argument, "=")
+        if !ok {
+            filters = append(filters, argument)
+            continue
+        }
+        if unescaped, err := url.QueryUnescape(value); err == nil {
+            value = unescaped
+        }
+        parameters.Set(key, value)
     }
+    return filters, parameters
+}
 
-    return images, nil
+// Searches for the query along with the given arguments, and returns a slice of Image objects.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+// A query without any results returns an empty slice rather than an error.
+func Images(query string, limit int, arguments ...string) (images []Image, err error) {
+    return defaultClient.Images(context.Background(), query, limit, arguments...)
 }
 
 // Searches for the query along with the given arguments, and returns a slice of the image urls.
 // The amount of images does not exceed the limit unless the limit is 0, in which case it will return all urls found.
 func Urls(query string, limit int, arguments ...string) (urls []string, err error) {
-    url := buildUrl(query, arguments)
-
-    page, err := getPage(url)
-    if err != nil {
-        return []string{}, err
-    }
-
-    images, err := unpack(page)
-    if err != nil {
-        return []string{}, err
-    }
-
-    if len(images) > limit && limit > 0 {
-        images = images[:limit]
-    }
-
-    for _, image := range images {
-        urls = append(urls, image.Url)
-    }
-
-    return urls, nil
+    return defaultClient.Urls(context.Background(), query, limit, arguments...)
 }
 
 // Searches for the given query along with the given argumetnts and downloads the images into the given directory.
@@ -123,51 +182,24 @@ func Urls(query string, limit int, arguments ...string) (urls []string, err erro
 // The number of missing images is the difference between the limit and the actual number of images downloaded. 
 // This is only non-zero when the limit is higher than the number of downloadable images found.
 func Download(query string, limit int, dir string, arguments ...string) (paths []string, missing int, err error) {
-    dir, err = filepath.Abs(strings.ReplaceAll(dir, "\\", "/"))
-    if err != nil {
-        return []string{}, 0, err
-    }
-
-    urls, err := Urls(query, 0, arguments...)
-    if err != nil {
-        return []string{}, 0, err
-    }
-
-    var suffix int
-    
-    var i int
-    for len(paths) < limit  {
-        if i >= len(urls) {
-            missing = limit-len(paths)
-            break
-        }
-
-        url := urls[i]
-        pat := path.Join(dir, query+strconv.Itoa(suffix)) + ".*"
-        matches, _ := filepath.Glob(pat)
-        for len(matches) > 0 {
-            suffix++
-            pat = path.Join(dir, query+strconv.Itoa(suffix)) + ".*"
-            matches, _ = filepath.Glob(pat)
-        }
-
-        file, err := DownloadImage(url, dir, query+strconv.Itoa(suffix))
-        for err != nil {
-            i++
-            if i >= len(urls) {
-                missing = limit-len(paths)
-                break
-            }
+    return defaultClient.Download(context.Background(), query, limit, dir, arguments...)
+}
 
-            url = urls[i]
-            file, err = DownloadImage(url, dir, query+strconv.Itoa(suffix))
-        }
+// Downloads the images at urls into the given directory until limit of them are downloaded, naming the files like Download with name followed by a number.
+// Use it to download results that were already found without searching again. Returns the same values as Download.
+func DownloadUrls(urls []string, limit int, dir, name string) (paths []string, missing int, err error) {
+    return defaultClient.DownloadUrls(context.Background(), urls, limit, dir, name)
+}
 
-        paths = append(paths, file)
-        i++
-    }
+// Downloads search results that were already found for the query and arguments into the given directory until limit of them are downloaded.
+// Unlike DownloadUrls the filters, captions, and manifest get the search results. Returns the same values as Download.
+func DownloadImages(images []Image, limit int, dir, query string, arguments ...string) (paths []string, missing int, err error) {
+    return defaultClient.DownloadImages(context.Background(), images, limit, dir, query, arguments...)
+}
 
-    return paths, missing, nil
+// Fetches the image at url into memory without saving it, and returns its bytes along with its format, like "jpeg". Fails if the content isn't an image.
+func Fetch(url string) (data []byte, format string, err error) {
+    return defaultClient.Fetch(context.Background(), url)
 }
 
 // Given the url of the image, the directory to download to, and the name of the file *without extension*, this will find the type of image and download it to the given directory.
@@ -185,132 +217,13 @@ func Download(query string, limit int, dir string, arguments ...string) (paths [
 //	    return len(matches) > 0
 //	}
 func DownloadImage(url, dir, name string) (imgpath string, err error) {
-    dir, err = filepath.Abs(dir)
-    if err != nil {
-        return "", err
-    }
-    _, err = os.Stat(dir)
-    if os.IsNotExist(err) {
-        err = os.MkdirAll(dir, os.ModePerm)
-        if err != nil {
-            return "", err
-        }
-    }
-
-    client := http.DefaultClient
-    req, _ := http.NewRequest("GET", url, nil)
-    req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.104 Safari/537.36")
-    resp, err := client.Do(req)
-    if err != nil {
-        return "", err
-    }
-
-    bytes, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return "", err
-    }
-
-    mimetype := http.DetectContentType(bytes)
-    var extension string
-    if strings.Contains(mimetype, "image") {
-        extension = strings.ReplaceAll(mimetype, "image/", "")
-    } else {
-        return "", errors.New("invalid image format")
-    }
-
-    file := name + "." + extension
-    abs := path.Join(dir, file)
-
-    f, err := os.Create(abs)
-    if err != nil {
-        return "", err
-    }
-    _, err = f.Write(bytes)
-    if err != nil {
-        return "", err
-    }
-
-    return f.Name(), nil
+    return defaultClient.DownloadImage(context.Background(), url, dir, name)
 }
 
 // Checks if an error is an unpacking error. An unpacking error is generally thrown when Google changes their JSON structure, or on certain internet connections, when the specific header does not work.
 // If you believe Google changed their JSON structure, please submit a bug report at https://github.com/commonkestrel/imagesearch/issues, and I will try to fix this asap.
+// Unpacking errors are returned as an *UnpackError, which includes hints about what went wrong.
 func IsUnpackErr(err error) bool {
-    return err == errUnpack
-}
-
-func buildUrl(query string, arguments []string) string {
-    url := "https://www.google.com/search?tbm=isch&q=" + query
-
-    if len(arguments) > 0 {
-        url += "&tbs=ic:specific"
-    }
-    for _, argument := range arguments {
-        url += "%2C" + argument
-    }
-
-    return url
-}
-
-func unpack(page string) ([]Image, error) {
-
-    scriptStart := strings.LastIndex(page, "AF_initDataCallback")
-    if scriptStart == -1 {
-        return []Image{}, errUnpack
-    }
-    page = page[scriptStart:]
-
-    startChar := strings.Index(page, "[")
-    if startChar == -1 {
-        return []Image{}, errUnpack
-    }
-    page = page[startChar:]
-
-    endChar := strings.Index(page, "</script>") - 20
-    if endChar == -1 {
-        return []Image{}, errUnpack
-    }
-    page = page[:endChar]
-
-    var imageJson []interface{}
-
-    err := json.Unmarshal([]byte(html.UnescapeString(page)), &imageJson)
-    if err != nil {
-        return []Image{}, err
-    }
-
-    imageObjects := imageJson[56].([]interface{})[1].([]interface{})[0].([]interface{})[0].([]interface{})[1].([]interface{})[0].([]interface{})
-
-    var images []Image
-    for _, imageObject := range imageObjects {
-        obj := imageObject.([]interface{})[0].([]interface{})[0].(map[string]interface{})["444383007"].([]interface{})[1]
-        if obj != nil {
-            var image Image
-            image.Url = obj.([]interface{})[3].([]interface{})[0].(string)
-
-            sourceInfo := obj.([]interface{})[9].(map[string]interface{})["2003"].([]interface{})
-            image.Source = sourceInfo[2].(string)
-            image.Base = sourceInfo[17].(string)
-            images = append(images, image)
-        }
-    }
-    return images, nil
-}
-
-func getPage(url string) (string, error) {
-    client := http.DefaultClient
-    req, _ := http.NewRequest("GET", url, nil)
-    // No idea why this works, but Google renders the page differently with this header. Credit to joeclinton1 on Github for this
-    req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.104 Safari/537.36")
-    resp, err := client.Do(req)
-    if err != nil {
-        return "", err
-    }
-    defer resp.Body.Close()
-
-    html, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return "", err
-    }
-    return string(html), nil
+    var unpackErr *UnpackError
+    return errors.As(err, &unpackErr) || errors.Is(err, errUnpack)
 }
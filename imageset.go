@@ -0,0 +1,203 @@
+package imagesearch
+
+import (
+    "math/rand"
+    "net/url"
+    "sort"
+    "strings"
+)
+
+// A set of images with chainable methods for the processing most results go through, for example:
+//	images, err := imagesearch.Images("example", 0)
+//	top := imagesearch.ImageSet(images).Dedupe().Filter(func(img imagesearch.Image) bool { return img.Width >= 1024 }).Limit(10)
+// Methods never change the set they are called on, and return a new set instead.
+type ImageSet []Image
+
+// Returns the images the function keeps, in order.
+func (s ImageSet) Filter(keep func(img Image) bool) ImageSet {
+    filtered := make(ImageSet, 0, len(s))
+    for _, image := range s {
+        if keep(image) {
+            filtered = append(filtered, image)
+        }
+    }
+    return filtered
+}
+
+// Returns the images sorted by less, which reports whether a sorts before b. Images that sort the same keep their order.
+func (s ImageSet) SortBy(less func(a, b Image) bool) ImageSet {
+    sorted := append(ImageSet{}, s...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return less(sorted[i], sorted[j])
+    })
+    return sorted
+}
+
+// Returns the images without those whose url is a duplicate of an earlier image's url, like Dedupe.
+func (s ImageSet) Dedupe() ImageSet {
+    return Dedupe(s)
+}
+
+// Returns the first n images, or all of them if there are n or fewer.
+func (s ImageSet) Limit(n int) ImageSet {
+    if n < 0 {
+        n = 0
+    }
+    if len(s) > n {
+        return append(ImageSet{}, s[:n]...)
+    }
+    return append(ImageSet{}, s...)
+}
+
+// Returns n images picked at random, in the order they have in the set, or all of them if there are n or fewer.
+// The same seed picks the same images of the same set every time, so selections are reproducible across runs, like for experiments.
+// Pass a different seed, like the time, for a different selection.
+func (s ImageSet) Sample(n int, seed int64) ImageSet {
+    if n >= len(s) {
+        return append(ImageSet{}, s...)
+    }
+    if n < 0 {
+        n = 0
+    }
+    picked := rand.New(rand.NewSource(seed)).Perm(len(s))[:n]
+    sort.Ints(picked)
+    sample := make(ImageSet, n)
+    for i, index := range picked {
+        sample[i] = s[index]
+    }
+    return sample
+}
+
+// Returns the images in a random order. The same seed puts the same set in the same order every time, so a bot can walk through the order
+// across restarts, keeping only the seed and its position, without ever repeating an image.
+func (s ImageSet) Shuffle(seed int64) ImageSet {
+    shuffled := make(ImageSet, len(s))
+    for i, index := range rand.New(rand.NewSource(seed)).Perm(len(s)) {
+        shuffled[i] = s[index]
+    }
+    return shuffled
+}
+
+// Returns the images interleaved by the domain of the page they were found on, so consecutive images come from different domains wherever possible, like WithDomainDiversity orders results.
+func (s ImageSet) Diversify() ImageSet {
+    return diversify(s)
+}
+
+// Returns the images without those beyond the n most relevant of every domain, like WithMaxPerDomain caps results.
+func (s ImageSet) MaxPerDomain(n int) ImageSet {
+    return capPerDomain(s, n)
+}
+
+// Groups the images by the domain of the page they were found on, without a leading www, like "example.com", keeping their order within every group.
+// Images without a source page are grouped by the domain of their url.
+func (s ImageSet) GroupByDomain() map[string]ImageSet {
+    groups := map[string]ImageSet{}
+    for _, image := range s {
+        domain := imageDomain(image)
+        groups[domain] = append(groups[domain], image)
+    }
+    return groups
+}
+
+// Returns the domain of the page the image was found on without a leading www, or of its url if it has no source page. Empty for inline images without either.
+func imageDomain(image Image) string {
+    if image.Base != "" {
+        return strings.ToLower(strings.TrimPrefix(image.Base, "www."))
+    }
+    for _, link := range []string{image.Source, image.Url} {
+        if parsed, err := url.Parse(link); err == nil && parsed.Hostname() != "" {
+            return strings.ToLower(strings.TrimPrefix(parsed.Hostname(), "www."))
+        }
+    }
+    return ""
+}
+
+// Interleaves the images by domain in rounds: the most relevant image of every domain first, then the second most relevant of every domain, and so on,
+// keeping the order of the images within every round.
+func diversify(images []Image) []Image {
+    seen := map[string]int{}
+    rounds := make([]int, len(images))
+    for i, image := range images {
+        domain := imageDomain(image)
+        rounds[i] = seen[domain]
+        seen[domain]++
+    }
+    order := make([]int, len(images))
+    for i := range order {
+        order[i] = i
+    }
+    sort.SliceStable(order, func(i, j int) bool {
+        return rounds[order[i]] < rounds[order[j]]
+    })
+    diversified := make([]Image, len(images))
+    for i, index := range order {
+        diversified[i] = images[index]
+    }
+    return diversified
+}
+
+// Removes the images after the first n of every domain, keeping the order of the rest.
+func capPerDomain(images []Image, n int) []Image {
+    counts := map[string]int{}
+    capped := make([]Image, 0, len(images))
+    for _, image := range images {
+        domain := imageDomain(image)
+        if counts[domain] < n {
+            capped = append(capped, image)
+        }
+        counts[domain]++
+    }
+    return capped
+}
+
+// Returns every image of a followed by the images of b that aren't in a, for the union of the results of several queries.
+// Images are the same if they have the same Id, or their urls are duplicates once normalized, like Dedupe finds them. Of images in both sets, the one in a is kept.
+func Merge(a, b ImageSet) ImageSet {
+    in := newImageIndex(a)
+    merged := append(ImageSet{}, a...)
+    for _, image := range b {
+        if !in.has(image) {
+            merged = append(merged, image)
+        }
+    }
+    return merged
+}
+
+// Returns the images of a that are in b as well, in the order of a, for the intersection of the results of several queries. Images are the same like in Merge.
+func Intersect(a, b ImageSet) ImageSet {
+    in := newImageIndex(b)
+    return a.Filter(in.has)
+}
+
+// Returns the images of latest that aren't in old, and the images of old that aren't in latest anymore, for "what's new since last week" workflows. Images are the same like in Merge.
+func Diff(old, latest ImageSet) (added, removed ImageSet) {
+    inOld, inLatest := newImageIndex(old), newImageIndex(latest)
+    added = latest.Filter(func(img Image) bool {
+        return !inOld.has(img)
+    })
+    removed = old.Filter(func(img Image) bool {
+        return !inLatest.has(img)
+    })
+    return added, removed
+}
+
+// The ids and normalized urls of a set of images, to look up whether an image is in the set.
+type imageIndex struct {
+    ids  map[string]bool
+    urls map[string]bool
+}
+
+func newImageIndex(images []Image) imageIndex {
+    index := imageIndex{ids: map[string]bool{}, urls: map[string]bool{}}
+    for _, image := range images {
+        if image.Id != "" {
+            index.ids[image.Id] = true
+        }
+        index.urls[dedupeKey(image.Url)] = true
+    }
+    return index
+}
+
+func (i imageIndex) has(image Image) bool {
+    return (image.Id != "" && i.ids[image.Id]) || i.urls[dedupeKey(image.Url)]
+}
@@ -0,0 +1,301 @@
+// Package jobs runs download jobs in the background from a queue persisted to a file, so that services accepting user submitted queries keep their jobs across restarts.
+// Jobs are executed by a pool of workers, and can be looked up and canceled by their ID. For example:
+//
+//	queue, err := jobs.Open(imagesearch.NewClient(imagesearch.WithManifest()), "jobs.json", 4)
+//	if err != nil {
+//	    return err
+//	}
+//	defer queue.Close()
+//	job, err := queue.Enqueue("example", 50, "./images")
+package jobs
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// The state of a Job.
+type State string
+
+const (
+    // Waiting for a worker
+    Queued State = "queued"
+    // Being downloaded by a worker
+    Running State = "running"
+    // Downloaded, see the Paths and Missing of the job
+    Done State = "done"
+    // Failed with the Error of the job
+    Failed State = "failed"
+    // Canceled before it was done
+    Canceled State = "canceled"
+)
+
+var (
+    // Returned by Cancel for an id that isn't in the queue
+    ErrNotFound = errors.New("no job with that id")
+    // Returned by Cancel for a job that is already done, failed, or canceled
+    ErrFinished = errors.New("the job already finished")
+    // Returned by Enqueue once the queue is closed
+    ErrClosed = errors.New("the queue is closed")
+)
+
+// A download job, as passed to Client.Download.
+type Job struct {
+    ID        string   `json:"id"`
+    Query     string   `json:"query"`
+    Limit     int      `json:"limit"`
+    Dir       string   `json:"dir"`
+    Arguments []string `json:"arguments,omitempty"`
+
+    State State `json:"state"`
+
+    // The results of the download once the job is done
+    Paths   []string `json:"paths,omitempty"`
+    Missing int      `json:"missing,omitempty"`
+
+    // Why the job failed
+    Error string `json:"error,omitempty"`
+
+    Created  time.Time  `json:"created"`
+    Started  *time.Time `json:"started,omitempty"`
+    Finished *time.Time `json:"finished,omitempty"`
+}
+
+// A Queue of download jobs persisted to a file. Safe for concurrent use.
+type Queue struct {
+    client *imagesearch.Client
+    path   string
+
+    mu      sync.Mutex
+    cond    *sync.Cond
+    jobs    map[string]*Job
+    order   []string
+    cancels map[string]context.CancelFunc
+    closed  bool
+    workers sync.WaitGroup
+}
+
+// Opens the queue persisted at path, creating the file if it doesn't exist, and starts workers to download its jobs with client.
+// Jobs that were running when the queue was closed are queued again. A workers count below 1 means 1.
+func Open(client *imagesearch.Client, path string, workers int) (*Queue, error) {
+    q := &Queue{client: client, path: path, jobs: map[string]*Job{}, cancels: map[string]context.CancelFunc{}}
+    q.cond = sync.NewCond(&q.mu)
+
+    data, err := os.ReadFile(path)
+    if err != nil && !os.IsNotExist(err) {
+        return nil, err
+    }
+    if err == nil {
+        var jobs []*Job
+        if err := json.Unmarshal(data, &jobs); err != nil {
+            return nil, fmt.Errorf("invalid job file %s: %w", path, err)
+        }
+        for _, job := range jobs {
+            if job.State == Running {
+                job.State = Queued
+                job.Started = nil
+            }
+            q.jobs[job.ID] = job
+            q.order = append(q.order, job.ID)
+        }
+    }
+
+    if workers < 1 {
+        workers = 1
+    }
+    for i := 0; i < workers; i++ {
+        q.workers.Add(1)
+        go q.work()
+    }
+    return q, nil
+}
+
+// Adds a job downloading the query into dir to the end of the queue, and returns it.
+func (q *Queue) Enqueue(query string, limit int, dir string, arguments ...string) (Job, error) {
+    id, err := newID()
+    if err != nil {
+        return Job{}, err
+    }
+
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if q.closed {
+        return Job{}, ErrClosed
+    }
+    job := &Job{ID: id, Query: query, Limit: limit, Dir: dir, Arguments: arguments, State: Queued, Created: time.Now()}
+    q.jobs[id] = job
+    q.order = append(q.order, id)
+    if err := q.save(); err != nil {
+        delete(q.jobs, id)
+        q.order = q.order[:len(q.order)-1]
+        return Job{}, err
+    }
+    q.cond.Signal()
+    return *job, nil
+}
+
+// Returns the job with the given id.
+func (q *Queue) Get(id string) (Job, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    job, ok := q.jobs[id]
+    if !ok {
+        return Job{}, false
+    }
+    return *job, true
+}
+
+// Returns every job in the order they were enqueued.
+func (q *Queue) List() []Job {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    jobs := make([]Job, len(q.order))
+    for i, id := range q.order {
+        jobs[i] = *q.jobs[id]
+    }
+    return jobs
+}
+
+// Cancels the job with the given id, stopping its download if it is running. Images that were already downloaded are kept.
+func (q *Queue) Cancel(id string) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    job, ok := q.jobs[id]
+    if !ok {
+        return ErrNotFound
+    }
+    if job.State != Queued && job.State != Running {
+        return ErrFinished
+    }
+
+    job.State = Canceled
+    now := time.Now()
+    job.Finished = &now
+    if cancel := q.cancels[id]; cancel != nil {
+        cancel()
+    }
+    return q.save()
+}
+
+// Stops the workers and waits for them to return. Running jobs are interrupted and queued again the next time the queue is opened.
+func (q *Queue) Close() error {
+    q.mu.Lock()
+    q.closed = true
+    for _, cancel := range q.cancels {
+        cancel()
+    }
+    q.cond.Broadcast()
+    q.mu.Unlock()
+
+    q.workers.Wait()
+
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return q.save()
+}
+
+// Runs jobs until the queue is closed.
+func (q *Queue) work() {
+    defer q.workers.Done()
+    for {
+        q.mu.Lock()
+        job := q.next()
+        for job == nil && !q.closed {
+            q.cond.Wait()
+            job = q.next()
+        }
+        if q.closed {
+            q.mu.Unlock()
+            return
+        }
+        ctx, cancel := context.WithCancel(context.Background())
+        q.cancels[job.ID] = cancel
+        job.State = Running
+        now := time.Now()
+        job.Started = &now
+        q.save()
+        request := *job
+        q.mu.Unlock()
+
+        paths, missing, err := q.client.Download(ctx, request.Query, request.Limit, request.Dir, request.Arguments...)
+        cancel()
+
+        q.mu.Lock()
+        delete(q.cancels, job.ID)
+        switch {
+        case job.State == Canceled:
+        case q.closed && ctx.Err() != nil:
+            // Interrupted by Close, so it runs again once the queue is reopened
+            job.State = Queued
+            job.Started = nil
+        case err != nil:
+            job.State = Failed
+            job.Error = err.Error()
+        default:
+            job.State = Done
+            job.Paths = paths
+            job.Missing = missing
+        }
+        if job.State == Done || job.State == Failed {
+            now := time.Now()
+            job.Finished = &now
+        }
+        q.save()
+        q.mu.Unlock()
+    }
+}
+
+// Returns the first queued job, or nil if there is none. Must be called with the lock held.
+func (q *Queue) next() *Job {
+    for _, id := range q.order {
+        if job := q.jobs[id]; job.State == Queued {
+            return job
+        }
+    }
+    return nil
+}
+
+// Writes every job to the file, replacing it atomically so a crash never leaves a partial file behind. Must be called with the lock held.
+func (q *Queue) save() error {
+    jobs := make([]*Job, len(q.order))
+    for i, id := range q.order {
+        jobs[i] = q.jobs[id]
+    }
+    data, err := json.MarshalIndent(jobs, "", "    ")
+    if err != nil {
+        return err
+    }
+
+    tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+    if err != nil {
+        return err
+    }
+    if _, err = tmp.Write(data); err != nil {
+        tmp.Close()
+        os.Remove(tmp.Name())
+        return err
+    }
+    if err = tmp.Close(); err != nil {
+        os.Remove(tmp.Name())
+        return err
+    }
+    return os.Rename(tmp.Name(), q.path)
+}
+
+func newID() (string, error) {
+    id := make([]byte, 8)
+    if _, err := rand.Read(id); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+// An engine whose searches block until they are released or canceled, reporting every search that starts and every search that is canceled.
+type blockingEngine struct {
+    started  chan string
+    canceled chan string
+    release  chan struct{}
+}
+
+func newBlockingEngine() *blockingEngine {
+    return &blockingEngine{started: make(chan string, 16), canceled: make(chan string, 16), release: make(chan struct{})}
+}
+
+func (e *blockingEngine) Search(ctx context.Context, query imagesearch.Query) ([]imagesearch.Image, error) {
+    e.started <- query.Text
+    select {
+    case <-e.release:
+        return []imagesearch.Image{}, nil
+    case <-ctx.Done():
+        e.canceled <- query.Text
+        return nil, ctx.Err()
+    }
+}
+
+func (e *blockingEngine) Name() string {
+    return "blocking"
+}
+
+// Opens a queue in a temporary directory with a single worker searching with engine.
+func openQueue(t *testing.T, path string, engine imagesearch.Engine) *Queue {
+    t.Helper()
+    client := imagesearch.NewClient(imagesearch.WithEngine(func(*imagesearch.Client) imagesearch.Engine { return engine }))
+    queue, err := Open(client, path, 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return queue
+}
+
+// Waits for a value on ch, failing the test if none arrives in time.
+func receive(t *testing.T, ch chan string) string {
+    t.Helper()
+    select {
+    case value := <-ch:
+        return value
+    case <-time.After(5 * time.Second):
+        t.Fatal("timed out")
+        return ""
+    }
+}
+
+// Waits for the job to reach the state, failing the test if it doesn't in time.
+func waitFor(t *testing.T, queue *Queue, id string, state State) Job {
+    t.Helper()
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        job, ok := queue.Get(id)
+        if !ok {
+            t.Fatalf("no job %s", id)
+        }
+        if job.State == state {
+            return job
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("job %s is %s, expected %s", id, job.State, state)
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+// A queued job that is canceled is never run.
+func TestCancelQueued(t *testing.T) {
+    engine := newBlockingEngine()
+    queue := openQueue(t, filepath.Join(t.TempDir(), "jobs.json"), engine)
+    defer queue.Close()
+
+    first, err := queue.Enqueue("first", 0, t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    receive(t, engine.started)
+    second, err := queue.Enqueue("second", 0, t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if err := queue.Cancel(second.ID); err != nil {
+        t.Fatal(err)
+    }
+    if job, _ := queue.Get(second.ID); job.State != Canceled || job.Finished == nil {
+        t.Errorf("canceled job is %s, finished %v", job.State, job.Finished)
+    }
+    if err := queue.Cancel(second.ID); !errors.Is(err, ErrFinished) {
+        t.Errorf("canceling a canceled job returned %v, expected ErrFinished", err)
+    }
+    if err := queue.Cancel("missing"); !errors.Is(err, ErrNotFound) {
+        t.Errorf("canceling a missing job returned %v, expected ErrNotFound", err)
+    }
+
+    close(engine.release)
+    waitFor(t, queue, first.ID, Done)
+    select {
+    case query := <-engine.started:
+        t.Errorf("searched for %q after it was canceled", query)
+    case <-time.After(100 * time.Millisecond):
+    }
+    if job, _ := queue.Get(second.ID); job.State != Canceled || job.Started != nil {
+        t.Errorf("canceled job is %s, started %v", job.State, job.Started)
+    }
+}
+
+// A running job that is canceled has its download stopped, and stays canceled once the worker returns.
+func TestCancelRunning(t *testing.T) {
+    engine := newBlockingEngine()
+    queue := openQueue(t, filepath.Join(t.TempDir(), "jobs.json"), engine)
+    defer queue.Close()
+
+    job, err := queue.Enqueue("running", 0, t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    receive(t, engine.started)
+    waitFor(t, queue, job.ID, Running)
+
+    if err := queue.Cancel(job.ID); err != nil {
+        t.Fatal(err)
+    }
+    if query := receive(t, engine.canceled); query != "running" {
+        t.Errorf("canceled the search for %q", query)
+    }
+
+    // The worker is free for the next job once it handled the canceled one
+    next, err := queue.Enqueue("next", 0, t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    receive(t, engine.started)
+    waitFor(t, queue, next.ID, Running)
+    if job, _ := queue.Get(job.ID); job.State != Canceled || job.Error != "" {
+        t.Errorf("canceled job is %s with error %q", job.State, job.Error)
+    }
+}
+
+// Jobs interrupted by Close are saved as queued, and run again once the queue is reopened.
+func TestReopen(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "jobs.json")
+    engine := newBlockingEngine()
+    queue := openQueue(t, path, engine)
+
+    job, err := queue.Enqueue("interrupted", 0, t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    receive(t, engine.started)
+    waitFor(t, queue, job.ID, Running)
+    if err := queue.Close(); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := queue.Enqueue("closed", 0, t.TempDir()); !errors.Is(err, ErrClosed) {
+        t.Errorf("enqueueing into a closed queue returned %v, expected ErrClosed", err)
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var saved []Job
+    if err := json.Unmarshal(data, &saved); err != nil {
+        t.Fatal(err)
+    }
+    if len(saved) != 1 || saved[0].ID != job.ID || saved[0].State != Queued || saved[0].Started != nil {
+        t.Fatalf("saved %+v, expected job %s queued", saved, job.ID)
+    }
+
+    engine = newBlockingEngine()
+    close(engine.release)
+    queue = openQueue(t, path, engine)
+    defer queue.Close()
+    if query := receive(t, engine.started); query != "interrupted" {
+        t.Errorf("searched for %q after reopening", query)
+    }
+    done := waitFor(t, queue, job.ID, Done)
+    if done.Finished == nil {
+        t.Error("done job has no finish time")
+    }
+}
@@ -0,0 +1,168 @@
+package imagesearch
+
+import (
+    "net/url"
+    "sort"
+    "strings"
+)
+
+// Usage rights information about a single image.
+// Google only annotates some results, so this is a hint rather than a guarantee; always check the source before reusing an image.
+type LicenseInfo struct {
+    // Whether Google marks the image as "Licensable", meaning the source provides licensing details
+    Licensable bool `json:"licensable,omitempty"`
+
+    // Name of the license, for example "CC BY-SA 4.0", if it could be identified
+    Name string `json:"name,omitempty"`
+
+    // Page describing the license, or the source's licensing details
+    Url string `json:"url,omitempty"`
+
+    // Page where a license for the image can be acquired
+    AcquireUrl string `json:"acquire_url,omitempty"`
+
+    // Creator of the image, if known
+    Author string `json:"author,omitempty"`
+}
+
+// Looks for license annotations anywhere in the JSON of a result. Google doesn't annotate results consistently,
+// so rather than relying on fixed positions this looks for Creative Commons license urls and the "Licensable" badge.
+// Other urls mentioning a license aren't taken, since they can't be told apart from the urls of the result itself, like an image named drivers-license.jpg.
+// The urls of the result, like its image, source page, and thumbnail, are never taken as its license, even if they are on creativecommons.org.
+func extractLicense(obj interface{}, urls ...string) *LicenseInfo {
+    var license LicenseInfo
+    found := false
+
+    walkStrings(obj, func(value string) {
+        lower := strings.ToLower(value)
+        switch {
+        case lower == "licensable":
+            license.Licensable = true
+            found = true
+        case license.Url == "" && ccLicenseName(value) != "" && !isResultUrl(value, urls):
+            license.Url = value
+            license.Name = ccLicenseName(value)
+            found = true
+        }
+    })
+
+    if !found {
+        return nil
+    }
+    return &license
+}
+
+// Checks if value is one of the urls of a result.
+func isResultUrl(value string, urls []string) bool {
+    for _, url := range urls {
+        if url != "" && value == url {
+            return true
+        }
+    }
+    return false
+}
+
+// Calls fn with every string inside the decoded json node.
+func walkStrings(node interface{}, fn func(string)) {
+    switch value := node.(type) {
+    case string:
+        fn(value)
+    case []interface{}:
+        for _, child := range value {
+            walkStrings(child, fn)
+        }
+    case map[string]interface{}:
+        keys := make([]string, 0, len(value))
+        for key := range value {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        for _, key := range keys {
+            walkStrings(value[key], fn)
+        }
+    }
+}
+
+// Turns the url of a Creative Commons license into its short name, like https://creativecommons.org/licenses/by-sa/4.0/ into "CC BY-SA 4.0".
+// Returns an empty string if the url isn't a Creative Commons license.
+func ccLicenseName(raw string) string {
+    parsed, err := url.Parse(raw)
+    if err != nil || !strings.HasSuffix(parsed.Hostname(), "creativecommons.org") {
+        return ""
+    }
+
+    parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+    if len(parts) < 2 {
+        return ""
+    }
+    version := ""
+    if len(parts) >= 3 {
+        version = " " + parts[2]
+    }
+
+    switch parts[0] {
+    case "licenses":
+        return "CC " + strings.ToUpper(parts[1]) + version
+    case "publicdomain":
+        if parts[1] == "zero" {
+            return "CC0" + version
+        }
+        if parts[1] == "mark" {
+            return "Public Domain Mark" + version
+        }
+    }
+    return ""
+}
+
+// Makes every search of the Client safe to use commercially in a single switch, for businesses that can't check the usage rights of every image by hand.
+// Searches are restricted to License.CreativeCommons in place of any other license argument, and unless an engine is set WithEngine,
+// the Client searches Openverse and then Wikimedia, which know the exact license and creator of every image, before falling back to Google.
+// Results without an identified license name, like a Creative Commons license or the license an API engine reports, or under a NonCommercial license, are removed, and the license and author of every download is recorded in the Manifest, which this turns on.
+// Licenses like CC BY still require attribution, so credit the Author of the License of every image you use.
+func WithCommercialSafe() Option {
+    return func(c *Client) {
+        c.commercialSafe = true
+        c.manifest = true
+    }
+}
+
+// Replaces any license argument with License.CreativeCommons.
+func commercialArguments(arguments []string) []string {
+    safe := make([]string, 0, len(arguments)+1)
+    for _, argument := range arguments {
+        if !strings.HasPrefix(argument, "il:") {
+            safe = append(safe, argument)
+        }
+    }
+    return append(safe, License.CreativeCommons)
+}
+
+// Removes the images that a Client created WithCommercialSafe refuses: those without an identified license name, and those under a NonCommercial license.
+// A license url alone isn't enough, since it doesn't say which license the image is under.
+func withCommercialLicense(images []Image) []Image {
+    safe := make([]Image, 0, len(images))
+    for _, image := range images {
+        license := image.License
+        if license == nil || license.Name == "" {
+            continue
+        }
+        if nonCommercial(license) {
+            continue
+        }
+        safe = append(safe, image)
+    }
+    return safe
+}
+
+// Checks if a license is a NonCommercial license, like CC BY-NC 4.0.
+func nonCommercial(license *LicenseInfo) bool {
+    words := strings.FieldsFunc(strings.ToUpper(license.Name+" "+ccLicenseName(license.Url)), func(r rune) bool {
+        return r == ' ' || r == '-' || r == '/'
+    })
+    for _, word := range words {
+        if word == "NC" {
+            return true
+        }
+    }
+    return false
+}
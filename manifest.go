@@ -0,0 +1,224 @@
+package imagesearch
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "image"
+    "net/http"
+    "os"
+    "path"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// The name of the manifest file kept in download directories by a Client created with WithManifest.
+const ManifestName = ".imagesearch.json"
+
+// A Manifest records the images that were downloaded into a directory, keyed by their url.
+// It is stored as JSON in the directory itself, under ManifestName.
+type Manifest struct {
+    dir   string
+    Files map[string]*ManifestEntry `json:"files"`
+}
+
+// Information about a single downloaded image.
+type ManifestEntry struct {
+    // Name of the file inside the manifest's directory
+    File string `json:"file"`
+
+    // Validators sent by the server, used to make conditional requests when downloading again
+    ETag         string `json:"etag,omitempty"`
+    LastModified string `json:"last_modified,omitempty"`
+
+    // Size in bytes and hex encoded SHA-256 checksum of the file
+    Size   int64  `json:"size"`
+    Sha256 string `json:"sha256"`
+
+    Downloaded time.Time `json:"downloaded"`
+
+    // The page the image was found on and its license, including the author to credit, if the search result had them
+    Source  string       `json:"source,omitempty"`
+    License *LicenseInfo `json:"license,omitempty"`
+}
+
+// Loads the manifest of the given directory. If the directory does not have a manifest yet, an empty one is returned.
+func LoadManifest(dir string) (*Manifest, error) {
+    dir, err := filepath.Abs(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    manifest := &Manifest{dir: dir, Files: map[string]*ManifestEntry{}}
+    data, err := os.ReadFile(path.Join(dir, ManifestName))
+    if os.IsNotExist(err) {
+        return manifest, nil
+    } else if err != nil {
+        return nil, err
+    }
+
+    err = json.Unmarshal(data, manifest)
+    if err != nil {
+        return nil, err
+    }
+    if manifest.Files == nil {
+        manifest.Files = map[string]*ManifestEntry{}
+    }
+
+    return manifest, nil
+}
+
+// Returns the absolute path of the directory the manifest belongs to.
+func (m *Manifest) Dir() string {
+    return m.dir
+}
+
+// Writes the manifest to its directory, creating the directory if it does not exist.
+func (m *Manifest) Save() error {
+    err := os.MkdirAll(m.dir, os.ModePerm)
+    if err != nil {
+        return err
+    }
+
+    data, err := json.MarshalIndent(m, "", "    ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(path.Join(m.dir, ManifestName), data, 0666)
+}
+
+// Returns the entry of the url if its file still exists, or nil otherwise. Safe to call on a nil manifest.
+func (m *Manifest) existing(url string) *ManifestEntry {
+    if m == nil {
+        return nil
+    }
+
+    entry, ok := m.Files[url]
+    if !ok {
+        return nil
+    }
+    if _, err := os.Stat(path.Join(m.dir, entry.File)); err != nil {
+        return nil
+    }
+
+    return entry
+}
+
+// Records a downloaded file along with the source page and license of the search result it was found in, if any. Safe to call on a nil manifest.
+func (m *Manifest) record(url, file string, header http.Header, data []byte, result Image) {
+    if m == nil {
+        return
+    }
+
+    sum := sha256.Sum256(data)
+    m.Files[url] = &ManifestEntry{
+        File:         file,
+        ETag:         header.Get("ETag"),
+        LastModified: header.Get("Last-Modified"),
+        Size:         int64(len(data)),
+        Sha256:       hex.EncodeToString(sum[:]),
+        Downloaded:   time.Now(),
+        Source:       result.Source,
+        License:      result.License,
+    }
+}
+
+// The kinds of problems Verify finds in a directory.
+const (
+    // A file of the manifest doesn't exist
+    ProblemMissing = "missing"
+
+    // A file's size or checksum doesn't match the manifest, so it changed since it was downloaded
+    ProblemChecksum = "checksum"
+
+    // A file isn't an image, or is an image that fails to decode, like a truncated download
+    ProblemUndecodable = "undecodable"
+
+    // A file in the directory isn't in the manifest
+    ProblemOrphan = "orphan"
+)
+
+// A problem Verify found with a file of a manifest's directory.
+type ManifestProblem struct {
+    // One of the Problem constants, like ProblemMissing
+    Kind string
+
+    // Name of the file inside the directory
+    File string
+
+    // Url the file was downloaded from, empty for orphan files
+    Url string
+
+    // What exactly is wrong, like the mismatched checksums
+    Detail string
+}
+
+func (p ManifestProblem) String() string {
+    return p.Kind + " " + p.File + ": " + p.Detail
+}
+
+// Checks the directory against the manifest: every file of the manifest must exist, match its size and checksum, and decode as an image,
+// and every file in the directory must be in the manifest. Hidden files, like the manifest itself, and the CaptionsName file are skipped.
+// Images in formats the standard library can't decode, like WebP, are only checked to look like images. Returns the problems sorted by file.
+func (m *Manifest) Verify() ([]ManifestProblem, error) {
+    var problems []ManifestProblem
+    known := map[string]bool{}
+    for url, entry := range m.Files {
+        known[entry.File] = true
+        problem := ManifestProblem{File: entry.File, Url: url}
+
+        data, err := os.ReadFile(path.Join(m.dir, entry.File))
+        if os.IsNotExist(err) {
+            problem.Kind, problem.Detail = ProblemMissing, "file doesn't exist"
+            problems = append(problems, problem)
+            continue
+        } else if err != nil {
+            return nil, err
+        }
+
+        sum := sha256.Sum256(data)
+        if checksum := hex.EncodeToString(sum[:]); int64(len(data)) != entry.Size || checksum != entry.Sha256 {
+            problem.Kind = ProblemChecksum
+            problem.Detail = fmt.Sprintf("expected %d bytes with sha256 %s, found %d bytes with sha256 %s", entry.Size, entry.Sha256, len(data), checksum)
+            problems = append(problems, problem)
+        }
+        if err := decodable(data); err != nil {
+            problem.Kind, problem.Detail = ProblemUndecodable, err.Error()
+            problems = append(problems, problem)
+        }
+    }
+
+    entries, err := os.ReadDir(m.dir)
+    if err != nil {
+        return nil, err
+    }
+    for _, entry := range entries {
+        if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || entry.Name() == CaptionsName || known[entry.Name()] {
+            continue
+        }
+        problems = append(problems, ManifestProblem{Kind: ProblemOrphan, File: entry.Name(), Detail: "file isn't in the manifest"})
+    }
+
+    sort.SliceStable(problems, func(i, j int) bool {
+        return problems[i].File < problems[j].File
+    })
+    return problems, nil
+}
+
+// Returns why data isn't a valid image, or nil if it is one.
+func decodable(data []byte) error {
+    _, _, err := image.Decode(bytes.NewReader(data))
+    if err == image.ErrFormat {
+        // SVG images are detected as XML or text
+        if mimetype := http.DetectContentType(data); !strings.HasPrefix(mimetype, "image/") && !bytes.Contains(data, []byte("<svg")) {
+            return fmt.Errorf("not an image, detected %s", mimetype)
+        }
+        return nil
+    }
+    return err
+}
@@ -0,0 +1,124 @@
+package imagesearch
+
+import (
+    "context"
+    "net/http"
+    "net/url"
+    "strconv"
+    "time"
+)
+
+// Sends every request of the Client through the proxy, like "http://proxy.example.com:8080" or "socks5://localhost:1080".
+// The proxy is set on a copy of the transport of the http.Client set with WithHTTPClient, so pass that option first. A nil proxy disables proxying, including through the environment.
+func WithProxy(proxy *url.URL) Option {
+    return func(c *Client) {
+        transport, ok := c.httpClient.Transport.(*http.Transport)
+        if !ok || transport == nil {
+            transport = http.DefaultTransport.(*http.Transport)
+        }
+        transport = transport.Clone()
+        transport.Proxy = http.ProxyURL(proxy)
+
+        httpClient := *c.httpClient
+        httpClient.Transport = transport
+        c.httpClient = &httpClient
+    }
+}
+
+// Sends agent as the User-Agent of every request instead of the browsers the Client imitates.
+// Google serves different pages to different browsers, so searching Google with a custom agent may only find the basic results, or fail to unpack the page.
+func WithUserAgent(agent string) Option {
+    return func(c *Client) {
+        c.userAgent = agent
+    }
+}
+
+// Fails any request that takes longer than timeout, including reading its response, like a search page or an image being downloaded.
+// The timeout is set on a copy of the http.Client set with WithHTTPClient, so pass that option first. A timeout of 0 means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+    return func(c *Client) {
+        httpClient := *c.httpClient
+        httpClient.Timeout = timeout
+        c.httpClient = &httpClient
+    }
+}
+
+// Retries requests that fail with a network error, 429 Too Many Requests, or a 5xx status up to retries times, waiting twice as long before every retry starting at half a second.
+// A Retry-After header sent with the response is waited for instead, if it is longer.
+func WithRetries(retries int) Option {
+    return func(c *Client) {
+        c.retries = retries
+    }
+}
+
+// Limits the requests the Client makes to rate per second on average, with bursts of up to burst requests, waiting for the limit instead of failing.
+// The limit is shared by every request of the Client and the copies made with Client.With, including image downloads. A rate of 0 removes the limit.
+func WithRate(rate float64, burst int) Option {
+    return func(c *Client) {
+        c.limiter = nil
+        if rate > 0 {
+            c.limiter = newLimiter(rate, burst)
+        }
+    }
+}
+
+// The wait before the first retry, which doubles for every retry after it
+const retryDelay = 500 * time.Millisecond
+
+// Sends the request over the network, waiting for the rate limit and retrying it as the Client is configured to.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+    if c.userAgent != "" {
+        req.Header.Set("User-Agent", c.userAgent)
+    }
+
+    delay := retryDelay
+    for attempt := 0; ; attempt++ {
+        if err := c.limiter.wait(req.Context()); err != nil {
+            return nil, err
+        }
+        resp, err := c.httpClient.Do(req)
+        if attempt >= c.retries || !retryable(resp, err) || (req.Body != nil && req.GetBody == nil) {
+            return resp, err
+        }
+
+        wait := delay
+        if resp != nil {
+            if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(seconds)*time.Second > wait {
+                wait = time.Duration(seconds) * time.Second
+            }
+            resp.Body.Close()
+        }
+        if err := sleep(req.Context(), wait); err != nil {
+            return nil, err
+        }
+        delay *= 2
+
+        if req.GetBody != nil {
+            body, err := req.GetBody()
+            if err != nil {
+                return nil, err
+            }
+            req.Body = body
+        }
+    }
+}
+
+// Whether a request that ended with the response or error may succeed if it is sent again.
+func retryable(resp *http.Response, err error) bool {
+    if err != nil {
+        return true
+    }
+    return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// Waits for d, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+    timer := time.NewTimer(d)
+    defer timer.Stop()
+    select {
+    case <-timer.C:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
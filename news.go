@@ -0,0 +1,104 @@
+package imagesearch
+
+import (
+    "context"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// A news article found by News, along with its image.
+// The embedded Image is the image of the article, whose Source is the article itself, so articles can be downloaded and deduplicated like any other image.
+type Article struct {
+    Image
+
+    // Headline of the article, which is also the Title of its image
+    Headline string `json:"headline"`
+
+    // Name of the publication, like "Example News"
+    Publisher string `json:"publisher,omitempty"`
+
+    // When the article was published. Google shows recent articles as relative times like "3 hours ago", so this is only accurate to the unit shown. Zero if unknown.
+    Published time.Time `json:"published"`
+}
+
+// Searches Google News for the query, and returns the articles found that have an image.
+// The amount of articles does not exceed the limit unless the limit is 0, in which case it will return all articles found. Only the Time, SafeSearch, Language, Region and Site arguments apply to news, the rest are ignored.
+func News(query string, limit int, arguments ...string) ([]Article, error) {
+    return defaultClient.News(context.Background(), query, limit, arguments...)
+}
+
+// Searches Google News for the query, and returns the articles found that have an image.
+// The amount of articles does not exceed the limit unless the limit is 0, in which case it will return all articles found. Only the Time, SafeSearch, Language, Region and Site arguments apply to news, the rest are ignored.
+// News always comes from Google, whatever the Client's engine is.
+func (c *Client) News(ctx context.Context, query string, limit int, arguments ...string) ([]Article, error) {
+    page, _, err := c.getPage(ctx, buildVerticalUrl("nws", query, arguments), basicUserAgent)
+    if err != nil {
+        return []Article{}, err
+    }
+
+    now := time.Now()
+    articles := []Article{}
+    for _, result := range basicResults(page) {
+        if limit > 0 && len(articles) >= limit {
+            break
+        }
+        article := Article{
+            Image:    Image{Url: result.thumbnail, Source: result.link, Title: result.title, Rank: len(articles) + 1, Engine: "google"},
+            Headline: result.title,
+        }
+
+        // The publisher comes first, followed by the time and the snippet
+        for _, piece := range strings.Split(strings.Join(result.text, " · "), "·") {
+            if piece = strings.TrimSpace(piece); piece == "" {
+                continue
+            }
+            if published, ok := parsePublished(piece, now); ok {
+                if article.Published.IsZero() {
+                    article.Published = published
+                }
+                continue
+            }
+            switch {
+            case article.Publisher == "" && piece != result.title:
+                article.Publisher = piece
+            case len(piece) > len(article.Description):
+                article.Description = piece
+            }
+        }
+        articles = append(articles, article)
+    }
+    return articles, nil
+}
+
+var relativeTimePattern = regexp.MustCompile(`(\d+) (second|minute|hour|day|week|month|year)s? ago`)
+
+// The length of each unit of relative times
+var relativeUnits = map[string]time.Duration{
+    "second": time.Second,
+    "minute": time.Minute,
+    "hour":   time.Hour,
+    "day":    24 * time.Hour,
+    "week":   7 * 24 * time.Hour,
+    "month":  30 * 24 * time.Hour,
+    "year":   365 * 24 * time.Hour,
+}
+
+// The formats Google shows the dates of older articles in
+var publishedLayouts = []string{"Jan 2, 2006", "2 Jan 2006", "January 2, 2006", "2006-01-02"}
+
+// Parses the time an article was published at from the way Google shows it, relative to now.
+func parsePublished(s string, now time.Time) (time.Time, bool) {
+    if match := relativeTimePattern.FindStringSubmatch(s); match != nil {
+        n, _ := strconv.Atoi(match[1])
+        return now.Add(-time.Duration(n) * relativeUnits[match[2]]), true
+    }
+    s = strings.TrimSpace(s)
+    for _, layout := range publishedLayouts {
+        if published, err := time.Parse(layout, s); err == nil {
+            return published, true
+        }
+    }
+    return time.Time{}, false
+}
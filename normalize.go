@@ -0,0 +1,165 @@
+package imagesearch
+
+import (
+    "errors"
+    "html"
+    "net/url"
+    "strings"
+)
+
+var errMalformedUrl = errors.New("malformed url")
+
+// Query parameters that only track where a click came from, and never change what a url points to.
+var trackingParams = []string{"fbclid", "gclid", "dclid", "msclkid", "igshid", "mc_cid", "mc_eid", "_ga", "yclid", "ref_src"}
+
+// Cleans up a url taken from a results page: decodes html entities, resolves protocol relative urls to https, lowercases the scheme and host, and strips tracking query parameters like utm_source and fbclid.
+// Returns an error for anything that isn't an absolute http or https url. Inline data: and blob: urls are returned unchanged.
+func NormalizeUrl(raw string) (string, error) {
+    raw = strings.TrimSpace(html.UnescapeString(raw))
+    if strings.HasPrefix(raw, "data:") || strings.HasPrefix(raw, "blob:") {
+        return raw, nil
+    }
+    if strings.HasPrefix(raw, "//") {
+        raw = "https:" + raw
+    }
+
+    parsed, err := url.Parse(raw)
+    if err != nil {
+        return "", err
+    }
+    parsed.Scheme = strings.ToLower(parsed.Scheme)
+    if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+        return "", errMalformedUrl
+    }
+    parsed.Host = strings.ToLower(parsed.Host)
+
+    if parsed.RawQuery != "" {
+        query := parsed.Query()
+        changed := false
+        for param := range query {
+            if isTrackingParam(param) {
+                query.Del(param)
+                changed = true
+            }
+        }
+        // Only re-encode the query if something was removed, since the server may depend on the exact encoding
+        if changed {
+            parsed.RawQuery = query.Encode()
+        }
+    }
+
+    return parsed.String(), nil
+}
+
+func isTrackingParam(param string) bool {
+    param = strings.ToLower(param)
+    if strings.HasPrefix(param, "utm_") {
+        return true
+    }
+    for _, tracking := range trackingParams {
+        if param == tracking {
+            return true
+        }
+    }
+    return false
+}
+
+// Returns the url of the favicon of the given host, from Google's favicon service.
+func faviconUrl(host string) string {
+    if host == "" {
+        return ""
+    }
+    return "https://www.google.com/s2/favicons?sz=32&domain=" + url.QueryEscape(host)
+}
+
+// Normalizes the urls of every image, dropping images whose url is malformed and clearing malformed sources. Base and Favicon are filled in from the source if they are missing.
+func normalizeImages(images []Image, diagnostics *Diagnostics) []Image {
+    normalized := images[:0]
+    for i, image := range images {
+        var err error
+        image.Url, err = NormalizeUrl(image.Url)
+        if err != nil {
+            diagnostics.warn(i, "url: %v", err)
+            continue
+        }
+
+        if strings.HasPrefix(image.Url, "data:") && image.ThumbnailData == nil {
+            image.ThumbnailData, _ = decodeDataUrl(image.Url)
+        }
+
+        if image.Source != "" {
+            image.Source, err = NormalizeUrl(image.Source)
+            if err != nil {
+                image.Source = ""
+            }
+        }
+        if image.Source != "" {
+            if source, err := url.Parse(image.Source); err == nil {
+                if image.Base == "" {
+                    image.Base = strings.TrimPrefix(source.Hostname(), "www.")
+                }
+                if image.Favicon == "" {
+                    image.Favicon = faviconUrl(source.Hostname())
+                }
+            }
+        }
+
+        normalized = append(normalized, image)
+    }
+    return normalized
+}
+
+// Image file extensions, whose query string is assumed to not change the image.
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".svg", ".ico", ".avif", ".tif", ".tiff"}
+
+// Returns the key two urls of the same image share. The key is the normalized url, without the scheme,
+// and without the query string if the path ends in an image extension, since that query string usually only resizes the image or busts caches.
+func dedupeKey(raw string) string {
+    normalized, err := NormalizeUrl(raw)
+    if err != nil {
+        return raw
+    }
+    parsed, err := url.Parse(normalized)
+    if err != nil || parsed.Scheme == "data" || parsed.Scheme == "blob" {
+        return normalized
+    }
+
+    key := strings.TrimPrefix(parsed.Host, "www.") + parsed.EscapedPath()
+    lower := strings.ToLower(parsed.Path)
+    for _, extension := range imageExtensions {
+        if strings.HasSuffix(lower, extension) {
+            return key
+        }
+    }
+    if parsed.RawQuery != "" {
+        key += "?" + parsed.RawQuery
+    }
+    return key
+}
+
+// Removes every image whose url is a duplicate of an earlier image's url, once normalized. The order of the remaining images is kept.
+// Urls that only differ in their scheme, a leading www, tracking parameters, or the query string of an image file are considered duplicates.
+func Dedupe(images []Image) []Image {
+    seen := map[string]bool{}
+    deduped := make([]Image, 0, len(images))
+    for _, image := range images {
+        key := dedupeKey(image.Url)
+        if seen[key] {
+            continue
+        }
+        seen[key] = true
+        deduped = append(deduped, image)
+    }
+    return deduped
+}
+
+// Removes every image whose url is an inline data: or blob: url.
+func withoutInline(images []Image) []Image {
+    filtered := make([]Image, 0, len(images))
+    for _, image := range images {
+        if !strings.HasPrefix(image.Url, "data:") && !strings.HasPrefix(image.Url, "blob:") {
+            filtered = append(filtered, image)
+        }
+    }
+    return filtered
+}
@@ -0,0 +1,90 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "net/url"
+    "strings"
+)
+
+// Returns the Openverse engine, which makes its requests through the given Client.
+// Openverse aggregates openly licensed images through a stable public API, and every image comes with its exact license and creator, which makes it the recommended engine for sourcing images you can use commercially.
+// Check the License of each image before using it, as some licenses don't allow commercial use or modification.
+// Of the arguments, Type.Photo, Type.Clipart, AspectRatio, and Format are supported, the rest are ignored. Anonymous requests return up to 20 images.
+func Openverse(c *Client) Engine {
+    return &openverse{client: c}
+}
+
+// Searches the images of Openverse through its API.
+type openverse struct {
+    client *Client
+}
+
+func (o *openverse) Name() string {
+    return "openverse"
+}
+
+// An image as returned by the Openverse API.
+type openverseResult struct {
+    Id    string `json:"id"`
+    Title string `json:"title"`
+    Url   string `json:"url"`
+    // Page the image was found on
+    ForeignLandingUrl string `json:"foreign_landing_url"`
+    Creator           string `json:"creator"`
+    LicenseUrl        string `json:"license_url"`
+    Width             int    `json:"width"`
+    Height            int    `json:"height"`
+}
+
+func (o *openverse) Search(ctx context.Context, query Query) ([]Image, error) {
+    var response struct {
+        Results []json.RawMessage `json:"results"`
+    }
+    if err := o.client.getJSON(ctx, buildOpenverseUrl(query), nil, &response); err != nil {
+        return nil, err
+    }
+
+    images := make([]Image, 0, len(response.Results))
+    for _, raw := range response.Results {
+        var result openverseResult
+        if err := json.Unmarshal(raw, &result); err != nil || result.Url == "" {
+            continue
+        }
+
+        image := Image{Url: result.Url, Source: result.ForeignLandingUrl, Title: result.Title, Width: result.Width, Height: result.Height, Id: result.Id, Raw: raw}
+        if result.LicenseUrl != "" {
+            image.License = &LicenseInfo{Licensable: true, Name: ccLicenseName(result.LicenseUrl), Url: result.LicenseUrl, Author: result.Creator}
+        }
+        images = append(images, image)
+    }
+    return images, nil
+}
+
+// The Openverse parameters equivalent to the arguments, as the parameter and its value.
+var openverseFilters = map[string][2]string{
+    Type.Photo:   {"category", "photograph"},
+    Type.Clipart: {"category", "illustration"},
+
+    AspectRatio.Tall:   {"aspect_ratio", "tall"},
+    AspectRatio.Square: {"aspect_ratio", "square"},
+    AspectRatio.Wide:   {"aspect_ratio", "wide"},
+
+    Format.Jpg: {"extension", "jpg"},
+    Format.Gif: {"extension", "gif"},
+    Format.Png: {"extension", "png"},
+    Format.Svg: {"extension", "svg"},
+}
+
+func buildOpenverseUrl(query Query) string {
+    values := url.Values{"q": {query.Text}, "page_size": {"20"}}
+    for _, argument := range query.Arguments {
+        if filter, ok := openverseFilters[argument]; ok {
+            values[filter[0]] = append(values[filter[0]], filter[1])
+        }
+    }
+    for key, value := range values {
+        values.Set(key, strings.Join(value, ","))
+    }
+    return "https://api.openverse.org/v1/images/?" + values.Encode()
+}
@@ -0,0 +1,642 @@
+package imagesearch
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "html"
+    "net/url"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Key of the object wrapping every image result in the callback payload.
+const envelopeKey = "444383007"
+
+// A strategy extracts images from a results page in one particular way. Google A/B tests the structure of its pages,
+// so there are several strategies that are tried in order until one of them succeeds.
+// Each strategy records its steps in the diagnostics, and calls fail before returning nil if it doesn't find any images.
+type strategy struct {
+    name string
+    run  func(page *resultsPage, diagnostics *Diagnostics) []Image
+}
+
+var strategies = []strategy{
+    {"index", indexStrategy},
+    {"envelope", envelopeStrategy},
+    {"legacy", legacyStrategy},
+    {"regex", regexStrategy},
+}
+
+// A results page along with its decoded callback payload, which is shared by the strategies so it is only decoded once.
+type resultsPage struct {
+    html    string
+    decoded bool
+    payload *payload
+    err     error
+}
+
+// Returned by a Client created WithStrictParsing when a result is missing one of its fields.
+type ErrMissingFields struct {
+    // Position of the first incomplete result on the page, starting at 0
+    Index int
+    // Url of the incomplete result, if it has one
+    Url string
+    // Names of the missing fields, as in the JSON encoding of Image
+    Fields []string
+}
+
+func (e *ErrMissingFields) Error() string {
+    return fmt.Sprintf("result %d (%s) is missing %s", e.Index, e.Url, strings.Join(e.Fields, ", "))
+}
+
+// Checks if an error is an *ErrMissingFields, returned by strict clients when a result is incomplete.
+func IsMissingFields(err error) bool {
+    var missing *ErrMissingFields
+    return errors.As(err, &missing)
+}
+
+// Returns an *ErrMissingFields for the first image missing any of its fields.
+func checkFields(images []Image) error {
+    for i, image := range images {
+        var fields []string
+        if image.Url == "" {
+            fields = append(fields, "url")
+        }
+        if image.Source == "" {
+            fields = append(fields, "source")
+        }
+        if image.Base == "" {
+            fields = append(fields, "base")
+        }
+        if len(fields) > 0 {
+            return &ErrMissingFields{Index: i, Url: image.Url, Fields: fields}
+        }
+    }
+    return nil
+}
+
+// Extracts the images from an already fetched results page, like one saved with Client.ResultsPage.
+func ParsePage(page string) ([]Image, error) {
+    images, diagnostics := extract(page)
+    if diagnostics.Failed != StageNone {
+        return []Image{}, newUnpackError("", "", page, diagnostics)
+    }
+    return images, nil
+}
+
+// Extracts the images from an already fetched basic results page, the kind used by a Client created WithBasicResults.
+func ParseBasicPage(page string) ([]Image, error) {
+    images, diagnostics := extractWith(page, basicStrategies)
+    if diagnostics.Failed != StageNone {
+        return []Image{}, newUnpackError("", "", page, diagnostics)
+    }
+    return images, nil
+}
+
+func extract(page string) ([]Image, Diagnostics) {
+    return extractWith(page, strategies)
+}
+
+func extractWith(page string, strategies []strategy) ([]Image, Diagnostics) {
+    var diagnostics Diagnostics
+    results := &resultsPage{html: page}
+
+    for _, strategy := range strategies {
+        diagnostics.strategy = strategy.name
+        diagnostics.Warnings = nil
+        images := strategy.run(results, &diagnostics)
+        if images != nil {
+            diagnostics.Strategy = strategy.name
+            diagnostics.Schema.Strategy = strategy.name
+            diagnostics.Failed = StageNone
+            diagnostics.Err = nil
+            for i := range images {
+                images[i].Rank = i + 1
+            }
+            images = normalizeImages(images, &diagnostics)
+            diagnostics.Images = len(images)
+            attachThumbnails(page, images)
+            return images, diagnostics
+        }
+    }
+
+    // A query without any results isn't a parse failure
+    if hasNoResults(page) {
+        diagnostics.NoResults = true
+        diagnostics.Failed = StageNone
+        diagnostics.Err = nil
+    }
+
+    return []Image{}, diagnostics
+}
+
+// Phrases Google uses on the page it shows when a query has no results, in the languages it is most commonly served in.
+var noResultsPhrases = []string{
+    "did not match any image results",
+    "did not match any documents",
+    "ergab keine Treffer",
+    "ergab keine Dokumente",
+    "ne correspond à aucun document",
+    "no obtuvo ningún resultado",
+    "に一致する画像は見つかりませんでした",
+    "に一致する情報は見つかりませんでした",
+    "لم يطابق بحثك أي مستندات",
+}
+
+// Converts the JavaScript escape sequences Google uses in pages served in some languages, like \x22 and \x26, into their json equivalent \u0022.
+// Escaped backslashes are left alone, so \\x22 stays a backslash followed by x22.
+func jsEscapes(data string) string {
+    if !strings.Contains(data, `\x`) {
+        return data
+    }
+
+    var b strings.Builder
+    b.Grow(len(data) + len(data)/100)
+    for i := 0; i < len(data); i++ {
+        if data[i] != '\\' || i+1 >= len(data) {
+            b.WriteByte(data[i])
+            continue
+        }
+        if data[i+1] == 'x' && i+3 < len(data) && isHex(data[i+2]) && isHex(data[i+3]) {
+            b.WriteString(`\u00`)
+            b.WriteString(data[i+2 : i+4])
+            i += 3
+            continue
+        }
+        // Any other escape is copied as is, including the escaped character
+        b.WriteString(data[i : i+2])
+        i++
+    }
+    return b.String()
+}
+
+func isHex(c byte) bool {
+    return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// Checks if the page is Google telling us the query has no results.
+func hasNoResults(page string) bool {
+    for _, phrase := range noResultsPhrases {
+        if strings.Contains(page, phrase) {
+            return true
+        }
+    }
+    return false
+}
+
+// Decodes the payload of the AF_initDataCallback holding the results, recording the callback and decode stages the first time it is called.
+func (p *resultsPage) callback(diagnostics *Diagnostics) (*payload, bool) {
+    if p.decoded {
+        return p.payload, p.err == nil
+    }
+    p.decoded = true
+    p.payload, p.err = decodeCallback(p.html, diagnostics)
+    return p.payload, p.err == nil
+}
+
+// A single AF_initDataCallback script on the page.
+//	AF_initDataCallback({key: 'ds:1', hash: '2', data:[...], sideChannel: {}});
+type callbackBlock struct {
+    key  string
+    data string
+}
+
+var callbackKeyPattern = regexp.MustCompile(`key:\s*'([^']*)'`)
+
+// Finds every AF_initDataCallback script on the page, in order.
+func callbackBlocks(page string) []callbackBlock {
+    var blocks []callbackBlock
+    for {
+        start := strings.Index(page, "AF_initDataCallback")
+        if start == -1 {
+            return blocks
+        }
+        page = page[start+len("AF_initDataCallback"):]
+
+        end := strings.Index(page, "</script>")
+        if end == -1 {
+            end = len(page)
+        }
+        script := page[:end]
+        page = page[end:]
+
+        var block callbackBlock
+        if key := callbackKeyPattern.FindStringSubmatch(script); key != nil {
+            block.key = key[1]
+        }
+        dataStart := strings.Index(script, "data:")
+        if dataStart != -1 {
+            dataStart += len("data:")
+        } else {
+            dataStart = strings.Index(script, "[")
+        }
+        if dataStart == -1 {
+            continue
+        }
+        block.data = script[dataStart:]
+        blocks = append(blocks, block)
+    }
+}
+
+// Decodes the payload of the AF_initDataCallback holding the results. There are several callbacks on every page, and their order isn't fixed,
+// so the one mentioning the result envelope key the most is chosen, preferring the earliest one on a tie. If none of them mention it, the last one is used.
+func decodeCallback(page string, diagnostics *Diagnostics) (*payload, error) {
+    blocks := callbackBlocks(page)
+    if len(blocks) == 0 {
+        diagnostics.fail(StageCallback, errUnpack, "no AF_initDataCallback with a payload in %d byte page", len(page))
+        return nil, errUnpack
+    }
+
+    var candidates []callbackBlock
+    var counts []int
+    for _, block := range blocks {
+        if count := strings.Count(block.data, envelopeKey); count > 0 {
+            candidates = append(candidates, block)
+            counts = append(counts, count)
+        }
+    }
+    if len(candidates) == 0 {
+        candidates = blocks[len(blocks)-1:]
+        counts = []int{0}
+    }
+    sort.Stable(byCount{candidates, counts})
+    diagnostics.pass(StageCallback, "%d AF_initDataCallback blocks, %d mentioning %s", len(blocks), len(candidates), envelopeKey)
+    diagnostics.Schema.Callbacks = len(blocks)
+
+    var err error
+    for _, block := range candidates {
+        var decoded *payload
+        data := jsEscapes(block.data)
+        decoded, err = readPayload(data)
+        if err != nil && strings.Contains(data, "&quot;") {
+            // Some pages serve the payload html escaped
+            data = html.UnescapeString(data)
+            decoded, err = readPayload(data)
+        }
+        if err != nil {
+            if syntax, ok := err.(*json.SyntaxError); ok {
+                diagnostics.context = snippet(data, int(syntax.Offset))
+            }
+            continue
+        }
+
+        diagnostics.pass(StageDecode, "%s in %s, %d %s objects", decoded.root, block.key, len(decoded.envelopes), envelopeKey)
+        diagnostics.Schema.CallbackKey = block.key
+        if len(decoded.envelopes) > 0 {
+            diagnostics.Schema.EnvelopePath = formatPath(decoded.envelopes[0].path)
+        }
+        diagnostics.shape = decoded.shape
+        return decoded, nil
+    }
+
+    diagnostics.fail(StageDecode, err, "payload is not a json array: %v", err)
+    return nil, err
+}
+
+// Sorts callback blocks by how often they mention the envelope key, most first.
+type byCount struct {
+    blocks []callbackBlock
+    counts []int
+}
+
+func (b byCount) Len() int           { return len(b.blocks) }
+func (b byCount) Less(i, j int) bool { return b.counts[i] > b.counts[j] }
+func (b byCount) Swap(i, j int) {
+    b.blocks[i], b.blocks[j] = b.blocks[j], b.blocks[i]
+    b.counts[i], b.counts[j] = b.counts[j], b.counts[i]
+}
+
+// Path from the root of the decoded callback payload to the list of image results.
+var imagePath = []int{56, 1, 0, 0, 1, 0}
+
+// Follows the index path the results have been at for a while. This is the fastest strategy, but breaks as soon as Google shifts a single index.
+func indexStrategy(page *resultsPage, diagnostics *Diagnostics) []Image {
+    payload, ok := page.callback(diagnostics)
+    if !ok {
+        return nil
+    }
+
+    trail := "imageJson"
+    for depth, i := range imagePath {
+        node := payload.trail[depth]
+        if node.kind != "array" {
+            diagnostics.fail(StageIndex, errUnpack, "expected array at %s, found %s", trail, node)
+            return nil
+        }
+        if i >= node.length {
+            diagnostics.fail(StageIndex, errUnpack, "expected index %d at %s, found %s", i, trail, node)
+            return nil
+        }
+        trail += fmt.Sprintf("[%d]", i)
+    }
+
+    node := payload.trail[len(imagePath)]
+    if node.kind != "array" {
+        diagnostics.fail(StageIndex, errUnpack, "expected array of results at %s, found %s", trail, node)
+        return nil
+    }
+
+    // Every result is wrapped like [[{"444383007": ...}]]
+    var envelopes []interface{}
+    for _, envelope := range payload.envelopes {
+        if envelope.under(imagePath, 0, 0) {
+            envelopes = append(envelopes, envelope.value)
+        }
+    }
+    if len(envelopes) == 0 {
+        diagnostics.fail(StageIndex, errUnpack, "no %s objects in the %s at %s", envelopeKey, node, trail)
+        return nil
+    }
+    diagnostics.pass(StageIndex, "%d %s objects at %s", len(envelopes), envelopeKey, trail)
+
+    return imagesFromEnvelopes(envelopes, diagnostics)
+}
+
+// Uses every result envelope in the payload, wherever they are.
+func envelopeStrategy(page *resultsPage, diagnostics *Diagnostics) []Image {
+    payload, ok := page.callback(diagnostics)
+    if !ok {
+        return nil
+    }
+
+    if len(payload.envelopes) == 0 {
+        diagnostics.fail(StageIndex, errUnpack, "no %s objects in %s", envelopeKey, payload.root)
+        return nil
+    }
+    diagnostics.pass(StageIndex, "%d %s objects", len(payload.envelopes), envelopeKey)
+
+    envelopes := make([]interface{}, len(payload.envelopes))
+    for i, envelope := range payload.envelopes {
+        envelopes[i] = envelope.value
+    }
+    return imagesFromEnvelopes(envelopes, diagnostics)
+}
+
+func imagesFromEnvelopes(envelopes []interface{}, diagnostics *Diagnostics) []Image {
+    var images []Image
+    var skipped, malformed int
+    for i, envelope := range envelopes {
+        obj, _ := lookup(envelope, 1)
+        if obj == nil {
+            // Not every result is an image, suggested searches and the like have a null entry.
+            skipped++
+            continue
+        }
+
+        var image Image
+        var ok bool
+        url, _ := lookup(obj, 3, 0)
+        if image.Url, ok = url.(string); !ok || image.Url == "" {
+            diagnostics.warn(i, "url: expected string, found %s", describe(url))
+            malformed++
+            continue
+        }
+
+        // The full size image is [url, height, width]
+        height, _ := lookup(obj, 3, 1)
+        image.Height = number(height)
+        width, _ := lookup(obj, 3, 2)
+        image.Width = number(width)
+
+        source, _ := lookup(obj, 9, "2003", 2)
+        image.Source, _ = source.(string)
+
+        base, _ := lookup(obj, 9, "2003", 17)
+        image.Base, _ = base.(string)
+
+        title, _ := lookup(obj, 9, "2003", 3)
+        image.Title, _ = title.(string)
+
+        description, _ := lookup(obj, 9, "2008", 1)
+        image.Description, _ = description.(string)
+
+        thumbnail, _ := lookup(obj, 2, 0)
+        thumbnailUrl, _ := thumbnail.(string)
+        image.License = extractLicense(obj, image.Url, image.Source, thumbnailUrl)
+
+        image.Raw, _ = json.Marshal(obj)
+
+        id, _ := lookup(obj, 1)
+        image.Id, _ = id.(string)
+
+        images = append(images, image)
+    }
+    if len(images) == 0 {
+        diagnostics.fail(StageFields, errUnpack, "no image urls in %d results, %d non-image results, %d malformed", len(envelopes), skipped, malformed)
+        return nil
+    }
+    diagnostics.pass(StageFields, "%d images, %d non-image results, %d malformed", len(images), skipped, malformed)
+
+    return images
+}
+
+// Metadata of a single result in the format Google used before the AF_initDataCallback payloads:
+//	<div class="rg_meta notranslate">{"ou":"https://example.com/image.png","ru":"https://example.com/article","rh":"example.com"}</div>
+type legacyMeta struct {
+    // Original url
+    Ou string `json:"ou"`
+    // Referrer url, the page the image was found on
+    Ru string `json:"ru"`
+    // Referrer host
+    Rh string `json:"rh"`
+    // Original width and height
+    Ow int `json:"ow"`
+    Oh int `json:"oh"`
+    // Page title and snippet
+    Pt string `json:"pt"`
+    S  string `json:"s"`
+    // Document id
+    Id string `json:"id"`
+}
+
+const legacyMarker = `class="rg_meta`
+
+// Reads the rg_meta elements of the legacy page format, which Google still serves every now and then.
+func legacyStrategy(page *resultsPage, diagnostics *Diagnostics) []Image {
+    rest := page.html
+    var images []Image
+    var elements, malformed int
+    for i := 0; ; i++ {
+        start := strings.Index(rest, legacyMarker)
+        if start == -1 {
+            break
+        }
+        rest = rest[start:]
+        open := strings.Index(rest, ">")
+        if open == -1 {
+            break
+        }
+        rest = rest[open+1:]
+        end := strings.Index(rest, "</div>")
+        if end == -1 {
+            break
+        }
+        elements++
+
+        var meta legacyMeta
+        raw := []byte(html.UnescapeString(rest[:end]))
+        err := json.Unmarshal(raw, &meta)
+        rest = rest[end:]
+        if err != nil {
+            diagnostics.warn(i, "rg_meta is not a json object: %v", err)
+            malformed++
+            continue
+        }
+        if meta.Ou == "" {
+            diagnostics.warn(i, "rg_meta has no ou")
+            malformed++
+            continue
+        }
+        images = append(images, Image{Url: meta.Ou, Source: meta.Ru, Base: meta.Rh, Title: meta.Pt, Description: meta.S, Width: meta.Ow, Height: meta.Oh, Id: strings.TrimSuffix(meta.Id, ":"), Raw: raw})
+    }
+
+    if elements == 0 {
+        diagnostics.fail(StageIndex, errUnpack, "no rg_meta elements")
+        return nil
+    }
+    diagnostics.pass(StageIndex, "%d rg_meta elements", elements)
+    if len(images) == 0 {
+        diagnostics.fail(StageFields, errUnpack, "no image urls in %d rg_meta elements, %d malformed", elements, malformed)
+        return nil
+    }
+    diagnostics.pass(StageFields, "%d images, %d malformed", len(images), malformed)
+
+    return images
+}
+
+var (
+    // Full size images appear in the payload as ["url",height,width]
+    imageUrlPattern  = regexp.MustCompile(`\["(https?://[^"]+)",\s*(\d+),\s*(\d+)\]`)
+    quotedUrlPattern = regexp.MustCompile(`"(https?://[^"]+)"`)
+)
+
+// How far after an image url to look for its source url.
+const sourceWindow = 2048
+
+// The last resort when none of the structured strategies work. Scrapes everything that looks like a full size image url from the page,
+// along with the first non-Google url that follows it as its source. The results are marked as BestEffort, since they may include urls that aren't results at all.
+func regexStrategy(page *resultsPage, diagnostics *Diagnostics) []Image {
+    matches := imageUrlPattern.FindAllStringSubmatchIndex(page.html, -1)
+    if len(matches) == 0 {
+        diagnostics.fail(StageIndex, errUnpack, "no image urls with dimensions")
+        return nil
+    }
+    diagnostics.pass(StageIndex, "%d image urls with dimensions", len(matches))
+
+    var images []Image
+    seen := map[string]bool{}
+    for _, match := range matches {
+        imageUrl := unquote(page.html[match[2]:match[3]])
+        if isGoogleUrl(imageUrl) || seen[imageUrl] {
+            continue
+        }
+        seen[imageUrl] = true
+
+        image := Image{Url: imageUrl, BestEffort: true}
+        image.Height, _ = strconv.Atoi(page.html[match[4]:match[5]])
+        image.Width, _ = strconv.Atoi(page.html[match[6]:match[7]])
+        window := page.html[match[1]:]
+        if len(window) > sourceWindow {
+            window = window[:sourceWindow]
+        }
+        for _, candidate := range quotedUrlPattern.FindAllStringSubmatch(window, -1) {
+            source := unquote(candidate[1])
+            if source == imageUrl || isGoogleUrl(source) {
+                continue
+            }
+            image.Source = source
+            if parsed, err := url.Parse(source); err == nil {
+                image.Base = strings.TrimPrefix(parsed.Hostname(), "www.")
+            }
+            break
+        }
+
+        images = append(images, image)
+    }
+    if len(images) == 0 {
+        diagnostics.fail(StageFields, errUnpack, "every image url belongs to Google")
+        return nil
+    }
+    diagnostics.pass(StageFields, "%d best effort images", len(images))
+
+    return images
+}
+
+// Decodes the escape sequences of a url taken from inside a json string, like \u003d.
+func unquote(raw string) string {
+    var decoded string
+    if err := json.Unmarshal([]byte(`"`+jsEscapes(raw)+`"`), &decoded); err != nil {
+        return raw
+    }
+    return decoded
+}
+
+// Checks if the url belongs to Google itself, like the thumbnails on gstatic.com, rather than to a result.
+func isGoogleUrl(raw string) bool {
+    parsed, err := url.Parse(raw)
+    if err != nil {
+        return true
+    }
+    host := parsed.Hostname()
+    return host == "google.com" || strings.HasSuffix(host, ".google.com") || strings.HasSuffix(host, ".gstatic.com") || strings.HasSuffix(host, ".googleusercontent.com")
+}
+
+// Follows the given path of array indexes (int) and object keys (string) from the node.
+// Returns false if any step of the path does not exist.
+func lookup(node interface{}, path ...interface{}) (interface{}, bool) {
+    for _, step := range path {
+        switch key := step.(type) {
+        case int:
+            array, ok := node.([]interface{})
+            if !ok || key < 0 || key >= len(array) {
+                return nil, false
+            }
+            node = array[key]
+        case string:
+            object, ok := node.(map[string]interface{})
+            if !ok {
+                return nil, false
+            }
+            node, ok = object[key]
+            if !ok {
+                return nil, false
+            }
+        }
+    }
+    return node, true
+}
+
+// Returns the decoded json number as an int, or 0 if the node isn't a number.
+func number(node interface{}) int {
+    switch value := node.(type) {
+    case float64:
+        return int(value)
+    case json.Number:
+        n, _ := value.Int64()
+        return int(n)
+    }
+    return 0
+}
+
+// Describes the type and size of a decoded json node, without including any of its content.
+func describe(node interface{}) string {
+    switch value := node.(type) {
+    case nil:
+        return "null"
+    case []interface{}:
+        return fmt.Sprintf("array of %d", len(value))
+    case map[string]interface{}:
+        return fmt.Sprintf("object with %d keys", len(value))
+    case string:
+        return fmt.Sprintf("string of length %d", len(value))
+    case float64, json.Number:
+        return "number"
+    case bool:
+        return "bool"
+    default:
+        return fmt.Sprintf("%T", node)
+    }
+}
@@ -0,0 +1,58 @@
+package imagesearch
+
+import (
+    "os"
+    "reflect"
+    "regexp"
+    "strings"
+    "testing"
+)
+
+var resultsScriptPattern = regexp.MustCompile(`<script[^>]*>AF_initDataCallback\(\{key: 'ds:1'.*?</script>`)
+
+// The same results callback must be chosen wherever it is among the other callbacks of the page.
+func TestCallbackOrder(t *testing.T) {
+    fixture, err := os.ReadFile("parsertest/fixtures/callback.html")
+    if err != nil {
+        t.Fatal(err)
+    }
+    results := resultsScriptPattern.FindString(string(fixture))
+    if results == "" {
+        t.Fatal("no results callback in the fixture")
+    }
+    others := []string{
+        `<script>AF_initDataCallback({key: 'ds:0', hash: '1', data:[null,[1,2]], sideChannel: {}});</script>`,
+        // Mentions the envelope key, but less often than the results
+        `<script>AF_initDataCallback({key: 'ds:2', hash: '2', data:[[{"444383007":null}]], sideChannel: {}});</script>`,
+        `<script>AF_initDataCallback({key: 'ds:3', hash: '3', data:["suggestions"], sideChannel: {}});</script>`,
+    }
+
+    orders := map[string][]string{
+        "first":  {results, others[0], others[1], others[2]},
+        "middle": {others[0], others[1], results, others[2]},
+        "last":   {others[0], others[1], others[2], results},
+    }
+    var want []string
+    for _, name := range []string{"first", "middle", "last"} {
+        page := "<html><body>" + strings.Join(orders[name], "") + "</body></html>"
+        images, err := ParsePage(page)
+        if err != nil {
+            t.Fatalf("%s: %v", name, err)
+        }
+        var urls []string
+        for _, image := range images {
+            urls = append(urls, image.Url)
+        }
+        if len(urls) != 12 {
+            t.Errorf("%s: extracted %d images, expected 12", name, len(urls))
+        }
+        if key := DetectSchema(page).CallbackKey; key != "ds:1" {
+            t.Errorf("%s: chose callback %q, expected ds:1", name, key)
+        }
+        if want == nil {
+            want = urls
+        } else if !reflect.DeepEqual(urls, want) {
+            t.Errorf("%s: extracted %v, expected %v", name, urls, want)
+        }
+    }
+}
@@ -0,0 +1,249 @@
+// Package parsertest checks that imagesearch can still parse Google's results pages, against a set of sample pages shipped with the package, pages you saved yourself, or live results.
+// It is meant to be used from your own tests, so you find out that your pinned version of imagesearch stopped working in CI instead of in production:
+//
+//	func TestImagesearch(t *testing.T) {
+//	    parsertest.CheckFixtures(t)
+//	    parsertest.CheckLive(t, "example")
+//	}
+//
+// The shipped fixtures reproduce the structure of each page layout imagesearch supports with placeholder content, except for those named captured-*,
+// which are live pages saved with Capture or CaptureBasic and cut down with Trim. To capture the current pages for a query into the shipped fixtures, run this from the root of the repository:
+//
+//	go test -run TestCapture -capture "example query"
+//
+// If a page stops parsing, save it with Save and contribute it at https://github.com/commonkestrel/imagesearch/issues.
+package parsertest
+
+import (
+    "context"
+    "embed"
+    "encoding/json"
+    "errors"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/commonkestrel/imagesearch"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// The name of the index file listing the fixtures in a fixture directory.
+const IndexName = "fixtures.json"
+
+// A saved results page along with what should be extracted from it.
+type Fixture struct {
+    // Name of the fixture, the page is stored as Name + ".html"
+    Name string `json:"name"`
+
+    // Whether the page is a basic results page, see imagesearch.WithBasicResults
+    Basic bool `json:"basic"`
+
+    // Minimum number of images that should be extracted from the page
+    MinImages int `json:"min_images"`
+
+    // What the fixture covers
+    Note string `json:"note,omitempty"`
+
+    Page string `json:"-"`
+}
+
+// Returns the fixtures shipped with the package.
+func Fixtures() ([]Fixture, error) {
+    return load(func(name string) ([]byte, error) {
+        return fixtures.ReadFile(path.Join("fixtures", name))
+    })
+}
+
+// Loads the fixtures saved in dir, listed in its IndexName file.
+func Load(dir string) ([]Fixture, error) {
+    return load(func(name string) ([]byte, error) {
+        return os.ReadFile(filepath.Join(dir, name))
+    })
+}
+
+func load(read func(name string) ([]byte, error)) ([]Fixture, error) {
+    index, err := read(IndexName)
+    if err != nil {
+        return nil, err
+    }
+
+    var loaded []Fixture
+    err = json.Unmarshal(index, &loaded)
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range loaded {
+        page, err := read(loaded[i].Name + ".html")
+        if err != nil {
+            return nil, err
+        }
+        loaded[i].Page = string(page)
+    }
+
+    return loaded, nil
+}
+
+// Saves the fixture into dir, adding it to the directory's IndexName file, or replacing the fixture with the same name.
+func Save(dir string, fixture Fixture) error {
+    err := os.MkdirAll(dir, os.ModePerm)
+    if err != nil {
+        return err
+    }
+
+    existing, err := Load(dir)
+    if err != nil && !errors.Is(err, os.ErrNotExist) {
+        return err
+    }
+
+    var index []Fixture
+    for _, other := range existing {
+        if other.Name != fixture.Name {
+            index = append(index, other)
+        }
+    }
+    index = append(index, fixture)
+
+    data, err := json.MarshalIndent(index, "", "    ")
+    if err != nil {
+        return err
+    }
+
+    err = os.WriteFile(filepath.Join(dir, fixture.Name+".html"), []byte(fixture.Page), 0666)
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(filepath.Join(dir, IndexName), data, 0666)
+}
+
+// Fetches the current results page for the query along with the given arguments, as a fixture expecting at least minImages images.
+func Capture(ctx context.Context, name, query string, minImages int, arguments ...string) (Fixture, error) {
+    page, err := imagesearch.NewClient().ResultsPage(ctx, query, arguments...)
+    if err != nil {
+        return Fixture{}, err
+    }
+
+    return Fixture{Name: name, MinImages: minImages, Note: "captured for " + query, Page: page}, nil
+}
+
+// Fetches the current basic results page for the query along with the given arguments, like a Client created WithBasicResults, as a fixture expecting at least minImages images.
+func CaptureBasic(ctx context.Context, name, query string, minImages int, arguments ...string) (Fixture, error) {
+    page, err := imagesearch.NewClient(imagesearch.WithBasicResults()).ResultsPage(ctx, query, arguments...)
+    if err != nil {
+        return Fixture{}, err
+    }
+
+    return Fixture{Name: name, Basic: true, MinImages: minImages, Note: "basic page captured for " + query, Page: page}, nil
+}
+
+// Cuts a captured page down to what the parser reads, so it is small enough to commit.
+// Styles, inline svgs, and scripts are removed, except the AF_initDataCallback scripts holding the results and the scripts inlining thumbnails.
+func Trim(page string) string {
+    var trimmed strings.Builder
+    for {
+        start, tag := -1, ""
+        for _, candidate := range []string{"<script", "<style", "<svg"} {
+            if i := strings.Index(page, candidate); i != -1 && (start == -1 || i < start) {
+                start, tag = i, candidate
+            }
+        }
+        if start == -1 {
+            trimmed.WriteString(page)
+            return trimmed.String()
+        }
+
+        closing := "</" + tag[1:] + ">"
+        end := strings.Index(page[start:], closing)
+        if end == -1 {
+            end = len(page)
+        } else {
+            end += start + len(closing)
+        }
+        trimmed.WriteString(page[:start])
+        element := page[start:end]
+        if tag == "<script" && (strings.Contains(element, "AF_initDataCallback") || strings.Contains(element, "_setImagesSrc")) {
+            trimmed.WriteString(element)
+        }
+        page = page[end:]
+    }
+}
+
+// Parses the fixture's page and reports an error, along with the parse diagnostics, if fewer images than expected were extracted.
+// Returns the extracted images.
+func Check(t testing.TB, fixture Fixture) []imagesearch.Image {
+    t.Helper()
+
+    var images []imagesearch.Image
+    var err error
+    if fixture.Basic {
+        images, err = imagesearch.ParseBasicPage(fixture.Page)
+    } else {
+        images, err = imagesearch.ParsePage(fixture.Page)
+    }
+
+    if err != nil {
+        t.Errorf("%s: %v\n%s", fixture.Name, err, imagesearch.DiagnosePage(fixture.Page))
+        return images
+    }
+    if len(images) < fixture.MinImages {
+        t.Errorf("%s: extracted %d images, expected at least %d", fixture.Name, len(images), fixture.MinImages)
+    }
+    for i, image := range images {
+        if image.Url == "" {
+            t.Errorf("%s: image %d has no url", fixture.Name, i)
+        }
+    }
+
+    return images
+}
+
+// Checks every fixture shipped with the package, each in its own subtest.
+func CheckFixtures(t *testing.T) {
+    t.Helper()
+
+    loaded, err := Fixtures()
+    if err != nil {
+        t.Fatal(err)
+    }
+    checkAll(t, loaded)
+}
+
+// Checks every fixture saved in dir, each in its own subtest.
+func CheckDir(t *testing.T, dir string) {
+    t.Helper()
+
+    loaded, err := Load(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    checkAll(t, loaded)
+}
+
+func checkAll(t *testing.T, loaded []Fixture) {
+    for _, fixture := range loaded {
+        fixture := fixture
+        t.Run(fixture.Name, func(t *testing.T) {
+            Check(t, fixture)
+        })
+    }
+}
+
+// Searches Google for the query and checks that the live results page still parses.
+// Skipped in short mode, since it needs network access.
+func CheckLive(t *testing.T, query string, arguments ...string) []imagesearch.Image {
+    t.Helper()
+
+    if testing.Short() {
+        t.Skip("skipping live search in short mode")
+    }
+
+    fixture, err := Capture(context.Background(), "live", query, 1, arguments...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return Check(t, fixture)
+}
@@ -0,0 +1,216 @@
+package imagesearch
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// The parts of a callback payload the strategies need. The payload is several megabytes of mostly irrelevant data,
+// so instead of decoding all of it into a tree of interfaces, it is streamed through once and only the result envelopes are materialized.
+type payload struct {
+    // Description of the root, like "array of 57"
+    root string
+
+    // Every result envelope, in the order they appear
+    envelopes []envelope
+
+    // The nodes along imagePath, the node at imagePath[:i] being trail[i]
+    trail []nodeInfo
+
+    // Skeleton of the payload, see skeleton
+    shape string
+}
+
+// The value of a single result envelope.
+type envelope struct {
+    // Path of the object holding the envelope
+    path  []interface{}
+    value interface{}
+}
+
+// Checks if the envelope is held at the given path followed by the given indexes.
+func (e envelope) under(path []int, indexes ...int) bool {
+    if len(e.path) != len(path)+1+len(indexes) {
+        return false
+    }
+    for i, index := range path {
+        if e.path[i] != index {
+            return false
+        }
+    }
+    // The position among the results can be anything
+    for i, index := range indexes {
+        if e.path[len(path)+1+i] != index {
+            return false
+        }
+    }
+    _, ok := e.path[len(path)].(int)
+    return ok
+}
+
+// The type and size of a node that wasn't materialized.
+type nodeInfo struct {
+    // One of "array", "object", "string", "number", "bool", or "null", empty if the node doesn't exist
+    kind   string
+    length int
+}
+
+func (n nodeInfo) String() string {
+    switch n.kind {
+    case "":
+        return "nothing"
+    case "array":
+        return fmt.Sprintf("array of %d", n.length)
+    case "object":
+        return fmt.Sprintf("object with %d keys", n.length)
+    case "string":
+        return fmt.Sprintf("string of length %d", n.length)
+    default:
+        return n.kind
+    }
+}
+
+type payloadReader struct {
+    decoder *json.Decoder
+    payload *payload
+    // Path of the value being read
+    path []interface{}
+}
+
+// Streams through the json array at the start of data. Whatever follows the array is ignored.
+func readPayload(data string) (*payload, error) {
+    r := &payloadReader{
+        decoder: json.NewDecoder(strings.NewReader(data)),
+        payload: &payload{trail: make([]nodeInfo, len(imagePath)+1)},
+    }
+
+    token, err := r.decoder.Token()
+    if err != nil {
+        return nil, err
+    }
+    if delim, ok := token.(json.Delim); !ok || delim != '[' {
+        return nil, fmt.Errorf("payload starts with %v instead of an array", token)
+    }
+
+    info, shape, err := r.value(token, 0)
+    if err != nil {
+        return nil, err
+    }
+    r.payload.root = info.String()
+    r.payload.shape = shape
+
+    return r.payload, nil
+}
+
+// Reads the rest of the value starting with token, and returns its type and skeleton. Envelopes are materialized along the way.
+func (r *payloadReader) value(token json.Token, depth int) (info nodeInfo, shape string, err error) {
+    switch value := token.(type) {
+    case json.Delim:
+        var children []string
+        if value == '[' {
+            info.kind = "array"
+            for r.decoder.More() {
+                r.path = append(r.path, info.length)
+                childShape, err := r.child(depth)
+                r.path = r.path[:len(r.path)-1]
+                if err != nil {
+                    return info, "", err
+                }
+                if depth < shapeDepth {
+                    children = append(children, childShape)
+                }
+                info.length++
+            }
+        } else {
+            info.kind = "object"
+            for r.decoder.More() {
+                token, err := r.decoder.Token()
+                if err != nil {
+                    return info, "", err
+                }
+                key, _ := token.(string)
+
+                var childShape string
+                if key == envelopeKey {
+                    var envelopeValue interface{}
+                    if err = r.decoder.Decode(&envelopeValue); err != nil {
+                        return info, "", err
+                    }
+                    r.payload.envelopes = append(r.payload.envelopes, envelope{path: append([]interface{}{}, r.path...), value: envelopeValue})
+                    if depth < shapeDepth {
+                        childShape = skeleton(envelopeValue, shapeDepth-depth-1)
+                    }
+                } else {
+                    r.path = append(r.path, key)
+                    childShape, err = r.child(depth)
+                    r.path = r.path[:len(r.path)-1]
+                    if err != nil {
+                        return info, "", err
+                    }
+                }
+                if depth < shapeDepth {
+                    children = append(children, key+":"+childShape)
+                }
+                info.length++
+            }
+            // Same order as skeleton, which can only see the keys of a map in sorted order
+            sort.Strings(children)
+        }
+
+        // The closing delimiter
+        if _, err = r.decoder.Token(); err != nil {
+            return info, "", err
+        }
+
+        if depth < shapeDepth {
+            if info.kind == "array" {
+                shape = "[" + strings.Join(children, ",") + "]"
+            } else {
+                shape = "{" + strings.Join(children, ",") + "}"
+            }
+        } else if info.kind == "array" {
+            shape = fmt.Sprintf("[%d]", info.length)
+        } else {
+            shape = fmt.Sprintf("{%d}", info.length)
+        }
+    case nil:
+        info.kind, shape = "null", "n"
+    case string:
+        info.kind, info.length, shape = "string", len(value), "s"
+    case bool:
+        info.kind, shape = "bool", "b"
+    default:
+        info.kind, shape = "number", "d"
+    }
+
+    r.record(info)
+    return info, shape, nil
+}
+
+// Reads the next value as a child of a node at depth. Only the skeleton of children within shapeDepth is kept.
+func (r *payloadReader) child(depth int) (string, error) {
+    token, err := r.decoder.Token()
+    if err != nil {
+        return "", err
+    }
+    _, shape, err := r.value(token, depth+1)
+    if depth >= shapeDepth {
+        shape = ""
+    }
+    return shape, err
+}
+
+// Records the node that was just read if it lies along imagePath.
+func (r *payloadReader) record(info nodeInfo) {
+    if len(r.path) > len(imagePath) {
+        return
+    }
+    for i, step := range r.path {
+        if step != imagePath[i] {
+            return
+        }
+    }
+    r.payload.trail[len(r.path)] = info
+}
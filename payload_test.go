@@ -0,0 +1,27 @@
+package imagesearch_test
+
+import (
+    "testing"
+
+    "github.com/commonkestrel/imagesearch"
+    "github.com/commonkestrel/imagesearch/parsertest"
+)
+
+// Parses every shipped fixture page, to measure the time and allocations of decoding the callback payload.
+func BenchmarkParse(b *testing.B) {
+    fixtures, err := parsertest.Fixtures()
+    if err != nil {
+        b.Fatal(err)
+    }
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        for _, fixture := range fixtures {
+            if fixture.Basic {
+                imagesearch.ParseBasicPage(fixture.Page)
+            } else {
+                imagesearch.ParsePage(fixture.Page)
+            }
+        }
+    }
+}
@@ -0,0 +1,151 @@
+package imagesearch
+
+import (
+    "context"
+    "errors"
+    "math/rand"
+    "strconv"
+    "strings"
+    "time"
+)
+
+var errNoResults = errors.New("no images found")
+
+// Checks if an error is the error of RandomImage or TopImage when the search has no usable results.
+func IsNoResults(err error) bool {
+    return errors.Is(err, errNoResults)
+}
+
+// Results RandomImage and TopImage pick from by default, and most searches whose results are kept CachePicks
+const (
+    defaultPickTop = 20
+    pickCacheSize  = 256
+)
+
+// Configures how RandomImage and TopImage pick an image.
+type PickOption func(*pickOptions)
+
+type pickOptions struct {
+    top       int
+    arguments []string
+    cache     bool
+    ttl       time.Duration
+    validate  bool
+}
+
+// Picks from the n most relevant results instead of the 20 most relevant. Below 1 picks from every result found.
+func FromTop(n int) PickOption {
+    return func(o *pickOptions) {
+        o.top = n
+    }
+}
+
+// Searches along with the given arguments, like Color.Red.
+func PickArguments(arguments ...string) PickOption {
+    return func(o *pickOptions) {
+        o.arguments = append(o.arguments, arguments...)
+    }
+}
+
+// Keeps the results of the search for ttl, so picking for the same query again doesn't search again, like a bot answering the same command repeatedly.
+// The results are kept by the Client, and shared by its copies made With. A ttl of 0 keeps them until they are evicted by the results of other queries.
+func CachePicks(ttl time.Duration) PickOption {
+    return func(o *pickOptions) {
+        o.cache, o.ttl = true, ttl
+    }
+}
+
+// Only picks images whose url is alive and serves an image, checked with a HEAD request, or a GET request for the first byte if the server doesn't support HEAD.
+// Dead images are passed over for the next candidate, so a pick only fails if none of the results are alive.
+func Validated() PickOption {
+    return func(o *pickOptions) {
+        o.validate = true
+    }
+}
+
+// Returns a random image of the most relevant results for the query, see Client.RandomImage.
+func RandomImage(ctx context.Context, query string, options ...PickOption) (Image, error) {
+    return defaultClient.RandomImage(ctx, query, options...)
+}
+
+// Returns a random image of the 20 most relevant results for the query, or of as many as FromTop says, for the "send me a picture of X" commands of chat bots.
+// Fails with an error IsNoResults reports if the search has no results that can be shown, which are all but blob: urls, or only those that are alive if Validated.
+func (c *Client) RandomImage(ctx context.Context, query string, options ...PickOption) (Image, error) {
+    random := rand.New(rand.NewSource(time.Now().UnixNano()))
+    return c.pick(ctx, query, options, random.Perm)
+}
+
+// Returns the most relevant result for the query that can be shown, see Client.TopImage.
+func TopImage(ctx context.Context, query string, options ...PickOption) (Image, error) {
+    return defaultClient.TopImage(ctx, query, options...)
+}
+
+// Returns the most relevant result for the query that can be shown, which is any but a blob: url, or only one that is alive if Validated,
+// for "show me a picture of X" features that don't need slices and limits. Only the 20 most relevant results are considered unless FromTop says otherwise.
+// Fails with an error IsNoResults reports if none of them can be shown.
+func (c *Client) TopImage(ctx context.Context, query string, options ...PickOption) (Image, error) {
+    return c.pick(ctx, query, options, func(n int) []int {
+        order := make([]int, n)
+        for i := range order {
+            order[i] = i
+        }
+        return order
+    })
+}
+
+// Searches for the query and returns the first image that can be shown of the results in the order returned by order, given the number of results.
+func (c *Client) pick(ctx context.Context, query string, options []PickOption, order func(n int) []int) (Image, error) {
+    pick := pickOptions{top: defaultPickTop}
+    for _, option := range options {
+        option(&pick)
+    }
+    if pick.top < 0 {
+        pick.top = 0
+    }
+
+    images, err := c.pickFrom(ctx, query, pick)
+    if err != nil {
+        return Image{}, err
+    }
+    for _, i := range order(len(images)) {
+        if c.showable(ctx, images[i], pick.validate) {
+            return images[i], nil
+        }
+        if err := ctx.Err(); err != nil {
+            return Image{}, err
+        }
+    }
+    return Image{}, errNoResults
+}
+
+// Checks if an image can be shown: its url isn't a blob: url, and if validate is set, it is alive and serves an image.
+func (c *Client) showable(ctx context.Context, image Image, validate bool) bool {
+    if image.Url == "" || strings.HasPrefix(image.Url, "blob:") {
+        return false
+    }
+    if !validate || strings.HasPrefix(image.Url, "data:") {
+        return true
+    }
+    status := c.validateUrl(ctx, image.Url)
+    return status.Alive && (status.ContentType == "" || strings.HasPrefix(status.ContentType, "image/"))
+}
+
+// Returns the results of the search a pick is made from, from the picks cache of the Client if they are kept there.
+func (c *Client) pickFrom(ctx context.Context, query string, pick pickOptions) ([]Image, error) {
+    // Google's cache keys leave out the limit, which decides the images picked from
+    key := strconv.Itoa(pick.top) + " " + c.cacheKey(query, pick.top, pick.arguments)
+    if pick.cache {
+        if results, ok := c.picks.get(key); ok {
+            return results.Images, nil
+        }
+    }
+
+    images, err := c.Images(ctx, query, pick.top, pick.arguments...)
+    if err != nil {
+        return nil, err
+    }
+    if pick.cache {
+        c.picks.putFor(key, Results{Images: images}, pick.ttl)
+    }
+    return images, nil
+}
@@ -0,0 +1,104 @@
+package imagesearch
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+// The phases of the pipeline every image fetched by a Client goes through, in the order they run.
+// Built in steps run first in their phase: the format of the image is detected as the first validation, and in downloads the file is saved as the first storage.
+const (
+    // Checks that the image is usable, like that it decodes or is large enough
+    PhaseValidate = "validate"
+
+    // Decides whether the image is kept, like content policies, WithFilter, and the built-in checks like WithoutWatermarks, WithFaces, and WithColorCheck.
+    // Rejected images count as failed downloads with an error IsFiltered reports, and so do images a stage of this phase fails on, so a broken classifier never lets images through.
+    PhaseFilter = "filter"
+
+    // Changes the image before it is stored, like resizing it or converting its format
+    PhaseTransform = "transform"
+
+    // Runs once the image is saved, with the Path of its file, like uploading it elsewhere. Only downloads have this phase, since fetching an image doesn't store it.
+    PhaseStore = "store"
+)
+
+// The order the phases run in
+var phases = []string{PhaseValidate, PhaseFilter, PhaseTransform, PhaseStore}
+
+// A step of the pipeline of a Client, added WithStage.
+type PipelineStage struct {
+    // Name of the stage, which prefixes the errors of the images it rejects
+    Name string
+
+    // The phase the stage runs in, one of PhaseValidate, PhaseFilter, PhaseTransform, and PhaseStore
+    Phase string
+
+    // Processes an image, and may change its Data and Format in place, like transforms do. The file of a download is saved with the extension of its Format.
+    // Returning an error rejects the image, which counts as a failed download. Errors of filter stages are reported by IsFiltered.
+    Process func(ctx context.Context, img *ImageData) error
+}
+
+// Adds a stage to the pipeline every image fetched by the Client goes through: validation, filtering, transformation, and storage, in that order.
+// Stages of the same phase run in the order they were added, so steps like resizing, format conversion, deduplication, and NSFW filtering compose in a predictable order.
+// An image is rejected as soon as a stage fails, and the later stages never see it. Stages of an unknown phase are ignored.
+//
+// Images that a Manifest shows are unchanged since they were last downloaded aren't fetched, so they don't go through the pipeline again.
+func WithStage(stage PipelineStage) Option {
+    return func(c *Client) {
+        // Insert after the last stage of the same or an earlier phase
+        order := phaseOrder(stage.Phase)
+        if order < 0 {
+            return
+        }
+        i := len(c.stages)
+        for i > 0 && phaseOrder(c.stages[i-1].Phase) > order {
+            i--
+        }
+        stages := make([]PipelineStage, 0, len(c.stages)+1)
+        stages = append(stages, c.stages[:i]...)
+        stages = append(stages, stage)
+        c.stages = append(stages, c.stages[i:]...)
+    }
+}
+
+// Returns the position of the phase in phases, or -1 if it isn't one of them.
+func phaseOrder(phase string) int {
+    for i, known := range phases {
+        if phase == known {
+            return i
+        }
+    }
+    return -1
+}
+
+// Detects the format of the image and runs the stages of the Client before storage on it, stopping at the first one that rejects it.
+func (c *Client) process(ctx context.Context, img *ImageData) error {
+    format, err := imageFormat(img.Data)
+    if err != nil {
+        return err
+    }
+    img.Format = format
+    return c.runStages(ctx, img, PhaseValidate, PhaseTransform)
+}
+
+// Runs the stages of the Client in the phases from first to last on the image, stopping at the first one that rejects it.
+func (c *Client) runStages(ctx context.Context, img *ImageData, first, last string) error {
+    from, to := phaseOrder(first), phaseOrder(last)
+    for _, stage := range c.stages {
+        if order := phaseOrder(stage.Phase); order < from || order > to {
+            continue
+        }
+        err := stage.Process(ctx, img)
+        switch {
+        case err == nil:
+        case errors.Is(err, errFiltered):
+            return err
+        case stage.Phase == PhaseFilter:
+            return fmt.Errorf("%w: %s: %v", errFiltered, stage.Name, err)
+        default:
+            return fmt.Errorf("%s: %w", stage.Name, err)
+        }
+    }
+    return nil
+}
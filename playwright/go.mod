@@ -0,0 +1,16 @@
+module github.com/commonkestrel/imagesearch/playwright
+
+go 1.22
+
+require (
+	github.com/commonkestrel/imagesearch v0.0.0
+	github.com/playwright-community/playwright-go v0.5700.1
+)
+
+require (
+	github.com/deckarep/golang-set/v2 v2.8.0 // indirect
+	github.com/go-jose/go-jose/v3 v3.0.4 // indirect
+	github.com/go-stack/stack v1.8.1 // indirect
+)
+
+replace github.com/commonkestrel/imagesearch => ../
@@ -0,0 +1,128 @@
+// Package playwright implements an imagesearch.Browser with playwright-go, for environments where chromedp or Chrome isn't available.
+// Playwright can drive Chromium, Firefox, or WebKit, and its browsers can be installed with playwright.Install.
+// It is a module of its own, so that only users of the headless engine depend on Playwright. For example:
+//
+//	browser, err := playwright.NewBrowser("firefox")
+//	if err != nil {
+//	    return err
+//	}
+//	defer browser.Close()
+//	client := imagesearch.NewClient(imagesearch.WithEngine(imagesearch.Headless(browser, 10)))
+//	images, err := client.Images(context.Background(), "example", 500)
+package playwright
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/commonkestrel/imagesearch"
+    "github.com/playwright-community/playwright-go"
+)
+
+// A Browser loads pages in a headless browser launched by Playwright. Each Load opens a new page, so a Browser is safe for concurrent use.
+type Browser struct {
+    pw      *playwright.Playwright
+    browser playwright.Browser
+
+    // How long to wait for more results to load after each scroll
+    ScrollDelay time.Duration
+}
+
+var _ imagesearch.Browser = (*Browser)(nil)
+
+// Starts Playwright and launches a headless browser, which is one of "chromium", "firefox", or "webkit". Close the Browser once done with it.
+func NewBrowser(name string) (*Browser, error) {
+    pw, err := playwright.Run()
+    if err != nil {
+        return nil, err
+    }
+
+    var browserType playwright.BrowserType
+    switch name {
+    case "chromium":
+        browserType = pw.Chromium
+    case "firefox":
+        browserType = pw.Firefox
+    case "webkit":
+        browserType = pw.WebKit
+    default:
+        pw.Stop()
+        return nil, fmt.Errorf("unknown browser %q, expected chromium, firefox, or webkit", name)
+    }
+
+    browser, err := browserType.Launch()
+    if err != nil {
+        pw.Stop()
+        return nil, err
+    }
+    return &Browser{pw: pw, browser: browser, ScrollDelay: time.Second}, nil
+}
+
+// Closes the browser and stops Playwright.
+func (b *Browser) Close() error {
+    if err := b.browser.Close(); err != nil {
+        b.pw.Stop()
+        return err
+    }
+    return b.pw.Stop()
+}
+
+// Loads the page at url in a new page and scrolls to its bottom up to scrolls times, waiting ScrollDelay for more results after each scroll.
+// Scrolling stops early once the page stops growing, or the context is done. Returns the rendered html along with the bodies of the batchexecute responses the page fetched.
+func (b *Browser) Load(ctx context.Context, url string, scrolls int) (string, []string, error) {
+    page, err := b.browser.NewPage()
+    if err != nil {
+        return "", nil, err
+    }
+    defer page.Close()
+
+    var mu sync.Mutex
+    var batches []playwright.Response
+    page.OnResponse(func(response playwright.Response) {
+        if strings.Contains(response.URL(), "/batchexecute") {
+            mu.Lock()
+            batches = append(batches, response)
+            mu.Unlock()
+        }
+    })
+
+    if _, err = page.Goto(url); err != nil {
+        return "", nil, err
+    }
+
+    var previous interface{}
+    for i := 0; i < scrolls; i++ {
+        if err = ctx.Err(); err != nil {
+            return "", nil, err
+        }
+        height, err := page.Evaluate(`() => { window.scrollTo(0, document.body.scrollHeight); return document.body.scrollHeight }`)
+        if err != nil {
+            return "", nil, err
+        }
+        if i > 0 && height == previous {
+            break
+        }
+        previous = height
+        page.WaitForTimeout(float64(b.ScrollDelay / time.Millisecond))
+    }
+
+    html, err := page.Content()
+    if err != nil {
+        return "", nil, err
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    var responses []string
+    for _, response := range batches {
+        body, err := response.Body()
+        if err != nil {
+            continue
+        }
+        responses = append(responses, string(body))
+    }
+    return html, responses, nil
+}
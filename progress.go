@@ -0,0 +1,99 @@
+package imagesearch
+
+import "io"
+
+// The progress of a download, passed to the hook of a Client created WithProgress.
+type Progress struct {
+    // The query being downloaded, empty for DownloadImage
+    Query string
+
+    // Url of the image the progress is about
+    Url string
+
+    // Bytes of the image read so far, and its size if the server sent one, -1 otherwise
+    Read int64
+    Size int64
+
+    // Whether the image is done, which means it was either saved to Path or failed with Err
+    Done bool
+    Path string
+    Err  error
+
+    // Images downloaded and failed so far, including this one once it is done
+    Downloaded int
+    Failed     int
+
+    // The limit of the download, 0 for every image found
+    Limit int
+}
+
+// Calls hook with the Progress of downloads made by the Client as they happen: while each image is read, and once it is done.
+// The hook is called from the goroutine downloading, so it should return quickly. Use Client.With to report the progress of a single download.
+func WithProgress(hook func(Progress)) Option {
+    return func(c *Client) {
+        c.progress = hook
+    }
+}
+
+// Tracks the progress of a download for the Client's progress hook. A nil tracker tracks nothing.
+type tracker struct {
+    hook       func(Progress)
+    query      string
+    limit      int
+    downloaded int
+    failed     int
+}
+
+// Returns a tracker for downloading the query, or nil if the Client has no progress hook.
+func (c *Client) tracker(query string, limit int) *tracker {
+    if c.progress == nil {
+        return nil
+    }
+    return &tracker{hook: c.progress, query: query, limit: limit}
+}
+
+func (t *tracker) report(progress Progress) {
+    progress.Query = t.query
+    progress.Downloaded = t.downloaded
+    progress.Failed = t.failed
+    progress.Limit = t.limit
+    t.hook(progress)
+}
+
+// Wraps the body of an image so every read is reported.
+func (t *tracker) reader(url string, body io.Reader, size int64) io.Reader {
+    if t == nil {
+        return body
+    }
+    return &progressReader{tracker: t, url: url, body: body, size: size}
+}
+
+// Reports that the image at url is done, saved to path unless err is not nil.
+func (t *tracker) done(url, path string, err error) {
+    if t == nil {
+        return
+    }
+    if err != nil {
+        t.failed++
+    } else {
+        t.downloaded++
+    }
+    t.report(Progress{Url: url, Read: -1, Size: -1, Done: true, Path: path, Err: err})
+}
+
+type progressReader struct {
+    tracker *tracker
+    url     string
+    body    io.Reader
+    read    int64
+    size    int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+    n, err := r.body.Read(p)
+    if n > 0 {
+        r.read += int64(n)
+        r.tracker.report(Progress{Url: r.url, Read: r.read, Size: r.size})
+    }
+    return n, err
+}
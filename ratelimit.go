@@ -0,0 +1,58 @@
+package imagesearch
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// A token bucket allowing rate requests per second on average, with bursts of up to burst requests. Safe for concurrent use.
+type limiter struct {
+    mu     sync.Mutex
+    rate   float64
+    burst  float64
+    tokens float64
+    last   time.Time
+}
+
+// Creates a limiter with a full bucket. A burst below 1 allows a single request at a time.
+func newLimiter(rate float64, burst int) *limiter {
+    if burst < 1 {
+        burst = 1
+    }
+    return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Waits until a request is allowed, or the context is done. A nil limiter allows every request right away.
+func (l *limiter) wait(ctx context.Context) error {
+    if l == nil || l.rate <= 0 {
+        return ctx.Err()
+    }
+
+    l.mu.Lock()
+    now := time.Now()
+    l.tokens += now.Sub(l.last).Seconds() * l.rate
+    if l.tokens > l.burst {
+        l.tokens = l.burst
+    }
+    l.last = now
+    // Taking the token right away reserves it, even if it has to be waited for
+    l.tokens--
+    delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+    l.mu.Unlock()
+
+    if delay <= 0 {
+        return ctx.Err()
+    }
+    timer := time.NewTimer(delay)
+    defer timer.Stop()
+    select {
+    case <-timer.C:
+        return nil
+    case <-ctx.Done():
+        l.mu.Lock()
+        l.tokens++
+        l.mu.Unlock()
+        return ctx.Err()
+    }
+}
@@ -0,0 +1,112 @@
+package imagesearch
+
+import (
+    "context"
+    "strings"
+)
+
+// Settings of Refresh.
+type RefreshOptions struct {
+    // Most urls checked at once. Below 1 checks one url at a time.
+    Concurrency int
+
+    // The query and arguments the images were found with, which are searched again to replace the dead images so the set keeps its size.
+    // Without a query, dead images are only dropped.
+    Query     string
+    Arguments []string
+}
+
+// Checks which of the images are still alive and drops the dead ones, replacing them with new results if a query is given. See Client.Refresh.
+func Refresh(ctx context.Context, images []Image, options RefreshOptions) ([]Image, error) {
+    return defaultClient.Refresh(ctx, images, options)
+}
+
+// Checks which of the images are still alive and drops the dead ones, for apps that keep results for days while their urls go stale.
+// Urls are checked like ValidateUrls checks them, data: urls are always alive, and blob: urls never are.
+//
+// If options has a query, it is searched again, and the dead images are replaced in place by the most relevant results that are alive and not already in the set,
+// so the order of the surviving images is kept. Dead images are dropped if there aren't enough replacements. If the search fails, the images that are alive are returned along with the error.
+func (c *Client) Refresh(ctx context.Context, images []Image, options RefreshOptions) ([]Image, error) {
+    alive := c.aliveImages(ctx, images, options.Concurrency)
+    dead := 0
+    for _, ok := range alive {
+        if !ok {
+            dead++
+        }
+    }
+
+    var replacements []Image
+    var err error
+    if dead > 0 && options.Query != "" {
+        replacements, err = c.replacements(ctx, images, dead, options)
+    }
+
+    refreshed := make([]Image, 0, len(images))
+    for i, image := range images {
+        if alive[i] {
+            refreshed = append(refreshed, image)
+        } else if len(replacements) > 0 {
+            refreshed = append(refreshed, replacements[0])
+            replacements = replacements[1:]
+        }
+    }
+    if err == nil {
+        err = ctx.Err()
+    }
+    return refreshed, err
+}
+
+// Searches for the query of options again, and returns up to count of the results that are alive and whose urls aren't those of images.
+func (c *Client) replacements(ctx context.Context, images []Image, count int, options RefreshOptions) ([]Image, error) {
+    results, err := c.Images(ctx, options.Query, 0, options.Arguments...)
+    if err != nil {
+        return nil, err
+    }
+
+    known := map[string]bool{}
+    for _, image := range images {
+        known[dedupeKey(image.Url)] = true
+    }
+    var candidates []Image
+    for _, result := range results {
+        if !known[dedupeKey(result.Url)] {
+            candidates = append(candidates, result)
+        }
+    }
+
+    // Check the candidates in batches of the number still missing, so no more urls are checked than needed when most are alive
+    var found []Image
+    for len(candidates) > 0 && len(found) < count && ctx.Err() == nil {
+        batch := candidates
+        if len(batch) > count-len(found) {
+            batch = batch[:count-len(found)]
+        }
+        candidates = candidates[len(batch):]
+        for i, ok := range c.aliveImages(ctx, batch, options.Concurrency) {
+            if ok {
+                found = append(found, batch[i])
+            }
+        }
+    }
+    return found, nil
+}
+
+// Checks which of the images are alive, running up to concurrency checks at once.
+func (c *Client) aliveImages(ctx context.Context, images []Image, concurrency int) []bool {
+    alive := make([]bool, len(images))
+    var urls []string
+    var indices []int
+    for i, image := range images {
+        switch {
+        case strings.HasPrefix(image.Url, "data:"):
+            alive[i] = true
+        case image.Url != "" && !strings.HasPrefix(image.Url, "blob:"):
+            urls = append(urls, image.Url)
+            indices = append(indices, i)
+        }
+    }
+    for i, status := range c.ValidateUrls(ctx, urls, concurrency) {
+        alive[indices[i]] = status.Alive
+    }
+    return alive
+}
@@ -0,0 +1,157 @@
+package imagesearch
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "mime/multipart"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+const (
+    // Google's endpoint for searching by an uploaded image, which redirects to the results of the reverse search
+    reverseSearchUrl = "https://www.google.com/searchbyimage/upload"
+    // Google's endpoint for searching by the url of an image
+    similarSearchUrl = "https://www.google.com/searchbyimage?image_url="
+    // Google's images results page opened on a result by its document id, which lists the images related to it
+    similarIdUrl = "https://www.google.com/search?tbm=isch&q=&tbnid="
+)
+
+var errNoImage = errors.New("the image has neither a url nor thumbnail data to search with")
+
+// Searches Google for images matching the image file at path, like dragging the file into Google Images, and returns the matches.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+func SearchByImageFile(ctx context.Context, path string, limit int) ([]Image, error) {
+    return defaultClient.SearchByImageFile(ctx, path, limit)
+}
+
+// Searches Google for images matching the image read from image, and returns the matches.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+func SearchByImage(ctx context.Context, image io.Reader, limit int) ([]Image, error) {
+    return defaultClient.SearchByImage(ctx, image, limit)
+}
+
+// Searches Google for images matching the image file at path, like dragging the file into Google Images, and returns the matches.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+func (c *Client) SearchByImageFile(ctx context.Context, path string, limit int) ([]Image, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return []Image{}, err
+    }
+    defer file.Close()
+
+    return c.searchByImage(ctx, file, filepath.Base(path), limit)
+}
+
+// Searches Google for images matching the image read from image, and returns the matches.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found.
+func (c *Client) SearchByImage(ctx context.Context, image io.Reader, limit int) ([]Image, error) {
+    return c.searchByImage(ctx, image, "image", limit)
+}
+
+// Uploads the image as the multipart form Google's search by image page sends, and unpacks the results page it redirects to.
+// Reverse searches always go to Google, whatever the Client's engine is, and are never cached.
+func (c *Client) searchByImage(ctx context.Context, image io.Reader, name string, limit int) ([]Image, error) {
+    var body bytes.Buffer
+    form := multipart.NewWriter(&body)
+    part, err := form.CreateFormFile("encoded_image", name)
+    if err != nil {
+        return []Image{}, err
+    }
+    if _, err = io.Copy(part, image); err != nil {
+        return []Image{}, err
+    }
+    if err = form.Close(); err != nil {
+        return []Image{}, err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, "POST", reverseSearchUrl, &body)
+    if err != nil {
+        return []Image{}, err
+    }
+    req.Header.Set("User-Agent", userAgent)
+    req.Header.Set("Content-Type", form.FormDataContentType())
+    resp, err := c.do(req)
+    if err != nil {
+        return []Image{}, err
+    }
+    defer resp.Body.Close()
+
+    var page strings.Builder
+    if _, err = io.Copy(&page, resp.Body); err != nil {
+        return []Image{}, err
+    }
+
+    return c.unpackMatches(page.String(), resp.Request.URL.String(), limit)
+}
+
+// Unpacks the images of a reverse search results page, which share the structure of the regular results.
+func (c *Client) unpackMatches(page, final string, limit int) ([]Image, error) {
+    images, diagnostics := extractWith(page, strategies)
+    if diagnostics.Failed != StageNone {
+        if c.failureHook != nil {
+            c.failureHook(diagnostics.Fingerprint())
+        }
+        return []Image{}, newUnpackError("", final, page, diagnostics)
+    }
+    for i := range images {
+        images[i].Engine = "google"
+    }
+    return c.filter(images, limit), nil
+}
+
+// Returns Google's visually similar images to an image from a previous search, for "more like this" features.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found. The image itself is left out of the results.
+func Similar(ctx context.Context, image Image, limit int) ([]Image, error) {
+    return defaultClient.Similar(ctx, image, limit)
+}
+
+// Returns Google's visually similar images to an image from a previous search, for "more like this" features.
+// The amount of images does not exceed the limit unless the limit is 0, in which case it will return all images found. The image itself is left out of the results.
+//
+// Google is asked for the images related to the image's Id if it is one of Google's document ids, since it names the very result that was found.
+// Otherwise Google is asked for images similar to the image's Url, and images with an inline data: url, or without a Url, are uploaded from their ThumbnailData instead.
+func (c *Client) Similar(ctx context.Context, image Image, limit int) ([]Image, error) {
+    var images []Image
+    var err error
+    // The ids other engines give their images mean nothing to Google
+    if image.Id != "" && (image.Engine == "" || image.Engine == "google") {
+        var page, final string
+        page, final, err = c.getPage(ctx, similarIdUrl+url.QueryEscape(image.Id), userAgent)
+        if err != nil {
+            return []Image{}, err
+        }
+        images, err = c.unpackMatches(page, final, 0)
+    } else if strings.HasPrefix(image.Url, "http") {
+        var page, final string
+        page, final, err = c.getPage(ctx, similarSearchUrl+url.QueryEscape(image.Url), userAgent)
+        if err != nil {
+            return []Image{}, err
+        }
+        images, err = c.unpackMatches(page, final, 0)
+    } else if len(image.ThumbnailData) > 0 {
+        images, err = c.searchByImage(ctx, bytes.NewReader(image.ThumbnailData), "image", 0)
+    } else {
+        return []Image{}, errNoImage
+    }
+    if err != nil {
+        return []Image{}, err
+    }
+
+    key := dedupeKey(image.Url)
+    similar := images[:0]
+    for _, match := range images {
+        if dedupeKey(match.Url) != key && (image.Id == "" || match.Id != image.Id) {
+            similar = append(similar, match)
+        }
+    }
+    if len(similar) > limit && limit > 0 {
+        similar = similar[:limit]
+    }
+    return similar, nil
+}
@@ -0,0 +1,111 @@
+package imagesearch
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// Identifiable markers of the structure of a results page. Comparing the schema of a failing page with that of a working one usually shows what Google changed.
+type Schema struct {
+    // Key of the AF_initDataCallback holding the results, for example "ds:1"
+    CallbackKey string `json:"callback_key"`
+
+    // Number of AF_initDataCallback blocks on the page
+    Callbacks int `json:"callbacks"`
+
+    // Path from the root of the payload to the first result envelope, for example "[56][1][0][0][1][0][0][0]"
+    EnvelopePath string `json:"envelope_path"`
+
+    // Name of the strategy that extracted the images, empty if none did
+    Strategy string `json:"strategy"`
+}
+
+// Detects the schema of an already fetched results page.
+func DetectSchema(page string) Schema {
+    return DiagnosePage(page).Schema
+}
+
+// An anonymized description of the structure of a results page.
+// It contains nothing from the content of the page, like the query or any urls, only the types and sizes of the payload, so it is safe to log and share.
+type Fingerprint struct {
+    Schema Schema `json:"schema"`
+
+    // Stage that failed in the last strategy, StageNone if the page was unpacked
+    Failed Stage `json:"failed"`
+
+    // Skeleton of the payload, the types and lengths of its values a few levels deep
+    Shape string `json:"shape"`
+
+    // Short hash of Shape, to quickly tell whether two pages share the same structure
+    Hash string `json:"hash"`
+}
+
+// Returns the anonymized Fingerprint of the page the diagnostics describe.
+func (d Diagnostics) Fingerprint() Fingerprint {
+    sum := sha256.Sum256([]byte(d.shape))
+    return Fingerprint{
+        Schema: d.Schema,
+        Failed: d.Failed,
+        Shape:  d.shape,
+        Hash:   hex.EncodeToString(sum[:8]),
+    }
+}
+
+// How many levels of the payload are included in its skeleton.
+const shapeDepth = 3
+
+// Describes the structure of a decoded json node without any of its content.
+// Arrays deeper than depth are only described by their length, and strings by their type, so no values leak into the skeleton.
+func skeleton(node interface{}, depth int) string {
+    switch value := node.(type) {
+    case nil:
+        return "n"
+    case string:
+        return "s"
+    case bool:
+        return "b"
+    case []interface{}:
+        if depth == 0 {
+            return fmt.Sprintf("[%d]", len(value))
+        }
+        children := make([]string, len(value))
+        for i, child := range value {
+            children[i] = skeleton(child, depth-1)
+        }
+        return "[" + strings.Join(children, ",") + "]"
+    case map[string]interface{}:
+        if depth == 0 {
+            return fmt.Sprintf("{%d}", len(value))
+        }
+        // Object keys are numeric identifiers like 444383007, which are part of the structure rather than the content.
+        keys := make([]string, 0, len(value))
+        for key := range value {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        children := make([]string, len(keys))
+        for i, key := range keys {
+            children[i] = key + ":" + skeleton(value[key], depth-1)
+        }
+        return "{" + strings.Join(children, ",") + "}"
+    default:
+        return "d"
+    }
+}
+
+// Formats a path of array indexes (int) and object keys (string) like [56][1]["2003"].
+func formatPath(path []interface{}) string {
+    var b strings.Builder
+    for _, step := range path {
+        switch key := step.(type) {
+        case int:
+            fmt.Fprintf(&b, "[%d]", key)
+        case string:
+            fmt.Fprintf(&b, "[%q]", key)
+        }
+    }
+    return b.String()
+}
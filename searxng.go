@@ -0,0 +1,101 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// Returns a constructor of the engine for a SearxNG instance, to be passed into WithEngine. instance is the base url of the instance, like "https://searx.example.com".
+// SearxNG aggregates the image results of the engines the instance enables, which lets self-hosters search on their own infrastructure. The instance must have the json format enabled in its settings.
+// Of the arguments, only Time is supported, the rest are ignored. Searches with a limit request more pages until it is reached.
+func SearxNG(instance string) func(*Client) Engine {
+    return func(c *Client) Engine {
+        return &searxNG{client: c, instance: strings.TrimSuffix(instance, "/")}
+    }
+}
+
+// Searches the images category of a SearxNG instance through its json format.
+type searxNG struct {
+    client   *Client
+    instance string
+}
+
+func (s *searxNG) Name() string {
+    return "searxng"
+}
+
+// A result of the images category.
+type searxNGResult struct {
+    // Page the image was found on
+    Url     string `json:"url"`
+    ImgSrc  string `json:"img_src"`
+    Title   string `json:"title"`
+    Content string `json:"content"`
+    // Dimensions as "width x height", if the engine that found the image reports them
+    Resolution string `json:"resolution"`
+}
+
+// The most pages searched for a single limit, as SearxNG instances rate limit clients
+const searxNGMaxPages = 10
+
+func (s *searxNG) Search(ctx context.Context, query Query) ([]Image, error) {
+    return collectPages(ctx, s, query)
+}
+
+func (s *searxNG) SearchPages(ctx context.Context, query Query, found func(images []Image) error) error {
+    var total int
+    for page := 1; page <= searxNGMaxPages; page++ {
+        var response struct {
+            Results []json.RawMessage `json:"results"`
+        }
+        if err := s.client.getJSON(ctx, s.buildUrl(query, page), nil, &response); err != nil {
+            return err
+        }
+
+        images := []Image{}
+        for _, raw := range response.Results {
+            var result searxNGResult
+            if err := json.Unmarshal(raw, &result); err != nil || result.ImgSrc == "" {
+                continue
+            }
+            image := Image{Url: result.ImgSrc, Source: result.Url, Title: result.Title, Description: result.Content, Raw: raw}
+            fmt.Sscanf(strings.ReplaceAll(result.Resolution, " ", ""), "%dx%d", &image.Width, &image.Height)
+            images = append(images, image)
+        }
+        if err := found(images); err != nil {
+            return err
+        }
+        total += len(images)
+        if query.Limit <= 0 || total >= query.Limit || len(response.Results) == 0 {
+            break
+        }
+    }
+    return nil
+}
+
+// The time ranges of SearxNG equivalent to the Time arguments
+var searxNGTimeRanges = map[string]string{
+    Time.PastDay:   "day",
+    Time.PastWeek:  "week",
+    Time.PastMonth: "month",
+    Time.PastYear:  "year",
+}
+
+func (s *searxNG) buildUrl(query Query, page int) string {
+    values := url.Values{
+        "q":          {query.Text},
+        "categories": {"images"},
+        "format":     {"json"},
+        "pageno":     {strconv.Itoa(page)},
+    }
+    for _, argument := range query.Arguments {
+        if timeRange, ok := searxNGTimeRanges[argument]; ok {
+            values.Set("time_range", timeRange)
+        }
+    }
+    return s.instance + "/search?" + values.Encode()
+}
@@ -0,0 +1,95 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// Returns a constructor of the SerpAPI engine, to be passed into WithEngine, which searches Google Images through SerpAPI's google_images engine with the given apiKey.
+// SerpAPI takes care of keeping up with Google's results page, so this is the most stable way to get Google's results if you already pay for it.
+// Every argument is supported, since they are passed on to Google as they are. Each request returns up to 100 images, and searches with a higher limit request more pages.
+func SerpAPI(apiKey string) func(*Client) Engine {
+    return func(c *Client) Engine {
+        return &serpAPI{client: c, apiKey: apiKey}
+    }
+}
+
+// Searches Google Images through SerpAPI.
+type serpAPI struct {
+    client *Client
+    apiKey string
+}
+
+func (s *serpAPI) Name() string {
+    return "serpapi"
+}
+
+// A result in the images_results of SerpAPI's google_images engine.
+type serpAPIResult struct {
+    Position int    `json:"position"`
+    Original string `json:"original"`
+    Width    int    `json:"original_width"`
+    Height   int    `json:"original_height"`
+    Title    string `json:"title"`
+    // Page the image was found on
+    Link string `json:"link"`
+}
+
+func (s *serpAPI) Search(ctx context.Context, query Query) ([]Image, error) {
+    return collectPages(ctx, s, query)
+}
+
+func (s *serpAPI) SearchPages(ctx context.Context, query Query, found func(images []Image) error) error {
+    var total int
+    for page := 0; ; page++ {
+        var response struct {
+            Error         string            `json:"error"`
+            ImagesResults []json.RawMessage `json:"images_results"`
+        }
+        if err := s.client.getJSON(ctx, s.buildUrl(query, page), nil, &response); err != nil {
+            return err
+        }
+        // SerpAPI reports searches without results as an error
+        if response.Error != "" && total == 0 && !strings.Contains(response.Error, "hasn't returned any results") {
+            return errors.New("serpapi: " + response.Error)
+        }
+
+        images := []Image{}
+        for _, raw := range response.ImagesResults {
+            var result serpAPIResult
+            if err := json.Unmarshal(raw, &result); err != nil || result.Original == "" {
+                continue
+            }
+            images = append(images, Image{Url: result.Original, Source: result.Link, Title: result.Title, Rank: result.Position, Width: result.Width, Height: result.Height, Raw: raw})
+        }
+        if err := found(images); err != nil {
+            return err
+        }
+        total += len(images)
+        if query.Limit <= 0 || total >= query.Limit || len(response.ImagesResults) == 0 {
+            break
+        }
+    }
+    return nil
+}
+
+func (s *serpAPI) buildUrl(query Query, page int) string {
+    values := url.Values{
+        "engine":  {"google_images"},
+        "api_key": {s.apiKey},
+        "q":       {query.Text},
+        "ijn":     {strconv.Itoa(page)},
+    }
+    filters, parameters := splitArguments(query.Arguments)
+    if len(filters) > 0 {
+        values.Set("tbs", "ic:specific,"+strings.Join(filters, ","))
+    }
+    for key := range parameters {
+        values.Set(key, parameters.Get(key))
+    }
+    return "https://serpapi.com/search.json?" + values.Encode()
+}
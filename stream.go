@@ -0,0 +1,124 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "sync"
+)
+
+// Returned by the page function of a PagingEngine once StreamImages has sent the limit, to stop requesting pages
+var errStreamDone = errors.New("stream done")
+
+// Searches for the query along with the given arguments and calls send with every image as soon as it is found. See Client.StreamImages.
+func StreamImages(ctx context.Context, query string, limit int, send func(img Image) error, arguments ...string) error {
+    return defaultClient.StreamImages(ctx, query, limit, send, arguments...)
+}
+
+// Searches for the query along with the given arguments like Images, but calls send with every image in order as soon as it is found, instead of returning them all at once,
+// so servers can stream results to their clients. Stops at the first error send returns, and returns it.
+// With a PagingEngine the images of every page are sent as soon as the engine has the page, and no more pages are requested once the limit is sent.
+// Other engines, results from the cache, and Clients created WithDomainDiversity, which orders the images only once all of them are found, send every image once the search is done.
+func (c *Client) StreamImages(ctx context.Context, query string, limit int, send func(img Image) error, arguments ...string) error {
+    engine, paging := c.engine.(PagingEngine)
+    searchArguments := arguments
+    if c.commercialSafe {
+        searchArguments = commercialArguments(arguments)
+    }
+    if _, cached := c.cached(query, limit, searchArguments); !paging || cached || c.diverse {
+        images, err := c.Images(ctx, query, limit, arguments...)
+        if err != nil {
+            return err
+        }
+        for _, image := range images {
+            if err := send(image); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+
+    // Search as far as Search widens, since filtering may remove images, and stop once the limit is sent
+    searched := limit * maxWidening
+    var found []Image
+    var skipped, sent int
+    diagnostics := Diagnostics{strategy: c.engine.Name()}
+    err := engine.SearchPages(ctx, Query{Text: query, Arguments: searchArguments, Limit: searched}, func(page []Image) error {
+        count := len(page)
+        found = append(found, c.normalizeEngineImages(page, skipped, &diagnostics)...)
+        skipped += count
+
+        // Filtering more images never changes those it kept before, so the images already sent stay the first ones
+        images := c.filter(found, limit)
+        for _, image := range images[sent:] {
+            if err := send(image); err != nil {
+                return err
+            }
+        }
+        sent = len(images)
+        if limit > 0 && sent >= limit {
+            return errStreamDone
+        }
+        return nil
+    })
+    if errors.Is(err, errStreamDone) {
+        return nil
+    }
+    return err
+}
+
+// Writes search results and download outcomes as they happen as newline delimited JSON, a JSON object per line, for piping into log processors. Created by StreamNDJSON.
+// Every line has an "event": "result" for a search result, with the image as "image", encoded like Image.MarshalJSON,
+// and "download" for an image that is done downloading, with the "query", "url", and "limit" of its Progress, the "path" it was saved to or the "error" it failed with, and the "downloaded" and "failed" counts so far.
+// Lines are written whole even if several searches or downloads report at once.
+type NDJSONSink struct {
+    mu      sync.Mutex
+    encoder *json.Encoder
+}
+
+// Returns an NDJSONSink writing to w. Attach it to searches by passing its Result to StreamImages, and to downloads by passing its Progress to WithProgress:
+//	sink := imagesearch.StreamNDJSON(os.Stdout)
+//	client := imagesearch.NewClient(imagesearch.WithProgress(sink.Progress))
+//	err := client.StreamImages(ctx, "example", 50, sink.Result)
+func StreamNDJSON(w io.Writer) *NDJSONSink {
+    return &NDJSONSink{encoder: json.NewEncoder(w)}
+}
+
+// A line written by NDJSONSink
+type ndjsonEvent struct {
+    Event string `json:"event"`
+    Image *Image `json:"image,omitempty"`
+
+    Query      string `json:"query,omitempty"`
+    Url        string `json:"url,omitempty"`
+    Path       string `json:"path,omitempty"`
+    Error      string `json:"error,omitempty"`
+    Downloaded *int   `json:"downloaded,omitempty"`
+    Failed     *int   `json:"failed,omitempty"`
+    Limit      int    `json:"limit,omitempty"`
+}
+
+// Writes a search result. Returns the error writing it, so StreamImages stops once w can't be written to anymore.
+func (s *NDJSONSink) Result(img Image) error {
+    return s.write(ndjsonEvent{Event: "result", Image: &img})
+}
+
+// Writes the outcome of an image once it is done downloading, and ignores the progress reported while it is read.
+// Errors writing it are ignored, since they mustn't fail the download.
+func (s *NDJSONSink) Progress(progress Progress) {
+    if !progress.Done {
+        return
+    }
+    event := ndjsonEvent{Event: "download", Query: progress.Query, Url: progress.Url, Path: progress.Path, Downloaded: &progress.Downloaded, Failed: &progress.Failed, Limit: progress.Limit}
+    if progress.Err != nil {
+        event.Error = progress.Err.Error()
+    }
+    s.write(event)
+}
+
+func (s *NDJSONSink) write(event ndjsonEvent) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.encoder.Encode(event)
+}
@@ -0,0 +1,260 @@
+package imagesearch
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/binary"
+    "fmt"
+    "hash/crc32"
+    "image"
+    "os"
+    "path"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// The name of the file WriteTFRecords lists the labels in, one per line in the order of their numbers.
+const TFRecordLabelsName = "labels.txt"
+
+// Settings of WriteTFRecords.
+type TFRecordOptions struct {
+    // Most classes downloaded at once. Below 1 downloads one class at a time.
+    Concurrency int
+
+    // Files the records are spread over, so TensorFlow can read them in parallel. Below 1 writes a single file.
+    Shards int
+
+    // Start of the name of every shard, like "data" for data-00000-of-00004.tfrecord. Defaults to "data".
+    Prefix string
+}
+
+// Searches for the query of every class and packs its images into sharded TFRecord files in dir, like data-00000-of-00004.tfrecord, along with TFRecordLabelsName.
+// See Client.WriteTFRecords.
+func WriteTFRecords(ctx context.Context, dir string, classes []DatasetClass, options TFRecordOptions) ([]string, error) {
+    return defaultClient.WriteTFRecords(ctx, dir, classes, options)
+}
+
+// Searches for the query of every class and packs its images into sharded TFRecord files in dir, like data-00000-of-00004.tfrecord, along with TFRecordLabelsName.
+// Images are fetched into memory and written straight into the records, so they are never saved as files of their own.
+//
+// Every record is a tf.train.Example with the features image/encoded, the bytes of the image, image/format, like "jpeg",
+// image/class/label, the number of the class in the order of classes, image/class/text, its label, image/width and image/height if the image could be decoded, and image/url.
+// The images of every class are spread over the shards in turn. Returns the paths of the shards, and like DownloadDataset, the first error of a class after the others are written.
+func (c *Client) WriteTFRecords(ctx context.Context, dir string, classes []DatasetClass, options TFRecordOptions) ([]string, error) {
+    dir, err := filepath.Abs(strings.ReplaceAll(dir, "\\", "/"))
+    if err != nil {
+        return nil, err
+    }
+    classes, err = labelClasses(classes, false)
+    if err != nil {
+        return nil, err
+    }
+    if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+        return nil, err
+    }
+    var labels strings.Builder
+    for _, class := range classes {
+        labels.WriteString(class.Label + "\n")
+    }
+    if err := os.WriteFile(path.Join(dir, TFRecordLabelsName), []byte(labels.String()), 0666); err != nil {
+        return nil, err
+    }
+
+    shards := options.Shards
+    if shards < 1 {
+        shards = 1
+    }
+    prefix := options.Prefix
+    if prefix == "" {
+        prefix = "data"
+    }
+    writers := make([]*tfrecordWriter, shards)
+    paths := make([]string, shards)
+    for i := range writers {
+        paths[i] = path.Join(dir, fmt.Sprintf("%s-%05d-of-%05d.tfrecord", prefix, i, shards))
+        if writers[i], err = createTFRecord(paths[i]); err != nil {
+            for _, writer := range writers[:i] {
+                writer.close()
+            }
+            return nil, err
+        }
+    }
+
+    concurrency := options.Concurrency
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    errs := make([]error, len(classes))
+    semaphore := make(chan struct{}, concurrency)
+    var wg sync.WaitGroup
+    for i, class := range classes {
+        wg.Add(1)
+        semaphore <- struct{}{}
+        go func(i int, class DatasetClass) {
+            defer wg.Done()
+            defer func() { <-semaphore }()
+            errs[i] = c.recordClass(ctx, writers, i, class)
+        }(i, class)
+    }
+    wg.Wait()
+
+    for _, writer := range writers {
+        if closeErr := writer.close(); err == nil {
+            err = closeErr
+        }
+    }
+    if err != nil {
+        return paths, err
+    }
+    for i, err := range errs {
+        if err != nil {
+            return paths, fmt.Errorf("%s: %w", classes[i].Label, err)
+        }
+    }
+    return paths, ctx.Err()
+}
+
+// Searches for a class and writes a record of each of its images, spreading them over the shards starting from the shard of the class's number.
+func (c *Client) recordClass(ctx context.Context, writers []*tfrecordWriter, number int, class DatasetClass) error {
+    images, err := c.Images(ctx, class.Query, c.overfetch(class.Limit), class.Arguments...)
+    if err != nil {
+        return err
+    }
+
+    progress := c.tracker(class.Label, class.Limit)
+    var written int
+    for _, img := range images {
+        if class.Limit > 0 && written >= class.Limit {
+            break
+        }
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+
+        fetched, err := c.fetchImage(ctx, ImageData{Url: img.Url, Query: class.Query, Arguments: class.Arguments, Image: img}, progress)
+        progress.done(img.Url, "", err)
+        if err != nil {
+            continue
+        }
+        features := map[string]tfFeature{
+            "image/encoded":     {bytes: [][]byte{fetched.Data}},
+            "image/format":      {bytes: [][]byte{[]byte(fetched.Format)}},
+            "image/class/label": {ints: []int64{int64(number)}},
+            "image/class/text":  {bytes: [][]byte{[]byte(class.Label)}},
+            "image/url":         {bytes: [][]byte{[]byte(img.Url)}},
+        }
+        if config, _, err := image.DecodeConfig(bytes.NewReader(fetched.Data)); err == nil {
+            features["image/width"] = tfFeature{ints: []int64{int64(config.Width)}}
+            features["image/height"] = tfFeature{ints: []int64{int64(config.Height)}}
+        }
+        if err := writers[(number+written)%len(writers)].write(encodeExample(features)); err != nil {
+            return err
+        }
+        written++
+    }
+    return nil
+}
+
+// Writes records in the TFRecord format: the length of every record and its data, each followed by its masked CRC-32C. Safe for concurrent use.
+type tfrecordWriter struct {
+    mu   sync.Mutex
+    file *os.File
+    out  *bufio.Writer
+}
+
+func createTFRecord(path string) (*tfrecordWriter, error) {
+    file, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &tfrecordWriter{file: file, out: bufio.NewWriter(file)}, nil
+}
+
+func (w *tfrecordWriter) write(record []byte) error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    var header [12]byte
+    binary.LittleEndian.PutUint64(header[:8], uint64(len(record)))
+    binary.LittleEndian.PutUint32(header[8:], maskedCRC(header[:8]))
+    var footer [4]byte
+    binary.LittleEndian.PutUint32(footer[:], maskedCRC(record))
+
+    w.out.Write(header[:])
+    w.out.Write(record)
+    _, err := w.out.Write(footer[:])
+    return err
+}
+
+func (w *tfrecordWriter) close() error {
+    err := w.out.Flush()
+    if closeErr := w.file.Close(); err == nil {
+        err = closeErr
+    }
+    return err
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Returns the CRC-32C of data masked like TFRecord expects, since checksums of data containing checksums are weak.
+func maskedCRC(data []byte) uint32 {
+    crc := crc32.Checksum(data, castagnoli)
+    return (crc>>15 | crc<<17) + 0xa282ead8
+}
+
+// A feature of a tf.train.Example, which is either a list of byte strings or a list of integers.
+type tfFeature struct {
+    bytes [][]byte
+    ints  []int64
+}
+
+// Encodes the features as a tf.train.Example protocol buffer, with the features sorted by name so the encoding is deterministic.
+//
+//	Example { Features features = 1; }
+//	Features { map<string, Feature> feature = 1; }
+//	Feature { oneof { BytesList bytes_list = 1; Int64List int64_list = 3; } }
+//	BytesList { repeated bytes value = 1; }
+//	Int64List { repeated int64 value = 1 [packed = true]; }
+func encodeExample(features map[string]tfFeature) []byte {
+    names := make([]string, 0, len(features))
+    for name := range features {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var encoded []byte
+    for _, name := range names {
+        feature := features[name]
+        var list []byte
+        var field int
+        if feature.ints != nil {
+            var packed []byte
+            for _, value := range feature.ints {
+                packed = binary.AppendUvarint(packed, uint64(value))
+            }
+            list = appendField(nil, 1, packed)
+            field = 3
+        } else {
+            for _, value := range feature.bytes {
+                list = appendField(list, 1, value)
+            }
+            field = 1
+        }
+
+        var entry []byte
+        entry = appendField(entry, 1, []byte(name))
+        entry = appendField(entry, 2, appendField(nil, field, list))
+        encoded = appendField(encoded, 1, entry)
+    }
+    return appendField(nil, 1, encoded)
+}
+
+// Appends a length-delimited field of a protocol buffer.
+func appendField(buffer []byte, field int, value []byte) []byte {
+    buffer = binary.AppendUvarint(buffer, uint64(field<<3|2))
+    buffer = binary.AppendUvarint(buffer, uint64(len(value)))
+    return append(buffer, value...)
+}
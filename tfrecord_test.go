@@ -0,0 +1,141 @@
+package imagesearch
+
+import (
+    "encoding/binary"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestMaskedCRC(t *testing.T) {
+    tests := []struct {
+        data string
+        want uint32
+    }{
+        {"", 0xa282ead8},
+        // The CRC-32C check value of "123456789" is 0xe3069283
+        {"123456789", 0xc78ab0e5},
+    }
+    for _, test := range tests {
+        if got := maskedCRC([]byte(test.data)); got != test.want {
+            t.Errorf("maskedCRC(%q) = %#x, expected %#x", test.data, got, test.want)
+        }
+    }
+}
+
+// Writes a record, reads it back, and decodes its Example.
+func TestTFRecord(t *testing.T) {
+    features := map[string]tfFeature{
+        "image/encoded":     {bytes: [][]byte{[]byte("\x89PNG")}},
+        "image/class/label": {ints: []int64{3}},
+        "image/class/text":  {bytes: [][]byte{[]byte("cat")}},
+    }
+    path := filepath.Join(t.TempDir(), "data.tfrecord")
+    writer, err := createTFRecord(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := writer.write(encodeExample(features)); err != nil {
+        t.Fatal(err)
+    }
+    if err := writer.close(); err != nil {
+        t.Fatal(err)
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(data) < 12 {
+        t.Fatalf("%d byte file is shorter than a record header", len(data))
+    }
+    length := binary.LittleEndian.Uint64(data[:8])
+    if length != 86 {
+        t.Fatalf("record length %d, expected 86", length)
+    }
+    if len(data) != 12+int(length)+4 {
+        t.Fatalf("%d byte file, expected a single %d byte record", len(data), length)
+    }
+    if crc := binary.LittleEndian.Uint32(data[8:12]); crc != 0xb2e22d4e {
+        t.Errorf("length crc %#x, expected 0xb2e22d4e", crc)
+    }
+    record := data[12 : 12+length]
+    if crc := binary.LittleEndian.Uint32(data[12+length:]); crc != 0xaf20afe2 {
+        t.Errorf("data crc %#x, expected 0xaf20afe2", crc)
+    }
+
+    decoded, err := decodeExample(record)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(decoded, features) {
+        t.Errorf("decoded %v, expected %v", decoded, features)
+    }
+}
+
+// Decodes a tf.train.Example written by encodeExample.
+func decodeExample(example []byte) (map[string]tfFeature, error) {
+    _, encoded, _, err := readField(example)
+    if err != nil {
+        return nil, err
+    }
+    features := map[string]tfFeature{}
+    for len(encoded) > 0 {
+        var entry []byte
+        _, entry, encoded, err = readField(encoded)
+        if err != nil {
+            return nil, err
+        }
+        _, name, rest, err := readField(entry)
+        if err != nil {
+            return nil, err
+        }
+        _, feature, _, err := readField(rest)
+        if err != nil {
+            return nil, err
+        }
+        kind, list, _, err := readField(feature)
+        if err != nil {
+            return nil, err
+        }
+
+        var decoded tfFeature
+        for len(list) > 0 {
+            var value []byte
+            _, value, list, err = readField(list)
+            if err != nil {
+                return nil, err
+            }
+            if kind == 1 {
+                decoded.bytes = append(decoded.bytes, value)
+                continue
+            }
+            for len(value) > 0 {
+                number, n := binary.Uvarint(value)
+                if n <= 0 {
+                    return nil, errUnpack
+                }
+                decoded.ints = append(decoded.ints, int64(number))
+                value = value[n:]
+            }
+        }
+        features[string(name)] = decoded
+    }
+    return features, nil
+}
+
+// Reads a length-delimited field of a protocol buffer, returning its number, its value, and the rest of the buffer.
+func readField(buffer []byte) (field int, value, rest []byte, err error) {
+    key, n := binary.Uvarint(buffer)
+    if n <= 0 || key&7 != 2 {
+        return 0, nil, nil, errUnpack
+    }
+    buffer = buffer[n:]
+    length, n := binary.Uvarint(buffer)
+    if n <= 0 || uint64(len(buffer)-n) < length {
+        return 0, nil, nil, errUnpack
+    }
+    buffer = buffer[n:]
+    return int(key >> 3), buffer[:length], buffer[length:], nil
+}
@@ -0,0 +1,69 @@
+package imagesearch
+
+import (
+    "encoding/base64"
+    "regexp"
+    "strings"
+)
+
+var (
+    // (function(){var s='data:image/jpeg;base64,/9j/4AAQ...';var ii=['dimg_1','dimg_3'];_setImagesSrc(ii,s);})();
+    inlineThumbnailPattern = regexp.MustCompile(`var s='(data:image/[^']+)';var ii=\[([^\]]*)\]`)
+    dimgIdPattern          = regexp.MustCompile(`'([^']+)'`)
+    docIdPattern           = regexp.MustCompile(`data-docid="([^"]+)"`)
+    imgIdPattern           = regexp.MustCompile(`id="(dimg_[^"]+)"`)
+)
+
+// Attaches the thumbnails Google inlines as base64 for the first results on the page to their images.
+// The inline thumbnails are assigned to img elements by id, which are matched to results through the data-docid of the element containing them.
+func attachThumbnails(page string, images []Image) {
+    inline := map[string][]byte{}
+    for _, match := range inlineThumbnailPattern.FindAllStringSubmatch(page, -1) {
+        data, ok := decodeDataUrl(unquote(match[1]))
+        if !ok {
+            continue
+        }
+        for _, id := range dimgIdPattern.FindAllStringSubmatch(match[2], -1) {
+            inline[id[1]] = data
+        }
+    }
+    if len(inline) == 0 {
+        return
+    }
+
+    byDoc := map[string][]byte{}
+    docs := docIdPattern.FindAllStringSubmatchIndex(page, -1)
+    for i, doc := range docs {
+        end := len(page)
+        if i+1 < len(docs) {
+            end = docs[i+1][0]
+        }
+        img := imgIdPattern.FindStringSubmatch(page[doc[1]:end])
+        if img == nil {
+            continue
+        }
+        if data, ok := inline[img[1]]; ok {
+            byDoc[page[doc[2]:doc[3]]] = data
+        }
+    }
+
+    for i := range images {
+        if data, ok := byDoc[images[i].Id]; ok && images[i].Id != "" {
+            images[i].ThumbnailData = data
+        }
+    }
+}
+
+// Decodes the content of a base64 data url, like data:image/jpeg;base64,/9j/4AAQ...
+func decodeDataUrl(url string) ([]byte, bool) {
+    i := strings.Index(url, ";base64,")
+    if !strings.HasPrefix(url, "data:") || i == -1 {
+        return nil, false
+    }
+
+    data, err := base64.StdEncoding.DecodeString(url[i+len(";base64,"):])
+    if err != nil {
+        return nil, false
+    }
+    return data, true
+}
@@ -0,0 +1,201 @@
+package imagesearch
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+)
+
+// Returned when the images can't be extracted from a results page. Check for it with IsUnpackErr.
+// Along with what failed, it includes the part of the page around the failure and hints about the likely cause, so that logs and bug reports are self-explanatory.
+type UnpackError struct {
+    // The engine whose results page couldn't be unpacked, empty for Google
+    Engine string
+
+    // The query that was searched for, empty for pages parsed with ParsePage
+    Query string
+
+    // The url of the page after any redirects, empty for pages parsed with ParsePage
+    Url string
+
+    // The first stage that failed, of the first strategy that was tried
+    Stage Stage
+
+    // What the stage was looking for and didn't find, for example "AF_initDataCallback"
+    Marker string
+
+    // Up to 200 bytes of the page around where the stage failed
+    Context string
+
+    // Likely causes of the failure, most likely first
+    Hints []string
+
+    // Whether the engine refused the search, like Google showing a captcha because of unusual traffic, instead of serving results that couldn't be unpacked
+    Blocked bool
+
+    // What failed, as recorded in the Diagnostics
+    Found string
+
+    // The underlying error, like a json syntax error
+    Err error
+}
+
+func (e *UnpackError) Error() string {
+    cause := errUnpack.Error()
+    if e.Engine != "" {
+        cause = fmt.Sprintf("failed to unpack %s results! no image results or %s changed their structure", e.Engine, e.Engine)
+    }
+    message := fmt.Sprintf("%s: %s stage failed looking for %s (%s)", cause, e.Stage, e.Marker, e.Found)
+    if e.Query != "" {
+        message += fmt.Sprintf(" for query %q", e.Query)
+    }
+    if e.Url != "" {
+        message += " at " + e.Url
+    }
+    if len(e.Hints) > 0 {
+        message += "; hints: " + strings.Join(e.Hints, "; ")
+    }
+    return message
+}
+
+func (e *UnpackError) Unwrap() error {
+    return e.Err
+}
+
+// How much of the page is included as context.
+const contextSize = 200
+
+var stageMarkers = map[Stage]string{
+    StageCallback: "AF_initDataCallback",
+    StageDecode:   "a json payload",
+    StageIndex:    "result envelopes",
+    StageFields:   "image urls",
+}
+
+func newUnpackError(query, final, page string, diagnostics Diagnostics) *UnpackError {
+    err := &UnpackError{
+        Query:   query,
+        Url:     final,
+        Stage:   diagnostics.Failed,
+        Err:     diagnostics.Err,
+        Hints:   hints(final, page),
+        Blocked: strings.Contains(final, "/sorry/") || captcha(page),
+    }
+
+    // The first failure is of the primary strategy, and the most telling
+    for _, step := range diagnostics.Steps {
+        if !step.Ok {
+            err.Stage = step.Stage
+            err.Found = step.Found
+            break
+        }
+    }
+    err.Marker = stageMarkers[err.Stage]
+    if err.Err == nil {
+        err.Err = errUnpack
+    }
+
+    switch {
+    case err.Stage == StageDecode && diagnostics.context != "":
+        err.Context = diagnostics.context
+    case err.Stage == StageIndex || err.Stage == StageFields:
+        if i := strings.Index(page, envelopeKey); i != -1 {
+            err.Context = snippet(page, i)
+            break
+        }
+        fallthrough
+    default:
+        if i := strings.Index(page, "<body"); i != -1 {
+            err.Context = snippet(page, i)
+        } else {
+            err.Context = snippet(page, 0)
+        }
+    }
+
+    return err
+}
+
+// Creates the error returned when the results page of an engine other than Google can't be unpacked because marker isn't on it.
+func newEngineUnpackError(engine, query, final, page, marker string) *UnpackError {
+    err := &UnpackError{
+        Engine: engine,
+        Query:  query,
+        Url:    final,
+        Stage:  StageIndex,
+        Marker: marker,
+        Found:  "none",
+        Err:    errUnpack,
+    }
+    if i := strings.Index(page, "<body"); i != -1 {
+        err.Context = snippet(page, i)
+    } else {
+        err.Context = snippet(page, 0)
+    }
+
+    if captcha(page) {
+        err.Blocked = true
+        err.Hints = append(err.Hints, engine+" is showing a captcha because of unusual traffic from this IP; slow down or use a different IP")
+    }
+    if len(page) < 10000 {
+        err.Hints = append(err.Hints, fmt.Sprintf("the page is unusually small (%d bytes), so it is probably not a results page", len(page)))
+    }
+    if len(err.Hints) == 0 {
+        err.Hints = append(err.Hints, engine+" probably changed the structure of its results page; please open an issue at https://github.com/commonkestrel/imagesearch/issues")
+    }
+    return err
+}
+
+// Guesses why a page couldn't be unpacked, from the most common reasons.
+func hints(final, page string) []string {
+    lower := strings.ToLower(page)
+    var hints []string
+
+    if strings.Contains(final, "consent.google.") || strings.Contains(lower, "before you continue to google") {
+        hints = append(hints, "Google redirected to its cookie consent page, which happens for requests from the EU; try setting a CONSENT cookie")
+    }
+    if strings.Contains(final, "/sorry/") || captcha(page) {
+        hints = append(hints, "Google is showing a captcha because of unusual traffic from this IP; slow down or use a different IP")
+    }
+    if len(page) < 10000 {
+        hints = append(hints, fmt.Sprintf("the page is unusually small (%d bytes), so it is probably not a results page", len(page)))
+    }
+    if len(hints) == 0 {
+        hints = append(hints, "Google probably changed the structure of its results page; please open an issue at https://github.com/commonkestrel/imagesearch/issues with the output of Diagnose")
+    }
+
+    return hints
+}
+
+// Whether the page is a captcha rather than results.
+func captcha(page string) bool {
+    lower := strings.ToLower(page)
+    return strings.Contains(lower, "captcha") || strings.Contains(lower, "unusual traffic")
+}
+
+// Checks if an error means the engine refused to search, rather than failing in some other way: an *UnpackError of a captcha page,
+// or a *StatusError of 403 Forbidden or 429 Too Many Requests. Searching again later, more slowly, or from a different IP may succeed.
+func IsBlocked(err error) bool {
+    var unpackErr *UnpackError
+    if errors.As(err, &unpackErr) && unpackErr.Blocked {
+        return true
+    }
+    var statusErr *StatusError
+    return errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusForbidden || statusErr.StatusCode == http.StatusTooManyRequests)
+}
+
+// Returns up to contextSize bytes of s centered around the byte at.
+func snippet(s string, at int) string {
+    start := at - contextSize/2
+    if start < 0 {
+        start = 0
+    }
+    end := start + contextSize
+    if end > len(s) {
+        end = len(s)
+    }
+    if start > end {
+        start = end
+    }
+    return s[start:end]
+}
@@ -0,0 +1,154 @@
+package imagesearch
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// The result of checking whether a single url is alive.
+type UrlStatus struct {
+    Url string
+
+    // Whether the server responded with a successful status code
+    Alive bool
+
+    // Status code of the last response, 0 if the request failed
+    StatusCode int
+
+    // Content-Type the server reported, for example "image/png"
+    ContentType string
+
+    // Size of the content in bytes, -1 if the server did not report it
+    Size int64
+
+    // Error that prevented the url from being checked, if any
+    Err error
+}
+
+// Checks which of the urls are alive without downloading them, running up to concurrency checks at once. A concurrency below 1 checks one url at a time.
+// Returns the status of every url in the same order as urls.
+func ValidateUrls(ctx context.Context, urls []string, concurrency int) []UrlStatus {
+    return defaultClient.ValidateUrls(ctx, urls, concurrency)
+}
+
+// Checks which of the urls are alive without downloading them, running up to concurrency checks at once. A concurrency below 1 checks one url at a time.
+// Returns the status of every url in the same order as urls.
+//
+// Every url is first checked with a HEAD request. Since plenty of servers don't support HEAD, a GET request for only the first byte is sent if it fails.
+func (c *Client) ValidateUrls(ctx context.Context, urls []string, concurrency int) []UrlStatus {
+    if concurrency < 1 {
+        concurrency = 1
+    }
+
+    statuses := make([]UrlStatus, len(urls))
+    semaphore := make(chan struct{}, concurrency)
+    var wg sync.WaitGroup
+    for i, url := range urls {
+        wg.Add(1)
+        semaphore <- struct{}{}
+        go func(i int, url string) {
+            defer wg.Done()
+            statuses[i] = c.validateUrl(ctx, url)
+            <-semaphore
+        }(i, url)
+    }
+    wg.Wait()
+
+    return statuses
+}
+
+func (c *Client) validateUrl(ctx context.Context, url string) UrlStatus {
+    status := UrlStatus{Url: url, Size: -1}
+
+    resp, err := c.probe(ctx, "HEAD", url)
+    if err != nil || resp.StatusCode >= 400 {
+        resp, err = c.probe(ctx, "GET", url)
+    }
+    if err != nil {
+        status.Err = err
+        return status
+    }
+
+    status.StatusCode = resp.StatusCode
+    status.Alive = resp.StatusCode >= 200 && resp.StatusCode < 300
+    status.ContentType = resp.Header.Get("Content-Type")
+    if resp.StatusCode == http.StatusPartialContent {
+        // Content-Range: bytes 0-0/12345
+        contentRange := resp.Header.Get("Content-Range")
+        if i := strings.LastIndex(contentRange, "/"); i != -1 {
+            if size, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+                status.Size = size
+            }
+        }
+    } else if resp.ContentLength >= 0 {
+        status.Size = resp.ContentLength
+    }
+
+    return status
+}
+
+// Sends a request that does not transfer the content of the url. GET requests only ask for the first byte.
+func (c *Client) probe(ctx context.Context, method, url string) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, method, url, nil)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("User-Agent", userAgent)
+    if method == "GET" {
+        req.Header.Set("Range", "bytes=0-0")
+    }
+
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+    resp.Body.Close()
+
+    return resp, nil
+}
+
+// The Referer Hotlinkable sends, standing in for any site other than the image's own
+const hotlinkReferer = "https://example.com/"
+
+// Checks if the image at url can be embedded directly on another site, see Client.Hotlinkable.
+func Hotlinkable(ctx context.Context, url string) (bool, error) {
+    return defaultClient.Hotlinkable(ctx, url)
+}
+
+// Checks if the image at url can be embedded directly on another site, like posted as a link that chat apps render as an image, instead of rendering as a broken image.
+// The url is requested with the Referer of an unrelated site, like a browser embedding it would, and is hotlinkable if it responds with 200 and content that is an image,
+// by both its Content-Type and its first bytes, since servers that block hotlinking often answer with a page instead. Only the first bytes of the image are read.
+// Returns an error if the request fails, in which case it couldn't be told either way.
+func (c *Client) Hotlinkable(ctx context.Context, url string) (bool, error) {
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return false, err
+    }
+    req.Header.Set("User-Agent", userAgent)
+    req.Header.Set("Referer", hotlinkReferer)
+    resp, err := c.do(req)
+    if err != nil {
+        return false, err
+    }
+    defer resp.Body.Close()
+
+    contentType := resp.Header.Get("Content-Type")
+    if resp.StatusCode != http.StatusOK || !strings.HasPrefix(contentType, "image/") {
+        return false, nil
+    }
+    if strings.HasPrefix(contentType, "image/svg+xml") {
+        // SVG images are text, which sniffing the first bytes doesn't recognize as an image
+        return true, nil
+    }
+    head := make([]byte, 512)
+    n, err := io.ReadFull(resp.Body, head)
+    if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+        return false, err
+    }
+    _, err = imageFormat(head[:n])
+    return err == nil, nil
+}
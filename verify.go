@@ -0,0 +1,199 @@
+package imagesearch
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "image"
+    "image/color"
+    "math"
+    "sort"
+)
+
+// The names of the colors of the Color arguments, by argument
+var colorNames = map[string]string{
+    Color.Red: "red", Color.Orange: "orange", Color.Yellow: "yellow", Color.Green: "green", Color.Teal: "teal", Color.Blue: "blue",
+    Color.Purple: "purple", Color.Pink: "pink", Color.White: "white", Color.Gray: "gray", Color.Black: "black", Color.Brown: "brown",
+}
+
+// Thresholds of WithColorCheck: the share of the image the requested color must cover, and how many of the most common colors it must be among
+const (
+    minColorShare = 0.1
+    topColors     = 3
+
+    // Share of the image that must be unsaturated for it to count as grayscale
+    minGrayShare = 0.9
+)
+
+// Verifies that images found with a Color or ColorType.Grayscale argument actually have that color, and discards those that don't, since the color filters of engines are frequently wrong.
+// The image is sampled into a 64x64 grid and every cell is binned into the closest of the Color arguments by its hue, saturation, and brightness.
+// An image has the requested color if it covers at least 10% of the image and is among its 3 most common colors, and is grayscale if at least 90% of it is unsaturated.
+// The check runs in PhaseFilter. Images found without these arguments, and images the standard library can't decode, are kept.
+func WithColorCheck() Option {
+    return WithStage(PipelineStage{Name: "color", Phase: PhaseFilter, Process: func(ctx context.Context, img *ImageData) error {
+        var color string
+        var grayscale bool
+        for _, argument := range img.Arguments {
+            if name, ok := colorNames[argument]; ok {
+                color = name
+            } else if argument == ColorType.Grayscale {
+                grayscale = true
+            }
+        }
+        if color == "" && !grayscale {
+            return nil
+        }
+        decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+        if err != nil {
+            return nil
+        }
+
+        shares := colorShares(decoded)
+        if grayscale && shares["white"]+shares["gray"]+shares["black"] < minGrayShare {
+            return fmt.Errorf("%w: not grayscale", errFiltered)
+        }
+        if color != "" && !hasColor(shares, color) {
+            return fmt.Errorf("%w: not %s", errFiltered, color)
+        }
+        return nil
+    }})
+}
+
+// Checks if the color covers at least minColorShare of the image and is among its topColors most common colors.
+func hasColor(shares map[string]float64, color string) bool {
+    if shares[color] < minColorShare {
+        return false
+    }
+    colors := make([]string, 0, len(shares))
+    for name := range shares {
+        colors = append(colors, name)
+    }
+    sort.Slice(colors, func(i, j int) bool {
+        return shares[colors[i]] > shares[colors[j]]
+    })
+    for i := 0; i < len(colors) && i < topColors; i++ {
+        if colors[i] == color {
+            return true
+        }
+    }
+    return false
+}
+
+// The side of the grid colorShares samples images into
+const colorGrid = 64
+
+// Returns the share of the image every color covers, by the names of the Color arguments.
+func colorShares(img image.Image) map[string]float64 {
+    shares := map[string]float64{}
+    sampleGrid(img, colorGrid, func(x, y int, c color.Color) {
+        r, g, b, a := c.RGBA()
+        if a < 0x8000 {
+            // Transparent cells have no color of their own
+            return
+        }
+        shares[colorName(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff)]++
+    })
+    var total float64
+    for _, count := range shares {
+        total += count
+    }
+    for name := range shares {
+        shares[name] /= total
+    }
+    return shares
+}
+
+// Returns the name of the Color argument closest to a color with channels from 0 to 1.
+func colorName(r, g, b float64) string {
+    high := math.Max(r, math.Max(g, b))
+    low := math.Min(r, math.Min(g, b))
+    value := high
+    var saturation float64
+    if high > 0 {
+        saturation = (high - low) / high
+    }
+    switch {
+    case value < 0.2:
+        return "black"
+    case saturation < 0.15 && value > 0.85:
+        return "white"
+    case saturation < 0.15:
+        return "gray"
+    }
+
+    var hue float64
+    switch high {
+    case r:
+        hue = math.Mod((g-b)/(high-low)*60+360, 360)
+    case g:
+        hue = (b-r)/(high-low)*60 + 120
+    default:
+        hue = (r-g)/(high-low)*60 + 240
+    }
+    switch {
+    case hue >= 15 && hue < 45 && value < 0.6:
+        return "brown"
+    case hue < 15 || hue >= 345:
+        if saturation < 0.5 && value > 0.7 {
+            return "pink"
+        }
+        return "red"
+    case hue < 40:
+        return "orange"
+    case hue < 70:
+        return "yellow"
+    case hue < 160:
+        return "green"
+    case hue < 200:
+        return "teal"
+    case hue < 255:
+        return "blue"
+    case hue < 290:
+        return "purple"
+    default:
+        return "pink"
+    }
+}
+
+// Verifies that images found with an AspectRatio argument actually have that aspect ratio, and discards those that don't, since engines only apply it approximately.
+// The tolerance is the fraction images may be off a square and still count as square, so with a tolerance of 0.05, square images are at most 5% wider or taller than they are tall or wide,
+// tall images are taller than that, wide images wider, and panoramic images at least twice as wide as they are tall, give or take the same 5%. A tolerance of 0 only counts exact squares as square.
+// Like WithColorCheck, this runs in PhaseFilter. Images found without an AspectRatio argument, and images the standard library can't decode, are kept.
+func WithAspectCheck(tolerance float64) Option {
+    if tolerance < 0 {
+        tolerance = 0
+    }
+    return WithStage(PipelineStage{Name: "aspect ratio", Phase: PhaseFilter, Process: func(ctx context.Context, img *ImageData) error {
+        var aspect string
+        for _, argument := range img.Arguments {
+            switch argument {
+            case AspectRatio.Tall, AspectRatio.Square, AspectRatio.Wide, AspectRatio.Panoramic:
+                aspect = argument
+            }
+        }
+        if aspect == "" {
+            return nil
+        }
+        config, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+        if err != nil || config.Width == 0 || config.Height == 0 {
+            return nil
+        }
+
+        ratio := float64(config.Width) / float64(config.Height)
+        var matches bool
+        switch aspect {
+        case AspectRatio.Tall:
+            matches = ratio < 1/(1+tolerance)
+        case AspectRatio.Square:
+            matches = ratio >= 1/(1+tolerance) && ratio <= 1+tolerance
+        case AspectRatio.Wide:
+            matches = ratio > 1+tolerance
+        case AspectRatio.Panoramic:
+            matches = ratio >= 2/(1+tolerance)
+        }
+        if !matches {
+            return fmt.Errorf("%w: aspect ratio %dx%d doesn't match", errFiltered, config.Width, config.Height)
+        }
+        return nil
+    }})
+}
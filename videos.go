@@ -0,0 +1,147 @@
+package imagesearch
+
+import (
+    "context"
+    "html"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// A video found by Videos, along with its thumbnail.
+// The embedded Image is the thumbnail, whose Source is the page of the video, so videos can be downloaded and deduplicated like any other image.
+type Video struct {
+    Image
+
+    // Length of the video as Google shows it, like "3:45". Empty if Google doesn't show one.
+    Duration string `json:"duration,omitempty"`
+}
+
+// Searches Google Videos for the query, and returns the videos found along with their thumbnails.
+// The amount of videos does not exceed the limit unless the limit is 0, in which case it will return all videos found. Only the Time, SafeSearch, Language, Region and Site arguments apply to videos, the rest are ignored.
+func Videos(query string, limit int, arguments ...string) ([]Video, error) {
+    return defaultClient.Videos(context.Background(), query, limit, arguments...)
+}
+
+// Searches Google Videos for the query, and returns the videos found along with their thumbnails.
+// The amount of videos does not exceed the limit unless the limit is 0, in which case it will return all videos found. Only the Time, SafeSearch, Language, Region and Site arguments apply to videos, the rest are ignored.
+// Videos always come from Google, whatever the Client's engine is.
+func (c *Client) Videos(ctx context.Context, query string, limit int, arguments ...string) ([]Video, error) {
+    page, _, err := c.getPage(ctx, buildVerticalUrl("vid", query, arguments), basicUserAgent)
+    if err != nil {
+        return []Video{}, err
+    }
+
+    videos := []Video{}
+    for _, result := range basicResults(page) {
+        if limit > 0 && len(videos) >= limit {
+            break
+        }
+        video := Video{
+            Image:    Image{Url: result.thumbnail, Source: result.link, Title: result.title, Rank: len(videos) + 1, Engine: "google"},
+            Duration: durationPattern.FindString(strings.Join(result.text, " ")),
+        }
+        videos = append(videos, video)
+    }
+    return videos, nil
+}
+
+// Durations like 3:45 or 1:02:03
+var durationPattern = regexp.MustCompile(`\b(?:\d{1,2}:)?\d{1,2}:\d{2}\b`)
+
+// Builds the url of the basic results page of one of Google's verticals other than images, like "vid" for videos or "nws" for news. Only the Time arguments and the url parameters like SafeSearch are kept, since the rest only apply to images.
+func buildVerticalUrl(vertical, query string, arguments []string) string {
+    u := "https://www.google.com/search?tbm=" + vertical + "&gbv=1&q=" + url.QueryEscape(query)
+    filters, parameters := splitArguments(arguments)
+    for _, argument := range filters {
+        if strings.HasPrefix(argument, "qdr:") {
+            u += "&tbs=" + argument
+            break
+        }
+    }
+    if len(parameters) > 0 {
+        u += "&" + parameters.Encode()
+    }
+    return u
+}
+
+// A result of the basic results page of a vertical: the page it links to, its title and thumbnail, and the pieces of text shown with it.
+type basicResult struct {
+    link, title, thumbnail string
+    text                   []string
+}
+
+// How much of the text following a result is kept with it
+const resultTextSize = 1000
+
+// Reads the results of the basic results page of a vertical. Every result is made up of one or more /url?q= links to the same page,
+// one around the thumbnail and one around the title, followed by text like the duration of a video or the publisher of an article.
+//	<a href="/url?q=https://example.com/watch&amp;sa=U&amp;..."><img src="https://encrypted-tbn0.gstatic.com/images?q=tbn:..."></a>
+//	<a href="/url?q=https://example.com/watch&amp;sa=U&amp;..."><h3><div>Title</div></h3></a><div>3:45 · Example</div>
+func basicResults(page string) []basicResult {
+    var results []basicResult
+    rest := page
+    for {
+        start := strings.Index(rest, `href="/url?`)
+        if start == -1 {
+            break
+        }
+        rest = rest[start+len(`href="/url?`):]
+        end := strings.Index(rest, `"`)
+        closing := strings.Index(rest, "</a>")
+        if end == -1 || closing == -1 || closing < end {
+            break
+        }
+
+        values, err := url.ParseQuery(html.UnescapeString(rest[:end]))
+        link := values.Get("q")
+        if err != nil || !strings.HasPrefix(link, "http") || isGoogleUrl(link) {
+            continue
+        }
+
+        anchor := rest[end:closing]
+        text := rest[closing:]
+        if next := strings.Index(text, `href="/url?`); next != -1 {
+            text = text[:next]
+            // Up to the start of the next link's tag
+            if tag := strings.LastIndex(text, "<"); tag != -1 {
+                text = text[:tag]
+            }
+        }
+        if len(text) > resultTextSize {
+            text = text[:resultTextSize]
+        }
+
+        if len(results) == 0 || results[len(results)-1].link != link {
+            results = append(results, basicResult{link: link})
+        }
+        result := &results[len(results)-1]
+        if img := srcPattern.FindStringSubmatch(anchor); img != nil && result.thumbnail == "" {
+            result.thumbnail = html.UnescapeString(img[1])
+        }
+        if title := stripTags(anchor[strings.Index(anchor, ">")+1:]); title != "" && result.title == "" {
+            result.title = title
+        }
+        result.text = textPieces(text)
+    }
+
+    // Results without a thumbnail have no image to return
+    withThumbnails := results[:0]
+    for _, result := range results {
+        if result.thumbnail != "" {
+            withThumbnails = append(withThumbnails, result)
+        }
+    }
+    return withThumbnails
+}
+
+// Splits html into the pieces of text between its tags, leaving out empty pieces.
+func textPieces(s string) []string {
+    var pieces []string
+    for _, piece := range tagPattern.Split(s, -1) {
+        if piece = strings.TrimSpace(html.UnescapeString(piece)); piece != "" {
+            pieces = append(pieces, piece)
+        }
+    }
+    return pieces
+}
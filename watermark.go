@@ -0,0 +1,138 @@
+package imagesearch
+
+import (
+    "bytes"
+    "image"
+    "net/url"
+    "strings"
+)
+
+// Decides whether an image carries a watermark, like the overlays stock sites put on their previews. See WithoutWatermarks.
+type WatermarkDetector interface {
+    Watermarked(img ImageData) (bool, error)
+}
+
+// Adapts a function into a WatermarkDetector, for example to plug in a classifier:
+//	imagesearch.WithoutWatermarks(imagesearch.WatermarkDetectorFunc(func(img imagesearch.ImageData) (bool, error) {
+//	    return model.Watermarked(img.Data)
+//	}))
+type WatermarkDetectorFunc func(img ImageData) (bool, error)
+
+func (f WatermarkDetectorFunc) Watermarked(img ImageData) (bool, error) {
+    return f(img)
+}
+
+// Discards images that any of the detectors finds a watermark in, so downloads are free of the overlays of stock sites.
+// Without detectors, the built-in heuristic of StockWatermarks is used. The detectors run in PhaseFilter.
+func WithoutWatermarks(detectors ...WatermarkDetector) Option {
+    if len(detectors) == 0 {
+        detectors = []WatermarkDetector{StockWatermarks()}
+    }
+    return WithFilter(func(img ImageData) (bool, error) {
+        for _, detector := range detectors {
+            watermarked, err := detector.Watermarked(img)
+            if err != nil || watermarked {
+                return false, err
+            }
+        }
+        return true, nil
+    })
+}
+
+// Hosts stock sites serve their watermarked previews from, matched along with their subdomains
+var stockHosts = []string{
+    "shutterstock.com", "gettyimages.com", "gettyimages.co.uk", "istockphoto.com", "alamy.com", "dreamstime.com", "depositphotos.com",
+    "123rf.com", "ftcdn.net", "stock.adobe.com", "bigstockphoto.com", "canstockphoto.com", "pond5.com", "agefotostock.com",
+}
+
+// Returns the built-in WatermarkDetector, a simple heuristic for the obvious watermarks of stock photos, like those of Shutterstock, Getty Images, and Alamy.
+// An image is watermarked if it or the page it was found on is hosted by a stock site, whose previews are always watermarked,
+// or if it has a stock banner: a flat white or black strip along its bottom edge that holds a logo or text, which stock sites stamp their name and image id into.
+// Images in formats the standard library can't decode are only checked by their host. The heuristic misses faint overlays in the middle of an image,
+// so plug in a classifier through WatermarkDetectorFunc where that matters.
+func StockWatermarks() WatermarkDetector {
+    return WatermarkDetectorFunc(func(img ImageData) (bool, error) {
+        for _, link := range []string{img.Url, img.Image.Url, img.Image.Source} {
+            if isStockHost(link) {
+                return true, nil
+            }
+        }
+        decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+        if err != nil {
+            return false, nil
+        }
+        return hasStockBanner(decoded), nil
+    })
+}
+
+// Checks if the url is on a host of stockHosts.
+func isStockHost(link string) bool {
+    parsed, err := url.Parse(link)
+    if err != nil {
+        return false
+    }
+    host := strings.ToLower(parsed.Hostname())
+    for _, stock := range stockHosts {
+        if host == stock || strings.HasSuffix(host, "."+stock) {
+            return true
+        }
+    }
+    return false
+}
+
+// Limits of the strip along the bottom edge that hasStockBanner looks for, as fractions of the height of the image
+const (
+    minBannerHeight = 0.03
+    maxBannerHeight = 0.15
+)
+
+// Checks if the image ends in a banner: rows along the bottom edge that are mostly one flat light or dark shade, some of which have text or a logo in a contrasting shade,
+// above which the image itself starts. Borders have no text, and images on plain backgrounds are flat far beyond the height of a banner, so neither counts.
+func hasStockBanner(img image.Image) bool {
+    bounds := img.Bounds()
+    width, height := bounds.Dx(), bounds.Dy()
+    if width < 32 || height < 32 {
+        return false
+    }
+    step := width/256 + 1
+
+    // Shade of a row, 1 for light and -1 for dark, if most of it is flat, and the fraction of its samples that contrast with the shade
+    row := func(y int) (shade int, contrast float64) {
+        var light, dark, samples int
+        for x := bounds.Min.X; x < bounds.Max.X; x += step {
+            r, g, b, _ := img.At(x, y).RGBA()
+            luminance := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0x101
+            switch {
+            case luminance >= 225:
+                light++
+            case luminance <= 30:
+                dark++
+            }
+            samples++
+        }
+        switch {
+        case float64(light) >= 0.7*float64(samples):
+            return 1, float64(samples-light) / float64(samples)
+        case float64(dark) >= 0.7*float64(samples):
+            return -1, float64(samples-dark) / float64(samples)
+        }
+        return 0, 0
+    }
+
+    shade, _ := row(bounds.Max.Y - 1)
+    if shade == 0 {
+        return false
+    }
+    var rows, text int
+    for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
+        rowShade, contrast := row(y)
+        if rowShade != shade {
+            break
+        }
+        rows++
+        if contrast >= 0.02 {
+            text++
+        }
+    }
+    return rows >= int(minBannerHeight*float64(height)) && rows <= int(maxBannerHeight*float64(height)) && text >= 2
+}
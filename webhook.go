@@ -0,0 +1,95 @@
+package imagesearch
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "path/filepath"
+    "time"
+)
+
+// The JSON summary POSTed to the webhook of a Client created WithWebhook when a job completes or fails.
+type WebhookSummary struct {
+    // Kind of job, either "download" or "batch"
+    Job string `json:"job"`
+
+    // The query that was downloaded, empty for batches
+    Query string `json:"query,omitempty"`
+
+    // Absolute path of the directory the images were downloaded into, empty for batches
+    Dir string `json:"dir,omitempty"`
+
+    // Path of the directory's Manifest, if the Client was created WithManifest
+    Manifest string `json:"manifest,omitempty"`
+
+    // Images or searches asked for, 0 for all the images found
+    Requested int `json:"requested"`
+
+    // Images downloaded or searches that succeeded
+    Succeeded int `json:"succeeded"`
+
+    // Images or searches that failed, by url or key, along with their error
+    Failures map[string]string `json:"failures,omitempty"`
+
+    // Difference between the limit and the images downloaded, see Download
+    Missing int `json:"missing,omitempty"`
+
+    // Why the whole job failed, empty if it completed
+    Error string `json:"error,omitempty"`
+}
+
+// How long posting a summary to the webhook may take
+const webhookTimeout = 30 * time.Second
+
+// POSTs a WebhookSummary as JSON to url whenever a Download, DownloadUrls, or BatchSearch completes or fails, so long running jobs can notify pipelines and chat channels.
+// The webhook is called before the job returns. Failing to reach it doesn't fail the job.
+func WithWebhook(url string) Option {
+    return func(c *Client) {
+        c.webhook = url
+    }
+}
+
+func (c *Client) notifyDownload(query string, limit int, dir string, paths []string, missing int, failures map[string]string, err error) {
+    summary := WebhookSummary{Job: "download", Query: query, Dir: dir, Requested: limit, Succeeded: len(paths), Failures: failures, Missing: missing}
+    if c.manifest {
+        summary.Manifest = filepath.Join(dir, ManifestName)
+    }
+    if err != nil {
+        summary.Error = err.Error()
+    }
+    c.notify(summary)
+}
+
+func (c *Client) notifyBatch(results map[string]BatchResult) {
+    summary := WebhookSummary{Job: "batch", Requested: len(results), Failures: map[string]string{}}
+    for key, result := range results {
+        if result.Err != nil {
+            summary.Failures[key] = result.Err.Error()
+        } else {
+            summary.Succeeded++
+        }
+    }
+    c.notify(summary)
+}
+
+// POSTs the summary to the webhook, ignoring any error, since the job itself is already done.
+func (c *Client) notify(summary WebhookSummary) {
+    body, err := json.Marshal(summary)
+    if err != nil {
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+    defer cancel()
+
+    req, err := http.NewRequestWithContext(ctx, "POST", c.webhook, bytes.NewReader(body))
+    if err != nil {
+        return
+    }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.do(req)
+    if err != nil {
+        return
+    }
+    resp.Body.Close()
+}
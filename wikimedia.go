@@ -0,0 +1,141 @@
+package imagesearch
+
+import (
+    "context"
+    "encoding/json"
+    "html"
+    "net/http"
+    "net/url"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+// Returns the Wikimedia Commons engine, which makes its requests through the given Client.
+// Every image on Commons is freely licensed, and comes with the exact name of its license and its author, which makes this the engine for strict reuse requirements.
+// Of the arguments, only Format is supported, the rest are ignored.
+func Wikimedia(c *Client) Engine {
+    return &wikimedia{client: c}
+}
+
+// Searches the files of Wikimedia Commons through the MediaWiki API.
+type wikimedia struct {
+    client *Client
+}
+
+func (w *wikimedia) Name() string {
+    return "wikimedia"
+}
+
+// A file page as returned by the search generator with the imageinfo prop.
+type wikimediaPage struct {
+    Title string `json:"title"`
+    // Position in the search results, starting at 1
+    Index     int `json:"index"`
+    Imageinfo []struct {
+        Url            string `json:"url"`
+        DescriptionUrl string `json:"descriptionurl"`
+        Width          int    `json:"width"`
+        Height         int    `json:"height"`
+        // Metadata of the file, mostly as html
+        Extmetadata map[string]struct {
+            Value interface{} `json:"value"`
+        } `json:"extmetadata"`
+    } `json:"imageinfo"`
+}
+
+// Wikimedia's API policy asks clients to identify themselves
+const wikimediaUserAgent = "imagesearch (https://github.com/commonkestrel/imagesearch)"
+
+func (w *wikimedia) Search(ctx context.Context, query Query) ([]Image, error) {
+    var response struct {
+        Query struct {
+            Pages []json.RawMessage `json:"pages"`
+        } `json:"query"`
+    }
+    header := http.Header{"User-Agent": {wikimediaUserAgent}}
+    if err := w.client.getJSON(ctx, buildWikimediaUrl(query), header, &response); err != nil {
+        return nil, err
+    }
+
+    var pages []wikimediaPage
+    var raws []json.RawMessage
+    for _, raw := range response.Query.Pages {
+        var page wikimediaPage
+        if err := json.Unmarshal(raw, &page); err != nil || len(page.Imageinfo) == 0 || page.Imageinfo[0].Url == "" {
+            continue
+        }
+        pages = append(pages, page)
+        raws = append(raws, raw)
+    }
+    // Pages come in no particular order
+    order := make([]int, len(pages))
+    for i := range order {
+        order[i] = i
+    }
+    sort.SliceStable(order, func(i, j int) bool { return pages[order[i]].Index < pages[order[j]].Index })
+
+    images := make([]Image, 0, len(pages))
+    for _, i := range order {
+        page, info := pages[i], pages[i].Imageinfo[0]
+        metadata := func(key string) string {
+            value, _ := info.Extmetadata[key].Value.(string)
+            return stripTags(value)
+        }
+
+        image := Image{
+            Url:         info.Url,
+            Source:      info.DescriptionUrl,
+            Title:       strings.TrimPrefix(page.Title, "File:"),
+            Description: metadata("ImageDescription"),
+            Width:       info.Width,
+            Height:      info.Height,
+            Raw:         raws[i],
+        }
+        if name := metadata("LicenseShortName"); name != "" {
+            image.License = &LicenseInfo{Licensable: true, Name: name, Url: metadata("LicenseUrl"), Author: metadata("Artist")}
+        }
+        images = append(images, image)
+    }
+    return images, nil
+}
+
+// The CirrusSearch keywords equivalent to the arguments, which are added to the search
+var wikimediaKeywords = map[string]string{
+    Format.Jpg:  "filemime:image/jpeg",
+    Format.Gif:  "filemime:image/gif",
+    Format.Png:  "filemime:image/png",
+    Format.Svg:  "filemime:image/svg+xml",
+    Format.Webp: "filemime:image/webp",
+    Format.Bmp:  "filemime:image/bmp",
+}
+
+func buildWikimediaUrl(query Query) string {
+    search := query.Text
+    for _, argument := range query.Arguments {
+        if keyword, ok := wikimediaKeywords[argument]; ok {
+            search += " " + keyword
+        }
+    }
+
+    values := url.Values{
+        "action":              {"query"},
+        "format":              {"json"},
+        "formatversion":       {"2"},
+        "generator":           {"search"},
+        "gsrsearch":           {search},
+        "gsrnamespace":        {"6"},
+        "gsrlimit":            {"50"},
+        "prop":                {"imageinfo"},
+        "iiprop":              {"url|size|extmetadata"},
+        "iiextmetadatafilter": {"LicenseShortName|LicenseUrl|Artist|ImageDescription"},
+    }
+    return "https://commons.wikimedia.org/w/api.php?" + values.Encode()
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Removes the html tags from the metadata Commons returns, like the links around author names.
+func stripTags(s string) string {
+    return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(s, "")))
+}